@@ -1,57 +1,68 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/BeatEcoprove/identityService/config"
 	"github.com/BeatEcoprove/identityService/internal"
+	"github.com/BeatEcoprove/identityService/internal/adapters"
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/repositories"
 	"github.com/BeatEcoprove/identityService/pkg/services"
 )
 
-func generatePki() error {
-	// Generate PKIS
-	if !services.MustCreatePKI() {
-		if err := services.GenerateServerPKI(); err != nil {
-			return err
-		}
-	}
-
-	// load pki
-	publicKey, privateKey, err := services.ReadKeys()
+func initPKIandJWKS() error {
+	return services.LoadKeyRing()
+}
 
-	if err != nil {
+// rotateKeys publishes a new signing key and exits, running instances pick it
+// up on their next key ring reload and start signing with it after the
+// publish lead.
+func rotateKeys() error {
+	if err := initPKIandJWKS(); err != nil {
 		return err
 	}
 
-	err = services.LoadKeys(privateKey, publicKey)
+	key, err := services.RotateKeys(services.KeyPublishLead())
 
 	if err != nil {
 		return err
 	}
 
+	log.Printf("🔑 signing key %s published, active from %s", key.Kid, key.ActivatesAt.Format(time.RFC3339))
 	return nil
 }
 
-func generateJWKS() error {
-	if _, err := services.NewJWKS(); err != nil {
+// registerClient stores a new OAuth client for the client credentials grant,
+// its secret is generated and printed once, only a hash is kept. Clients of
+// the authorization code flow also pass the redirect uris they may use.
+func registerClient(clientID, scopes, redirectURIs string) error {
+	secret, err := services.GenerateClientSecret()
+
+	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	client := domain.NewOAuthClient(clientID, secret, strings.Fields(scopes), strings.Fields(redirectURIs))
+	clientRepo := repositories.NewOAuthClientRepository(adapters.GetDatabase())
 
-func initPKIandJWKS() error {
-	if err := generatePki(); err != nil {
-		return err
+	if clientRepo.ExistsClientWithClientId(clientID) {
+		return fmt.Errorf("client %s is already registered", clientID)
 	}
 
-	if err := generateJWKS(); err != nil {
+	if err := clientRepo.Create(client); err != nil {
 		return err
 	}
 
+	log.Printf("🤖 client %s registered with scopes [%s]", clientID, scopes)
+	fmt.Printf("client_secret: %s\n", secret)
 	return nil
 }
 
@@ -72,11 +83,34 @@ func exitGracefully() {
 //	@in							header
 //	@name						Authorization
 //	@description Enter the token with the `Bearer: ` prefix, e.g. "Bearer eyJhbGciOiJSUzI1NiIsImtpZCI6IjQ5MjRhNmEx..."
+//	@securityDefinitions.basic	BasicAuth
 //
 // @ Schemas http
 func main() {
+	rotate := flag.Bool("rotate-keys", false, "publish a new signing key and exit")
+	clientID := flag.String("register-client", "", "register an OAuth client with this id, print its secret and exit")
+	scopes := flag.String("scopes", "", "space separated scopes the registered client may request")
+	redirectURIs := flag.String("redirect-uris", "", "space separated redirect uris the registered client may use in the authorization code flow")
+	flag.Parse()
+
 	config.LoadEnv(config.DotEnv)
 
+	if *rotate {
+		if err := rotateKeys(); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	if *clientID != "" {
+		if err := registerClient(*clientID, *scopes, *redirectURIs); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
 	err := initPKIandJWKS()
 
 	if err != nil {
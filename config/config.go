@@ -14,6 +14,7 @@ type Config struct {
 	POSTGRES_PORT     string
 
 	BEAT_IDENTITY_SERVER uint16
+	PUBLIC_URL           string
 
 	JWT_AUDIENCE        string
 	JWT_ISSUER          string
@@ -21,6 +22,67 @@ type Config struct {
 	JWT_REFRESH_EXPIRED int
 	JWT_SECRET          string
 
+	JWT_SIGNING_ALG       string
+	JWT_KEY_ROTATION_DAYS int
+	JWT_KEY_PUBLISH_LEAD  int
+
+	EMAIL_VERIFICATION_URL string
+
+	MFA_ENCRYPTION_KEY string
+
+	WEBAUTHN_RP_ID      string
+	WEBAUTHN_RP_NAME    string
+	WEBAUTHN_RP_ORIGINS string
+
+	OIDC_GOOGLE_CLIENT_ID   string
+	OIDC_APPLE_CLIENT_ID    string
+	OIDC_PROVIDER_NAME      string
+	OIDC_PROVIDER_ISSUER    string
+	OIDC_PROVIDER_CLIENT_ID string
+
+	PASSWORD_MIN_LENGTH       int
+	PASSWORD_MAX_LENGTH       int
+	PASSWORD_REQUIRED_CLASSES string
+	PASSWORD_BANNED_WORDS     string
+	PASSWORD_MIN_STRENGTH     int
+
+	PASSWORD_HISTORY_SIZE int
+
+	PASSWORD_HASH_MEMORY      int
+	PASSWORD_HASH_ITERATIONS  int
+	PASSWORD_HASH_PARALLELISM int
+
+	PASSWORD_BREACH_RANGE_DIR string
+	PASSWORD_BREACH_RANGE_URL string
+
+	ACCOUNT_DELETION_GRACE_DAYS int
+
+	LOGIN_MAX_ATTEMPTS    int
+	LOGIN_MAX_IP_ATTEMPTS int
+	LOGIN_ATTEMPTS_WINDOW int
+	LOGIN_LOCKOUT         int
+	LOGIN_MAX_LOCKOUT     int
+
+	RATE_LIMIT_SIGN_UP_IP            string
+	RATE_LIMIT_SIGN_UP_EMAIL         string
+	RATE_LIMIT_FORGOT_PASSWORD_IP    string
+	RATE_LIMIT_FORGOT_PASSWORD_EMAIL string
+	RATE_LIMIT_TOKEN_IP              string
+	RATE_LIMIT_TOKEN_EMAIL           string
+	RATE_LIMIT_CHECK_FIELD_IP        string
+	RATE_LIMIT_CHECK_FIELD_EMAIL     string
+	RATE_LIMIT_VERIFY_EMAIL_IP       string
+	RATE_LIMIT_VERIFY_EMAIL_EMAIL    string
+	RATE_LIMIT_CONFIRM_EMAIL_IP      string
+	RATE_LIMIT_LOGIN_CODE_IP         string
+	RATE_LIMIT_LOGIN_CODE_EMAIL      string
+	RATE_LIMIT_MFA_IP                string
+	RATE_LIMIT_MFA_EMAIL             string
+	RATE_LIMIT_RESTORE_IP            string
+	RATE_LIMIT_RESTORE_EMAIL         string
+	RATE_LIMIT_WEBAUTHN_LOGIN_IP     string
+	RATE_LIMIT_WEBAUTHN_LOGIN_EMAIL  string
+
 	REDIS_HOST string
 	REDIS_PORT string
 	REDIS_DB   int
@@ -58,6 +120,7 @@ func LoadEnv(path string) {
 		POSTGRES_PORT:     viper.GetString("POSTGRES_PORT"),
 
 		BEAT_IDENTITY_SERVER: viper.GetUint16("BEAT_IDENTITY_SERVER"),
+		PUBLIC_URL:           viper.GetString("PUBLIC_URL"),
 
 		JWT_AUDIENCE:        viper.GetString("JWT_AUDIENCE"),
 		JWT_ISSUER:          viper.GetString("JWT_ISSUER"),
@@ -65,6 +128,67 @@ func LoadEnv(path string) {
 		JWT_REFRESH_EXPIRED: viper.GetInt("JWT_REFRESH_EXPIRED"),
 		JWT_SECRET:          viper.GetString("JWT_SECRET"),
 
+		JWT_SIGNING_ALG:       viper.GetString("JWT_SIGNING_ALG"),
+		JWT_KEY_ROTATION_DAYS: viper.GetInt("JWT_KEY_ROTATION_DAYS"),
+		JWT_KEY_PUBLISH_LEAD:  viper.GetInt("JWT_KEY_PUBLISH_LEAD"),
+
+		EMAIL_VERIFICATION_URL: viper.GetString("EMAIL_VERIFICATION_URL"),
+
+		MFA_ENCRYPTION_KEY: viper.GetString("MFA_ENCRYPTION_KEY"),
+
+		WEBAUTHN_RP_ID:      viper.GetString("WEBAUTHN_RP_ID"),
+		WEBAUTHN_RP_NAME:    viper.GetString("WEBAUTHN_RP_NAME"),
+		WEBAUTHN_RP_ORIGINS: viper.GetString("WEBAUTHN_RP_ORIGINS"),
+
+		OIDC_GOOGLE_CLIENT_ID:   viper.GetString("OIDC_GOOGLE_CLIENT_ID"),
+		OIDC_APPLE_CLIENT_ID:    viper.GetString("OIDC_APPLE_CLIENT_ID"),
+		OIDC_PROVIDER_NAME:      viper.GetString("OIDC_PROVIDER_NAME"),
+		OIDC_PROVIDER_ISSUER:    viper.GetString("OIDC_PROVIDER_ISSUER"),
+		OIDC_PROVIDER_CLIENT_ID: viper.GetString("OIDC_PROVIDER_CLIENT_ID"),
+
+		PASSWORD_MIN_LENGTH:       viper.GetInt("PASSWORD_MIN_LENGTH"),
+		PASSWORD_MAX_LENGTH:       viper.GetInt("PASSWORD_MAX_LENGTH"),
+		PASSWORD_REQUIRED_CLASSES: viper.GetString("PASSWORD_REQUIRED_CLASSES"),
+		PASSWORD_BANNED_WORDS:     viper.GetString("PASSWORD_BANNED_WORDS"),
+		PASSWORD_MIN_STRENGTH:     viper.GetInt("PASSWORD_MIN_STRENGTH"),
+
+		PASSWORD_HISTORY_SIZE: viper.GetInt("PASSWORD_HISTORY_SIZE"),
+
+		PASSWORD_HASH_MEMORY:      viper.GetInt("PASSWORD_HASH_MEMORY"),
+		PASSWORD_HASH_ITERATIONS:  viper.GetInt("PASSWORD_HASH_ITERATIONS"),
+		PASSWORD_HASH_PARALLELISM: viper.GetInt("PASSWORD_HASH_PARALLELISM"),
+
+		PASSWORD_BREACH_RANGE_DIR: viper.GetString("PASSWORD_BREACH_RANGE_DIR"),
+		PASSWORD_BREACH_RANGE_URL: viper.GetString("PASSWORD_BREACH_RANGE_URL"),
+
+		ACCOUNT_DELETION_GRACE_DAYS: viper.GetInt("ACCOUNT_DELETION_GRACE_DAYS"),
+
+		LOGIN_MAX_ATTEMPTS:    viper.GetInt("LOGIN_MAX_ATTEMPTS"),
+		LOGIN_MAX_IP_ATTEMPTS: viper.GetInt("LOGIN_MAX_IP_ATTEMPTS"),
+		LOGIN_ATTEMPTS_WINDOW: viper.GetInt("LOGIN_ATTEMPTS_WINDOW"),
+		LOGIN_LOCKOUT:         viper.GetInt("LOGIN_LOCKOUT"),
+		LOGIN_MAX_LOCKOUT:     viper.GetInt("LOGIN_MAX_LOCKOUT"),
+
+		RATE_LIMIT_SIGN_UP_IP:            viper.GetString("RATE_LIMIT_SIGN_UP_IP"),
+		RATE_LIMIT_SIGN_UP_EMAIL:         viper.GetString("RATE_LIMIT_SIGN_UP_EMAIL"),
+		RATE_LIMIT_FORGOT_PASSWORD_IP:    viper.GetString("RATE_LIMIT_FORGOT_PASSWORD_IP"),
+		RATE_LIMIT_FORGOT_PASSWORD_EMAIL: viper.GetString("RATE_LIMIT_FORGOT_PASSWORD_EMAIL"),
+		RATE_LIMIT_TOKEN_IP:              viper.GetString("RATE_LIMIT_TOKEN_IP"),
+		RATE_LIMIT_TOKEN_EMAIL:           viper.GetString("RATE_LIMIT_TOKEN_EMAIL"),
+		RATE_LIMIT_CHECK_FIELD_IP:        viper.GetString("RATE_LIMIT_CHECK_FIELD_IP"),
+		RATE_LIMIT_CHECK_FIELD_EMAIL:     viper.GetString("RATE_LIMIT_CHECK_FIELD_EMAIL"),
+		RATE_LIMIT_VERIFY_EMAIL_IP:       viper.GetString("RATE_LIMIT_VERIFY_EMAIL_IP"),
+		RATE_LIMIT_VERIFY_EMAIL_EMAIL:    viper.GetString("RATE_LIMIT_VERIFY_EMAIL_EMAIL"),
+		RATE_LIMIT_CONFIRM_EMAIL_IP:      viper.GetString("RATE_LIMIT_CONFIRM_EMAIL_IP"),
+		RATE_LIMIT_LOGIN_CODE_IP:         viper.GetString("RATE_LIMIT_LOGIN_CODE_IP"),
+		RATE_LIMIT_LOGIN_CODE_EMAIL:      viper.GetString("RATE_LIMIT_LOGIN_CODE_EMAIL"),
+		RATE_LIMIT_MFA_IP:                viper.GetString("RATE_LIMIT_MFA_IP"),
+		RATE_LIMIT_MFA_EMAIL:             viper.GetString("RATE_LIMIT_MFA_EMAIL"),
+		RATE_LIMIT_RESTORE_IP:            viper.GetString("RATE_LIMIT_RESTORE_IP"),
+		RATE_LIMIT_RESTORE_EMAIL:         viper.GetString("RATE_LIMIT_RESTORE_EMAIL"),
+		RATE_LIMIT_WEBAUTHN_LOGIN_IP:     viper.GetString("RATE_LIMIT_WEBAUTHN_LOGIN_IP"),
+		RATE_LIMIT_WEBAUTHN_LOGIN_EMAIL:  viper.GetString("RATE_LIMIT_WEBAUTHN_LOGIN_EMAIL"),
+
 		REDIS_HOST: viper.GetString("REDIS_HOST"),
 		REDIS_PORT: viper.GetString("REDIS_PORT"),
 		REDIS_DB:   viper.GetInt("REDIS_DB"),
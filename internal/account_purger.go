@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"log"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/internal/usecases"
+)
+
+const accountPurgeInterval = time.Hour
+
+// AccountPurger erases the accounts whose deletion grace period is over, it
+// runs once an hour the way KeyRotator does.
+type AccountPurger struct {
+	purge *usecases.PurgeDeletedAccountsUseCase
+	done  chan struct{}
+}
+
+func NewAccountPurger(purge *usecases.PurgeDeletedAccountsUseCase) *AccountPurger {
+	return &AccountPurger{
+		purge: purge,
+		done:  make(chan struct{}),
+	}
+}
+
+func (ap *AccountPurger) Check() error {
+	purged, err := ap.purge.Handle(usecases.PurgeDeletedAccountsInput{Now: time.Now()})
+
+	if err != nil {
+		return err
+	}
+
+	if purged > 0 {
+		log.Printf("🗑️ %d deleted accounts erased", purged)
+	}
+
+	return nil
+}
+
+func (ap *AccountPurger) Start() {
+	ticker := time.NewTicker(accountPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ap.done:
+			return
+		case <-ticker.C:
+			if err := ap.Check(); err != nil {
+				log.Printf("failed to purge deleted accounts: %s", err.Error())
+			}
+		}
+	}
+}
+
+func (ap *AccountPurger) Close() error {
+	close(ap.done)
+	return nil
+}
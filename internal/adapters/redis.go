@@ -57,6 +57,68 @@ func (r *RedisConnection) GetAndDelValue(key interfaces.RedisKey) (string, error
 	return r.client.GetDel(r.ctx, key.Key).Result()
 }
 
+func (r *RedisConnection) DelValue(keys ...interfaces.RedisKey) error {
+	return r.client.Del(r.ctx, toRawKeys(keys)...).Err()
+}
+
+// Increment bumps a counter and restarts its expiration, both in a single
+// round trip.
+func (r *RedisConnection) Increment(key interfaces.RedisKey, expiration time.Duration) (int64, error) {
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(r.ctx, key.Key)
+	pipe.Expire(r.ctx, key.Key, expiration)
+
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// TimeToLive is negative when the key doesn't exist or never expires.
+func (r *RedisConnection) TimeToLive(key interfaces.RedisKey) (time.Duration, error) {
+	return r.client.TTL(r.ctx, key.Key).Result()
+}
+
+// AddToSet adds the members and restarts the expiration of the set, both in
+// a single round trip.
+func (r *RedisConnection) AddToSet(key interfaces.RedisKey, expiration time.Duration, members ...string) error {
+	pipe := r.client.TxPipeline()
+	pipe.SAdd(r.ctx, key.Key, toAnySlice(members)...)
+	pipe.Expire(r.ctx, key.Key, expiration)
+
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+func (r *RedisConnection) GetSetMembers(key interfaces.RedisKey) ([]string, error) {
+	return r.client.SMembers(r.ctx, key.Key).Result()
+}
+
+func (r *RedisConnection) RemoveFromSet(key interfaces.RedisKey, members ...string) error {
+	return r.client.SRem(r.ctx, key.Key, toAnySlice(members)...).Err()
+}
+
 func (r *RedisConnection) Close() error {
 	return r.client.Close()
 }
+
+func toRawKeys(keys []interfaces.RedisKey) []string {
+	rawKeys := make([]string, len(keys))
+
+	for i, key := range keys {
+		rawKeys[i] = key.Key
+	}
+
+	return rawKeys
+}
+
+func toAnySlice(values []string) []any {
+	result := make([]any, len(values))
+
+	for i, value := range values {
+		result[i] = value
+	}
+
+	return result
+}
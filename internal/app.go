@@ -31,6 +31,8 @@ type App struct {
 	UseCases      *usecases.UseCases
 	Services      *services.Services
 	EventHandlers *handlers.EventHandlers
+	KeyRotator    *services.KeyRotator
+	AccountPurger *AccountPurger
 }
 
 type Controllers struct {
@@ -49,47 +51,87 @@ func NewApp() (*App, error) {
 	}
 
 	repos := &repositories.Repositories{
-		Auth:       repositories.NewAuthRepository(db),
-		Profile:    repositories.NewProfileRepository(db),
-		MemberChat: repositories.NewMemberChatRepository(db),
+		Auth:        repositories.NewAuthRepository(db),
+		Profile:     repositories.NewProfileRepository(db),
+		MemberChat:  repositories.NewMemberChatRepository(db),
+		OAuthClient: repositories.NewOAuthClientRepository(db),
+
+		RecoveryCode:       repositories.NewRecoveryCodeRepository(db),
+		WebAuthnCredential: repositories.NewWebAuthnCredentialRepository(db),
+		ExternalIdentity:   repositories.NewExternalIdentityRepository(db),
+		PasswordHistory:    repositories.NewPasswordHistoryRepository(db),
 	}
 
+	keyRotator := services.NewKeyRotator()
+
 	services := &services.Services{
 		Token: services.NewTokenService(redis),
 		PG:    services.NewPGService(redis),
 		Email: services.NewEmailService(kafkaPub),
+
+		AuthorizationCode: services.NewAuthorizationCodeService(redis),
+		LoginAttempt:      services.NewLoginAttemptService(redis),
+		RateLimiter:       services.NewRateLimiter(redis),
+		EmailVerification: services.NewEmailVerificationService(redis),
+		MFAChallenge:      services.NewMFAChallengeService(redis),
+		TOTP:              services.NewTOTPService(redis),
+		WebAuthn:          services.NewWebAuthnService(redis),
+		LoginCode:         services.NewLoginCodeService(redis),
+		Federation:        services.NewFederationService(),
+		BreachedPassword:  services.NewBreachedPasswordService(services.NewPasswordRangeProvider()),
 	}
 
 	createProfileService := helpers.NewProfileCreateService(repos.Profile, kafkaPub, redis)
 	usecases := &usecases.UseCases{
-		ProfileCreateService: createProfileService,
-		Sign:                 usecases.NewSignUpUseCase(repos.Auth, repos.Profile, services.Token, services.Email, createProfileService),
-		Login:                usecases.NewLoginUseCase(repos.Auth, repos.Profile, services.Token),
-		AttachProfile:        usecases.NewAttachProfileUseCase(repos.Auth, repos.Profile, services.Token, createProfileService),
-		RefreshTokens:        usecases.NewRefreshTokensUseCase(repos.Auth, repos.Profile, services.Token),
-		ForgotPassword:       usecases.NewForgotPasswordUseCase(repos.Auth, services.PG, services.Email),
-		ResetPassword:        usecases.NewResetPasswdUseCase(repos.Auth, services.PG, services.Email),
-		CheckFields:          usecases.NewCheckFieldUseCase(repos.Auth),
-		FetchPermissions:     usecases.NewFetchGroupUserPermissionsUseCase(repos.MemberChat),
+		ProfileCreateService:       createProfileService,
+		Sign:                       usecases.NewSignUpUseCase(repos.Auth, repos.Profile, services.Token, services.Email, services.EmailVerification, createProfileService, services.BreachedPassword),
+		Login:                      usecases.NewLoginUseCase(repos.Auth, repos.Profile, services.Token, services.LoginAttempt, services.MFAChallenge, kafkaPub),
+		AttachProfile:              usecases.NewAttachProfileUseCase(repos.Auth, repos.Profile, services.Token, createProfileService),
+		RefreshTokens:              usecases.NewRefreshTokensUseCase(repos.Auth, repos.Profile, services.Token, kafkaPub),
+		ForgotPassword:             usecases.NewForgotPasswordUseCase(repos.Auth, services.PG, services.Email),
+		ResetPassword:              usecases.NewResetPasswdUseCase(repos.Auth, services.PG, services.Email, services.BreachedPassword, repos.PasswordHistory),
+		CheckFields:                usecases.NewCheckFieldUseCase(repos.Auth),
+		FetchPermissions:           usecases.NewFetchGroupUserPermissionsUseCase(repos.MemberChat),
+		ListSessions:               usecases.NewListSessionsUseCase(services.Token),
+		RevokeSession:              usecases.NewRevokeSessionUseCase(services.Token),
+		RevokeAllSessions:          usecases.NewRevokeAllSessionsUseCase(services.Token),
+		RevokeToken:                usecases.NewRevokeTokenUseCase(services.Token),
+		IntrospectToken:            usecases.NewIntrospectTokenUseCase(repos.Auth, services.Token),
+		Authorize:                  usecases.NewAuthorizeUseCase(repos.Auth, repos.OAuthClient, services.AuthorizationCode, services.LoginAttempt, services.MFAChallenge, services.TOTP, repos.RecoveryCode, kafkaPub),
+		AuthorizationCode:          usecases.NewAuthorizationCodeUseCase(repos.Auth, repos.Profile, services.Token, services.AuthorizationCode),
+		ClientCredentials:          usecases.NewClientCredentialsUseCase(repos.OAuthClient, services.Token),
+		VerifyEmail:                usecases.NewVerifyEmailUseCase(repos.Auth, services.EmailVerification),
+		ResendVerificationEmail:    usecases.NewResendVerificationEmailUseCase(repos.Auth, services.EmailVerification, services.Email),
+		MFAOTP:                     usecases.NewMFAOTPUseCase(repos.Auth, repos.Profile, services.Token, services.MFAChallenge, services.TOTP, repos.RecoveryCode, kafkaPub),
+		EnrollTOTP:                 usecases.NewEnrollTOTPUseCase(repos.Auth),
+		ConfirmTOTP:                usecases.NewConfirmTOTPUseCase(repos.Auth, repos.RecoveryCode, services.TOTP),
+		DisableTOTP:                usecases.NewDisableTOTPUseCase(repos.Auth, repos.RecoveryCode, services.TOTP),
+		RegenerateRecoveryCodes:    usecases.NewRegenerateRecoveryCodesUseCase(repos.Auth, repos.RecoveryCode, services.TOTP),
+		BeginWebAuthnRegistration:  usecases.NewBeginWebAuthnRegistrationUseCase(repos.Auth, repos.WebAuthnCredential, services.WebAuthn),
+		FinishWebAuthnRegistration: usecases.NewFinishWebAuthnRegistrationUseCase(repos.Auth, repos.WebAuthnCredential, services.WebAuthn),
+		BeginWebAuthnLogin:         usecases.NewBeginWebAuthnLoginUseCase(services.WebAuthn),
+		WebAuthn:                   usecases.NewWebAuthnUseCase(repos.Auth, repos.Profile, repos.WebAuthnCredential, services.Token, services.WebAuthn),
+		SendLoginCode:              usecases.NewSendLoginCodeUseCase(repos.Auth, services.LoginCode, services.Email),
+		LoginCode:                  usecases.NewLoginCodeUseCase(repos.Auth, repos.Profile, services.Token, services.LoginCode, services.MFAChallenge),
+		FederatedLogin:             usecases.NewFederatedLoginUseCase(repos.Auth, repos.Profile, repos.ExternalIdentity, services.Token, services.Federation, services.MFAChallenge, kafkaPub, createProfileService),
+		ListIdentities:             usecases.NewListIdentitiesUseCase(repos.ExternalIdentity),
+		LinkIdentity:               usecases.NewLinkIdentityUseCase(repos.Auth, repos.ExternalIdentity, services.Token, services.Federation, services.LoginAttempt, kafkaPub),
+		UnlinkIdentity:             usecases.NewUnlinkIdentityUseCase(repos.Auth, repos.ExternalIdentity, repos.WebAuthnCredential, kafkaPub),
+		ChangePassword:             usecases.NewChangePasswordUseCase(repos.Auth, services.Token, services.Email, services.BreachedPassword, repos.PasswordHistory, services.LoginAttempt, kafkaPub),
+		DeleteAccount:              usecases.NewDeleteAccountUseCase(repos.Auth, services.Token, services.LoginAttempt, kafkaPub),
+		RestoreAccount:             usecases.NewRestoreAccountUseCase(repos.Auth, services.LoginAttempt, services.MFAChallenge, services.TOTP, repos.RecoveryCode, kafkaPub),
+		PurgeDeletedAccounts:       usecases.NewPurgeDeletedAccountsUseCase(repos.Auth, repos.Profile, services.Token, kafkaPub),
 	}
 
 	middlewares := &middlewares.Middlewares{
 		Authorization: middlewares.NewAuthorizationMiddleware(repos.Auth, services.Token),
+		Service:       middlewares.NewServiceAuthMiddleware(repos.OAuthClient),
+		RateLimit:     middlewares.NewRateLimitMiddleware(services.RateLimiter),
 	}
 
 	controllers := &Controllers{
 		Static: NewStaticController(),
-		Auth: NewAuthController(
-			usecases.Sign,
-			usecases.Login,
-			usecases.AttachProfile,
-			usecases.RefreshTokens,
-			usecases.ForgotPassword,
-			usecases.ResetPassword,
-			usecases.CheckFields,
-			middlewares.Authorization,
-			usecases.FetchPermissions,
-		),
+		Auth:   NewAuthController(usecases, middlewares),
 	}
 
 	eventHandlers := &handlers.EventHandlers{
@@ -114,6 +156,8 @@ func NewApp() (*App, error) {
 		UseCases:      usecases,
 		Services:      services,
 		EventHandlers: eventHandlers,
+		KeyRotator:    keyRotator,
+		AccountPurger: NewAccountPurger(usecases.PurgeDeletedAccounts),
 	}, nil
 }
 
@@ -138,6 +182,8 @@ func (app *App) Serve() {
 
 	go app.HTTPServer.Serve(env.BEAT_IDENTITY_SERVER)
 	go app.Consumer.Consume()
+	go app.KeyRotator.Start()
+	go app.AccountPurger.Start()
 }
 
 func initKafka() (*adapters.KafkaPublisher, *adapters.KafkaConsumer, error) {
@@ -161,6 +207,8 @@ func (app *App) Close() error {
 	app.Redis.Close()
 	app.Publisher.Close()
 	app.Consumer.Close()
+	app.KeyRotator.Close()
+	app.AccountPurger.Close()
 
 	return nil
 }
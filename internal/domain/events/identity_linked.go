@@ -0,0 +1,15 @@
+package events
+
+import "time"
+
+type IdentityLinkedEvent struct {
+	AuthID   string    `json:"auth_id"`
+	Email    string    `json:"email"`
+	Provider string    `json:"provider"`
+	Subject  string    `json:"subject"`
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+func (e *IdentityLinkedEvent) GetEventType() string {
+	return "identity_linked"
+}
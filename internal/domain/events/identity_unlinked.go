@@ -0,0 +1,15 @@
+package events
+
+import "time"
+
+type IdentityUnlinkedEvent struct {
+	AuthID     string    `json:"auth_id"`
+	Email      string    `json:"email"`
+	Provider   string    `json:"provider"`
+	Subject    string    `json:"subject"`
+	UnlinkedAt time.Time `json:"unlinked_at"`
+}
+
+func (e *IdentityUnlinkedEvent) GetEventType() string {
+	return "identity_unlinked"
+}
@@ -0,0 +1,16 @@
+package events
+
+import "time"
+
+type RecoveryCodeUsedEvent struct {
+	AuthID    string    `json:"auth_id"`
+	Email     string    `json:"email"`
+	Remaining int       `json:"remaining"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	UsedAt    time.Time `json:"used_at"`
+}
+
+func (e *RecoveryCodeUsedEvent) GetEventType() string {
+	return "recovery_code_used"
+}
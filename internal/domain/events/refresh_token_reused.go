@@ -0,0 +1,12 @@
+package events
+
+type RefreshTokenReusedEvent struct {
+	AuthID    string `json:"auth_id"`
+	SessionID string `json:"session_id"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+func (e *RefreshTokenReusedEvent) GetEventType() string {
+	return "refresh_token_reused"
+}
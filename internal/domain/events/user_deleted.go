@@ -0,0 +1,17 @@
+package events
+
+import "time"
+
+// UserDeletedEvent is sent once an account is erased for good, services
+// holding data of the user or its profiles must erase it too.
+type UserDeletedEvent struct {
+	AuthID              string    `json:"auth_id"`
+	Email               string    `json:"email"`
+	ProfileIDs          []string  `json:"profile_ids"`
+	DeletionRequestedAt time.Time `json:"deletion_requested_at"`
+	DeletedAt           time.Time `json:"deleted_at"`
+}
+
+func (e *UserDeletedEvent) GetEventType() string {
+	return "user_deleted"
+}
@@ -0,0 +1,16 @@
+package events
+
+import "time"
+
+type UserLockedEvent struct {
+	AuthID      string    `json:"auth_id"`
+	Email       string    `json:"email"`
+	Attempts    int64     `json:"attempts"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func (e *UserLockedEvent) GetEventType() string {
+	return "user_locked"
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	interfaces "github.com/BeatEcoprove/identityService/pkg/domain"
+)
+
+// ExternalIdentity links an account to the subject an identity provider
+// knows it by, the provider's email may change but the subject doesn't.
+type ExternalIdentity struct {
+	interfaces.EntityBase
+	AuthID   string
+	Provider string
+	Subject  string
+	Email    string
+}
+
+func NewExternalIdentity(authID, provider, subject, email string) *ExternalIdentity {
+	identity := &ExternalIdentity{
+		AuthID:   authID,
+		Provider: provider,
+		Subject:  subject,
+		Email:    email,
+	}
+
+	identity.GetId()
+	return identity
+}
+
+func (i *ExternalIdentity) TableName() string {
+	return "external_identities"
+}
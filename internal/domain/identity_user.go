@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"time"
+
 	interfaces "github.com/BeatEcoprove/identityService/pkg/domain"
 	"github.com/BeatEcoprove/identityService/pkg/services"
 	"gorm.io/gorm"
@@ -17,11 +19,20 @@ const (
 
 type IdentityUser struct {
 	interfaces.EntityBase
-	Email    string
-	Password string
-	Salt     string `gorm:"column:salt"`
-	IsActive bool
-	Role     AuthRole
+	Email         string
+	Password      string
+	Salt          string `gorm:"column:salt"`
+	IsActive      bool
+	EmailVerified bool `gorm:"column:email_verified"`
+	Role          AuthRole
+
+	// TotpSecret is encrypted, it is kept while enrollment is unconfirmed
+	TotpSecret  string `gorm:"column:totp_secret"`
+	TotpEnabled bool   `gorm:"column:totp_enabled"`
+
+	// DeletionRequestedAt is set while the account waits to be erased, it
+	// can't sign in until the deletion is cancelled
+	DeletionRequestedAt *time.Time `gorm:"column:deletion_requested_at"`
 }
 
 func NewIdentityUser(email, password string, role AuthRole) *IdentityUser {
@@ -37,32 +48,89 @@ func (b *IdentityUser) GetRole() AuthRole {
 	return b.Role
 }
 
-func (b *IdentityUser) TableName() string {
-	return "auths"
+func (b *IdentityUser) VerifyEmail() {
+	b.EmailVerified = true
 }
 
-func (u *IdentityUser) SetPassword(value string) error {
-	salt, err := services.GenerateSalt(services.SALT_COST)
+// EnrollTOTP replaces any unconfirmed secret, the second factor is only
+// asked for once EnableTOTP confirmed it.
+func (b *IdentityUser) EnrollTOTP(encryptedSecret string) {
+	b.TotpSecret = encryptedSecret
+	b.TotpEnabled = false
+}
 
-	if err != nil {
-		return err
+func (b *IdentityUser) EnableTOTP() {
+	b.TotpEnabled = true
+}
+
+func (b *IdentityUser) DisableTOTP() {
+	b.TotpSecret = ""
+	b.TotpEnabled = false
+}
+
+func (b *IdentityUser) HasMFA() bool {
+	return b.TotpEnabled
+}
+
+// HasPassword is false for accounts signed up through an identity provider
+// until a password is set through forgot-password.
+func (u *IdentityUser) HasPassword() bool {
+	return u.Password != ""
+}
+
+func (u *IdentityUser) RequestDeletion(at time.Time) {
+	u.DeletionRequestedAt = &at
+}
+
+func (u *IdentityUser) CancelDeletion() {
+	u.DeletionRequestedAt = nil
+}
+
+func (u *IdentityUser) IsPendingDeletion() bool {
+	return u.DeletionRequestedAt != nil
+}
+
+// DeletesAt is when an account pending deletion is erased, grace after
+// it was requested.
+func (u *IdentityUser) DeletesAt(grace time.Duration) time.Time {
+	if u.DeletionRequestedAt == nil {
+		return time.Time{}
 	}
 
-	password, err := services.HashPassword(value, salt)
+	return u.DeletionRequestedAt.Add(grace)
+}
+
+func (b *IdentityUser) TableName() string {
+	return "auths"
+}
+
+// SetPassword hashes value with argon2id, the salt is part of the hash so
+// the salt column is only kept for legacy bcrypt hashes.
+func (u *IdentityUser) SetPassword(value string) error {
+	password, err := services.HashPassword(value)
 
 	if err != nil {
 		return err
 	}
 
-	u.Salt = salt
+	u.Salt = ""
 	u.Password = password
 	return nil
 }
 
+// PasswordNeedsRehash is true while the password hash is legacy bcrypt or
+// uses outdated costs.
+func (u *IdentityUser) PasswordNeedsRehash() bool {
+	return u.HasPassword() && services.NeedsRehash(u.Password)
+}
+
 func (u *IdentityUser) BeforeCreate(tx *gorm.DB) error {
 	u.GetId()
 
-	u.SetPassword(u.Password)
+	if u.HasPassword() {
+		u.SetPassword(u.Password)
+	}
+
 	u.DeletedAt = nil
 	return nil
 }
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+
+	interfaces "github.com/BeatEcoprove/identityService/pkg/domain"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"gorm.io/gorm"
+)
+
+const (
+	// internal scopes, only granted to registered clients
+	ScopeGroupPermissions Permission = "group:permissions"
+	ScopeTokenIntrospect  Permission = "token:introspect"
+)
+
+// OAuthClient is a service registered to authenticate with the client
+// credentials grant, Scopes is the space separated list it may request.
+// Clients of the authorization code flow also register the space separated
+// RedirectURIs codes may be sent to.
+type OAuthClient struct {
+	interfaces.EntityBase
+	ClientID     string
+	Secret       string
+	Scopes       string
+	RedirectURIs string `gorm:"column:redirect_uris"`
+}
+
+func NewOAuthClient(clientID, secret string, scopes, redirectURIs []string) *OAuthClient {
+	return &OAuthClient{
+		ClientID:     clientID,
+		Secret:       secret,
+		Scopes:       strings.Join(scopes, " "),
+		RedirectURIs: strings.Join(redirectURIs, " "),
+	}
+}
+
+func (c *OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+func (c *OAuthClient) AllowedScopes() []string {
+	return strings.Fields(c.Scopes)
+}
+
+// AllowsRedirectURI compares against the registered uris exactly, as
+// RFC 9700 section 2.1 recommends.
+func (c *OAuthClient) AllowsRedirectURI(redirectURI string) bool {
+	return redirectURI != "" && slices.Contains(strings.Fields(c.RedirectURIs), redirectURI)
+}
+
+// GrantScopes returns the scopes a token may carry, all the allowed ones when
+// none were requested, and false when a requested scope isn't allowed.
+func (c *OAuthClient) GrantScopes(requested []string) ([]string, bool) {
+	allowed := c.AllowedScopes()
+
+	if len(requested) == 0 {
+		return allowed, true
+	}
+
+	for _, scope := range requested {
+		if !slices.Contains(allowed, scope) {
+			return nil, false
+		}
+	}
+
+	return requested, true
+}
+
+func (c *OAuthClient) SetSecret(value string) error {
+	secret, err := services.HashPassword(value)
+
+	if err != nil {
+		return err
+	}
+
+	c.Secret = secret
+	return nil
+}
+
+func (c *OAuthClient) VerifySecret(value string) bool {
+	return services.CheckArgon2idHash(value, c.Secret)
+}
+
+func (c *OAuthClient) BeforeCreate(tx *gorm.DB) error {
+	c.GetId()
+
+	if err := c.SetSecret(c.Secret); err != nil {
+		return err
+	}
+
+	c.DeletedAt = nil
+	return nil
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	interfaces "github.com/BeatEcoprove/identityService/pkg/domain"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+// PasswordHistory is a password the account used before, kept hashed as it
+// was so it can't be chosen again.
+type PasswordHistory struct {
+	interfaces.EntityBase
+	AuthID   string
+	Password string
+	Salt     string `gorm:"column:salt"`
+}
+
+// NewPasswordHistory remembers the password identityUser has right now,
+// call it before the password is replaced.
+func NewPasswordHistory(identityUser *IdentityUser) *PasswordHistory {
+	history := &PasswordHistory{
+		AuthID:   identityUser.ID,
+		Password: identityUser.Password,
+		Salt:     identityUser.Salt,
+	}
+
+	history.GetId()
+	return history
+}
+
+func (h *PasswordHistory) TableName() string {
+	return "password_history"
+}
+
+func (h *PasswordHistory) Matches(value string) bool {
+	return services.CheckPasswordHash(value, h.Salt, h.Password)
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	interfaces "github.com/BeatEcoprove/identityService/pkg/domain"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+// RecoveryCode stands in for the second factor once, when the authenticator
+// app is lost. Only a keyed hash of the code is stored, checking one against
+// every code of an account stays cheap.
+type RecoveryCode struct {
+	interfaces.EntityBase
+	AuthID string
+	Code   string
+	UsedAt *time.Time `gorm:"column:used_at"`
+}
+
+func NewRecoveryCode(authID, code string) (*RecoveryCode, error) {
+	recoveryCode := &RecoveryCode{
+		AuthID: authID,
+	}
+
+	recoveryCode.GetId()
+
+	if err := recoveryCode.SetCode(code); err != nil {
+		return nil, err
+	}
+
+	return recoveryCode, nil
+}
+
+func (c *RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// NormalizeRecoveryCode accepts a code however it was typed, with or
+// without the dash and in any case.
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+func (c *RecoveryCode) SetCode(value string) error {
+	code, err := services.HashRecoveryCode(NormalizeRecoveryCode(value))
+
+	if err != nil {
+		return err
+	}
+
+	c.Code = code
+	return nil
+}
+
+func (c *RecoveryCode) Matches(value string) bool {
+	if c.UsedAt != nil {
+		return false
+	}
+
+	code, err := services.HashRecoveryCode(NormalizeRecoveryCode(value))
+	return err == nil && subtle.ConstantTimeCompare([]byte(code), []byte(c.Code)) == 1
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	interfaces "github.com/BeatEcoprove/identityService/pkg/domain"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+type (
+	// WebAuthnCredential is a passkey registered by a user, only its public
+	// key is known to the server.
+	WebAuthnCredential struct {
+		interfaces.EntityBase
+		AuthID          string
+		CredentialID    []byte `gorm:"column:credential_id"`
+		PublicKey       []byte `gorm:"column:public_key"`
+		AttestationType string `gorm:"column:attestation_type"`
+		Transports      string `gorm:"column:transports"`
+		AAGUID          []byte `gorm:"column:aaguid"`
+		SignCount       uint32 `gorm:"column:sign_count"`
+		BackupEligible  bool   `gorm:"column:backup_eligible"`
+		BackupState     bool   `gorm:"column:backup_state"`
+		Name            string
+		LastUsedAt      *time.Time `gorm:"column:last_used_at"`
+	}
+
+	// WebAuthnUser is the account as seen by the webauthn ceremonies, the
+	// user handle is the auth id.
+	WebAuthnUser struct {
+		user        *IdentityUser
+		credentials []WebAuthnCredential
+	}
+)
+
+func NewWebAuthnCredential(authID, name string, credential *webauthn.Credential) *WebAuthnCredential {
+	transports := make([]string, len(credential.Transport))
+
+	for i, transport := range credential.Transport {
+		transports[i] = string(transport)
+	}
+
+	webAuthnCredential := &WebAuthnCredential{
+		AuthID:          authID,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Name:            name,
+	}
+
+	webAuthnCredential.GetId()
+	return webAuthnCredential
+}
+
+func (c *WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+func (c *WebAuthnCredential) Credential() webauthn.Credential {
+	var transports []protocol.AuthenticatorTransport
+
+	for _, transport := range strings.Split(c.Transports, ",") {
+		if transport != "" {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+	}
+
+	return webauthn.Credential{
+		ID:              c.CredentialID,
+		PublicKey:       c.PublicKey,
+		AttestationType: c.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			BackupEligible: c.BackupEligible,
+			BackupState:    c.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    c.AAGUID,
+			SignCount: c.SignCount,
+		},
+	}
+}
+
+// MarkUsed keeps the signature counter of the last login, a counter that
+// goes backwards points at a cloned authenticator.
+func (c *WebAuthnCredential) MarkUsed(authenticator webauthn.Authenticator, flags webauthn.CredentialFlags) {
+	now := time.Now()
+
+	c.SignCount = authenticator.SignCount
+	c.BackupState = flags.BackupState
+	c.LastUsedAt = &now
+}
+
+func NewWebAuthnUser(user *IdentityUser, credentials []WebAuthnCredential) *WebAuthnUser {
+	return &WebAuthnUser{
+		user:        user,
+		credentials: credentials,
+	}
+}
+
+func (u *WebAuthnUser) User() *IdentityUser {
+	return u.user
+}
+
+func (u *WebAuthnUser) WebAuthnID() []byte {
+	return []byte(u.user.ID)
+}
+
+func (u *WebAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *WebAuthnUser) WebAuthnDisplayName() string {
+	return u.user.Email
+}
+
+func (u *WebAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *WebAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, len(u.credentials))
+
+	for i := range u.credentials {
+		credentials[i] = u.credentials[i].Credential()
+	}
+
+	return credentials
+}
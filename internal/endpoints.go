@@ -2,9 +2,12 @@ package internal
 
 import (
 	"strconv"
+	"strings"
 
+	"github.com/BeatEcoprove/identityService/internal/domain"
 	"github.com/BeatEcoprove/identityService/internal/middlewares"
 	"github.com/BeatEcoprove/identityService/internal/usecases"
+	"github.com/BeatEcoprove/identityService/internal/views"
 	"github.com/BeatEcoprove/identityService/pkg/contracts"
 	fails "github.com/BeatEcoprove/identityService/pkg/errors"
 	"github.com/BeatEcoprove/identityService/pkg/services"
@@ -17,9 +20,21 @@ const (
 	ProfileRoutes      = "profiles"
 	AvailabilityRoutes = "availability"
 	GroupRoutes        = "groups"
+	SessionRoutes      = "sessions"
+	MFARoutes          = "mfa"
+	WebAuthnRoutes     = "webauthn"
+	IdentityRoutes     = "identities"
 
-	GrantTypePassword      = "password"
-	GrantTypeRefreshTokens = "refresh_token"
+	DeviceNameHeader = "X-Device-Name"
+
+	GrantTypePassword          = "password"
+	GrantTypeRefreshTokens     = "refresh_token"
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeMFAOTP            = "mfa_otp"
+	GrantTypeWebAuthn          = "webauthn"
+	GrantTypeLoginCode         = "login_code"
+	GrantTypeIDToken           = "id_token"
 )
 
 type AuthController struct {
@@ -31,31 +46,81 @@ type AuthController struct {
 	resetPasswdUseCase    *usecases.ResetPasswdUseCase
 	checkFieldUseCase     *usecases.CheckFieldUseCase
 	fechPermissions       *usecases.FetchGroupUserPermissionsUseCase
+	listSessions          *usecases.ListSessionsUseCase
+	revokeSession         *usecases.RevokeSessionUseCase
+	revokeAllSessions     *usecases.RevokeAllSessionsUseCase
+	revokeToken           *usecases.RevokeTokenUseCase
+	introspectToken       *usecases.IntrospectTokenUseCase
+	authorize             *usecases.AuthorizeUseCase
+	authorizationCode     *usecases.AuthorizationCodeUseCase
+	clientCredentials     *usecases.ClientCredentialsUseCase
+	verifyEmail           *usecases.VerifyEmailUseCase
+	resendVerification    *usecases.ResendVerificationEmailUseCase
+	mfaOTP                *usecases.MFAOTPUseCase
+	enrollTOTP            *usecases.EnrollTOTPUseCase
+	confirmTOTP           *usecases.ConfirmTOTPUseCase
+	disableTOTP           *usecases.DisableTOTPUseCase
+	recoveryCodes         *usecases.RegenerateRecoveryCodesUseCase
+	beginWebAuthnRegister *usecases.BeginWebAuthnRegistrationUseCase
+	webAuthnRegister      *usecases.FinishWebAuthnRegistrationUseCase
+	beginWebAuthnLogin    *usecases.BeginWebAuthnLoginUseCase
+	webAuthn              *usecases.WebAuthnUseCase
+	sendLoginCode         *usecases.SendLoginCodeUseCase
+	loginCode             *usecases.LoginCodeUseCase
+	federatedLogin        *usecases.FederatedLoginUseCase
+	listIdentities        *usecases.ListIdentitiesUseCase
+	linkIdentity          *usecases.LinkIdentityUseCase
+	unlinkIdentity        *usecases.UnlinkIdentityUseCase
+	changePassword        *usecases.ChangePasswordUseCase
+	deleteAccount         *usecases.DeleteAccountUseCase
+	restoreAccount        *usecases.RestoreAccountUseCase
 
-	authMiddleware *middlewares.AuthorizationMiddleware
+	authMiddleware      *middlewares.AuthorizationMiddleware
+	serviceMiddleware   *middlewares.ServiceAuthMiddleware
+	rateLimitMiddleware *middlewares.RateLimitMiddleware
 }
 
-func NewAuthController(
-	signUpUseCase *usecases.SignUpUseCase,
-	loginUseCase *usecases.LoginUseCase,
-	attachProfileUseCase *usecases.AttachProfileUseCase,
-	refreshTokensUseCase *usecases.RefreshTokensUseCase,
-	forgotPasswordUseCase *usecases.ForgotPasswordUseCase,
-	resetPasswdUseCase *usecases.ResetPasswdUseCase,
-	checkFieldUseCase *usecases.CheckFieldUseCase,
-	authMiddleware *middlewares.AuthorizationMiddleware,
-	fechPermissions *usecases.FetchGroupUserPermissionsUseCase,
-) *AuthController {
+func NewAuthController(useCases *usecases.UseCases, middlewares *middlewares.Middlewares) *AuthController {
 	return &AuthController{
-		signUpUseCase:         signUpUseCase,
-		loginUseCase:          loginUseCase,
-		attachProfileUseCase:  attachProfileUseCase,
-		refreshTokensUseCase:  refreshTokensUseCase,
-		forgotPasswordUseCase: forgotPasswordUseCase,
-		resetPasswdUseCase:    resetPasswdUseCase,
-		checkFieldUseCase:     checkFieldUseCase,
-		authMiddleware:        authMiddleware,
-		fechPermissions:       fechPermissions,
+		signUpUseCase:         useCases.Sign,
+		loginUseCase:          useCases.Login,
+		attachProfileUseCase:  useCases.AttachProfile,
+		refreshTokensUseCase:  useCases.RefreshTokens,
+		forgotPasswordUseCase: useCases.ForgotPassword,
+		resetPasswdUseCase:    useCases.ResetPassword,
+		checkFieldUseCase:     useCases.CheckFields,
+		authMiddleware:        middlewares.Authorization,
+		fechPermissions:       useCases.FetchPermissions,
+		listSessions:          useCases.ListSessions,
+		revokeSession:         useCases.RevokeSession,
+		revokeAllSessions:     useCases.RevokeAllSessions,
+		revokeToken:           useCases.RevokeToken,
+		introspectToken:       useCases.IntrospectToken,
+		serviceMiddleware:     middlewares.Service,
+		authorize:             useCases.Authorize,
+		authorizationCode:     useCases.AuthorizationCode,
+		clientCredentials:     useCases.ClientCredentials,
+		rateLimitMiddleware:   middlewares.RateLimit,
+		verifyEmail:           useCases.VerifyEmail,
+		resendVerification:    useCases.ResendVerificationEmail,
+		mfaOTP:                useCases.MFAOTP,
+		enrollTOTP:            useCases.EnrollTOTP,
+		confirmTOTP:           useCases.ConfirmTOTP,
+		disableTOTP:           useCases.DisableTOTP,
+		recoveryCodes:         useCases.RegenerateRecoveryCodes,
+		beginWebAuthnRegister: useCases.BeginWebAuthnRegistration,
+		webAuthnRegister:      useCases.FinishWebAuthnRegistration,
+		beginWebAuthnLogin:    useCases.BeginWebAuthnLogin,
+		webAuthn:              useCases.WebAuthn,
+		sendLoginCode:         useCases.SendLoginCode,
+		loginCode:             useCases.LoginCode,
+		federatedLogin:        useCases.FederatedLogin,
+		listIdentities:        useCases.ListIdentities,
+		linkIdentity:          useCases.LinkIdentity,
+		unlinkIdentity:        useCases.UnlinkIdentity,
+		changePassword:        useCases.ChangePassword,
+		deleteAccount:         useCases.DeleteAccount,
+		restoreAccount:        useCases.RestoreAccount,
 	}
 }
 
@@ -64,19 +129,58 @@ func (c *AuthController) Route(router fiber.Router) {
 
 	authRoutes := router.Group(AuthRoutes)
 	authRoutes.Post("reset-password", c.ResetPassword)
-	authRoutes.Post("forgot-password", c.ForgotPassword)
-	authRoutes.Post("token", c.Token)
-	authRoutes.Post("sign-up", c.SignUp)
+	authRoutes.Post("password", c.authMiddleware.AccessTokenHandler, c.ChangePassword)
+	authRoutes.Delete("", c.authMiddleware.AccessTokenHandler, c.DeleteAccount)
+	authRoutes.Post("restore", c.rateLimitMiddleware.Handler(middlewares.RateLimitRestore), c.RestoreAccount)
+	authRoutes.Post("forgot-password", c.rateLimitMiddleware.Handler(middlewares.RateLimitForgotPassword), c.ForgotPassword)
+	authRoutes.Get("authorize", c.AuthorizeForm)
+	authRoutes.Post("authorize", c.rateLimitMiddleware.Handler(middlewares.RateLimitToken), c.Authorize)
+	authRoutes.Post("token", c.rateLimitMiddleware.Handler(middlewares.RateLimitToken), c.Token)
+	authRoutes.Post("revoke", c.Revoke)
+	authRoutes.Post("introspect", c.serviceMiddleware.BasicAuthHandler(domain.ScopeTokenIntrospect), c.Introspect)
+	authRoutes.Post("sign-up", c.rateLimitMiddleware.Handler(middlewares.RateLimitSignUp), c.SignUp)
+	authRoutes.Post("verify-email", c.rateLimitMiddleware.Handler(middlewares.RateLimitConfirmEmail), c.VerifyEmail)
+	authRoutes.Post("login-code", c.rateLimitMiddleware.Handler(middlewares.RateLimitLoginCode), c.SendLoginCode)
+	authRoutes.Post("verify-email/resend", c.authMiddleware.AccessTokenHandler, c.rateLimitMiddleware.Handler(middlewares.RateLimitVerifyEmail), c.ResendVerificationEmail)
 
 	profileRoutes := authRoutes.Group(ProfileRoutes)
 	profileRoutes.Post("reserve", c.authMiddleware.AccessTokenHandler, c.AttachProfile)
 	profileRoutes.Get("me", c.authMiddleware.AccessTokenHandler, c.Me)
 
 	availabilityRoutes := authRoutes.Group(AvailabilityRoutes)
-	availabilityRoutes.Get("check-field", c.CheckField)
+	availabilityRoutes.Get("check-field", c.rateLimitMiddleware.Handler(middlewares.RateLimitCheckField), c.CheckField)
 
 	groupRoutes := authRoutes.Group(GroupRoutes)
-	groupRoutes.Get("permissions", c.FetchGroupPermissions)
+	groupRoutes.Get("permissions", c.serviceMiddleware.ScopeHandler(domain.ScopeGroupPermissions), c.FetchGroupPermissions)
+
+	sessionRoutes := authRoutes.Group(SessionRoutes)
+	sessionRoutes.Get("", c.authMiddleware.AccessTokenHandler, c.ListSessions)
+	sessionRoutes.Delete("", c.authMiddleware.AccessTokenHandler, c.RevokeAllSessions)
+	sessionRoutes.Delete(":id", c.authMiddleware.AccessTokenHandler, c.RevokeSession)
+
+	mfaRoutes := authRoutes.Group(MFARoutes)
+	mfaRoutes.Post("totp", c.authMiddleware.AccessTokenHandler, c.EnrollTOTP)
+	mfaRoutes.Post("totp/confirm", c.authMiddleware.AccessTokenHandler, c.rateLimitMiddleware.Handler(middlewares.RateLimitMFA), c.ConfirmTOTP)
+	mfaRoutes.Delete("totp", c.authMiddleware.AccessTokenHandler, c.rateLimitMiddleware.Handler(middlewares.RateLimitMFA), c.DisableTOTP)
+	mfaRoutes.Post("recovery-codes", c.authMiddleware.AccessTokenHandler, c.rateLimitMiddleware.Handler(middlewares.RateLimitMFA), c.RegenerateRecoveryCodes)
+
+	webAuthnRoutes := authRoutes.Group(WebAuthnRoutes)
+	webAuthnRoutes.Post("register/begin", c.authMiddleware.AccessTokenHandler, c.BeginWebAuthnRegistration)
+	webAuthnRoutes.Post("register/finish", c.authMiddleware.AccessTokenHandler, c.FinishWebAuthnRegistration)
+	webAuthnRoutes.Post("login/begin", c.rateLimitMiddleware.Handler(middlewares.RateLimitWebAuthnLogin), c.BeginWebAuthnLogin)
+
+	identityRoutes := authRoutes.Group(IdentityRoutes)
+	identityRoutes.Get("", c.authMiddleware.AccessTokenHandler, c.ListIdentities)
+	identityRoutes.Post("", c.authMiddleware.AccessTokenHandler, c.LinkIdentity)
+	identityRoutes.Delete(":id", c.authMiddleware.AccessTokenHandler, c.UnlinkIdentity)
+}
+
+func getDeviceInfo(ctx *fiber.Ctx) services.DeviceInfo {
+	return services.DeviceInfo{
+		Name:      ctx.Get(DeviceNameHeader),
+		IP:        ctx.IP(),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+	}
 }
 
 // // ShowAccount godoc
@@ -91,6 +195,9 @@ func (c *AuthController) Route(router fiber.Router) {
 //
 // @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters"
 // @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
+// @Failure  403       {object}  shared.ProblemDetails   "Second factor required, extensions carry the mfa_token for the mfa_otp grant"
+// @Failure  423       {object}  shared.ProblemDetails   "Too many failed attempts, account or ip locked out"
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
 // @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
 //
 //	@Router		/token [post]
@@ -106,6 +213,18 @@ func (c *AuthController) Token(ctx *fiber.Ctx) error {
 		return c.handleLogin(ctx)
 	case GrantTypeRefreshTokens:
 		return c.handleRefreshTokens(ctx)
+	case GrantTypeAuthorizationCode:
+		return c.handleAuthorizationCode(ctx)
+	case GrantTypeClientCredentials:
+		return c.handleClientCredentials(ctx)
+	case GrantTypeMFAOTP:
+		return c.handleMFAOTP(ctx)
+	case GrantTypeWebAuthn:
+		return c.handleWebAuthn(ctx)
+	case GrantTypeLoginCode:
+		return c.handleLoginCode(ctx)
+	case GrantTypeIDToken:
+		return c.handleFederatedLogin(ctx)
 	default:
 		return fails.DONT_HAVE_ACCESS_TO_RESOURCE
 	}
@@ -121,8 +240,14 @@ func (c *AuthController) handleLogin(ctx *fiber.Ctx) error {
 	response, err := c.loginUseCase.Handle(usecases.LoginInput{
 		Email:    loginRequest.Email,
 		Password: loginRequest.Password,
+		Nonce:    loginRequest.Nonce,
+		Device:   getDeviceInfo(ctx),
 	})
 
+	if challenge, ok := err.(*usecases.MFARequiredError); ok {
+		return writeMFAChallenge(ctx, challenge)
+	}
+
 	if err != nil {
 		return err
 	}
@@ -139,12 +264,377 @@ func (c *AuthController) handleRefreshTokens(ctx *fiber.Ctx) error {
 
 	var claims services.AuthClaims
 	if err := services.GetClaims(refreshTokenRequest.Token, &claims, services.Refresh); err != nil {
-		return err
+		return fails.INVALID_REFRESH_TOKEN
 	}
 
 	response, err := c.refreshTokensUseCase.Handle(usecases.RefreshTokensInput{
 		AuthId:    claims.Subject,
+		SessionId: claims.SessionID,
+		Token:     refreshTokenRequest.Token,
 		ProfileId: refreshTokenRequest.ProfileID,
+		Device:    getDeviceInfo(ctx),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (c *AuthController) handleAuthorizationCode(ctx *fiber.Ctx) error {
+	var authorizationCodeRequest contracts.AuthorizationCodeRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &authorizationCodeRequest); err != nil {
+		return err
+	}
+
+	response, err := c.authorizationCode.Handle(usecases.AuthorizationCodeInput{
+		Code:         authorizationCodeRequest.Code,
+		ClientID:     authorizationCodeRequest.ClientID,
+		RedirectURI:  authorizationCodeRequest.RedirectURI,
+		CodeVerifier: authorizationCodeRequest.CodeVerifier,
+		Device:       getDeviceInfo(ctx),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// handleClientCredentials accepts the client credentials over HTTP Basic or in
+// the body, as RFC 6749 section 2.3.1 allows both.
+func (c *AuthController) handleClientCredentials(ctx *fiber.Ctx) error {
+	var clientCredentialsRequest contracts.ClientCredentialsRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &clientCredentialsRequest); err != nil {
+		return err
+	}
+
+	if clientID, clientSecret, ok := middlewares.ParseBasicAuth(ctx); ok {
+		clientCredentialsRequest.ClientID = clientID
+		clientCredentialsRequest.ClientSecret = clientSecret
+	}
+
+	response, err := c.clientCredentials.Handle(usecases.ClientCredentialsInput{
+		ClientID:     clientCredentialsRequest.ClientID,
+		ClientSecret: clientCredentialsRequest.ClientSecret,
+		Scope:        strings.Fields(clientCredentialsRequest.Scope),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// writeMFAChallenge answers a password login of an account with a second
+// factor, the mfa_token goes back with a code through the mfa_otp grant.
+func writeMFAChallenge(ctx *fiber.Ctx, challenge *usecases.MFARequiredError) error {
+	return shared.WriteProblemDetails(ctx, *fails.MFA_REQUIRED, map[string]interface{}{
+		"mfa_token":  challenge.Token,
+		"expires_in": challenge.ExpiresIn,
+	})
+}
+
+func (c *AuthController) handleMFAOTP(ctx *fiber.Ctx) error {
+	var mfaOTPRequest contracts.MFAOTPRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &mfaOTPRequest); err != nil {
+		return err
+	}
+
+	response, err := c.mfaOTP.Handle(usecases.MFAOTPInput{
+		MFAToken:     mfaOTPRequest.MFAToken,
+		OTP:          mfaOTPRequest.OTP,
+		RecoveryCode: mfaOTPRequest.RecoveryCode,
+		Device:       getDeviceInfo(ctx),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (c *AuthController) handleWebAuthn(ctx *fiber.Ctx) error {
+	var webAuthnRequest contracts.WebAuthnRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &webAuthnRequest); err != nil {
+		return err
+	}
+
+	response, err := c.webAuthn.Handle(usecases.WebAuthnInput{
+		Credential: webAuthnRequest.Credential,
+		Nonce:      webAuthnRequest.Nonce,
+		Device:     getDeviceInfo(ctx),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (c *AuthController) handleLoginCode(ctx *fiber.Ctx) error {
+	var loginCodeRequest contracts.LoginCodeRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &loginCodeRequest); err != nil {
+		return err
+	}
+
+	response, err := c.loginCode.Handle(usecases.LoginCodeInput{
+		Email:  loginCodeRequest.Email,
+		Code:   loginCodeRequest.Code,
+		Nonce:  loginCodeRequest.Nonce,
+		Device: getDeviceInfo(ctx),
+	})
+
+	if challenge, ok := err.(*usecases.MFARequiredError); ok {
+		return writeMFAChallenge(ctx, challenge)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (c *AuthController) handleFederatedLogin(ctx *fiber.Ctx) error {
+	var federatedRequest contracts.FederatedLoginRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &federatedRequest); err != nil {
+		return err
+	}
+
+	response, err := c.federatedLogin.Handle(usecases.FederatedLoginInput{
+		Provider:      federatedRequest.Provider,
+		IDToken:       federatedRequest.IDToken,
+		ProviderNonce: federatedRequest.ProviderNonce,
+		Role:          federatedRequest.Role,
+		Nonce:         federatedRequest.Nonce,
+		Device:        getDeviceInfo(ctx),
+	})
+
+	if challenge, ok := err.(*usecases.MFARequiredError); ok {
+		return writeMFAChallenge(ctx, challenge)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func toAuthorizeInput(request contracts.AuthorizeRequest) usecases.AuthorizeInput {
+	return usecases.AuthorizeInput{
+		ResponseType:        request.ResponseType,
+		ClientID:            request.ClientID,
+		RedirectURI:         request.RedirectURI,
+		State:               request.State,
+		Nonce:               request.Nonce,
+		CodeChallenge:       request.CodeChallenge,
+		CodeChallengeMethod: request.CodeChallengeMethod,
+	}
+}
+
+func renderAuthorize(ctx *fiber.Ctx, status int, view views.AuthorizeView) error {
+	// the sign in page must never be framed by another site
+	ctx.Set(fiber.HeaderXFrameOptions, "DENY")
+	ctx.Set(fiber.HeaderContentSecurityPolicy, "frame-ancestors 'none'")
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Type("html", "utf-8")
+	ctx.Status(status)
+
+	return views.Authorize.Execute(ctx, view)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	OAuth2 authorization endpoint (authorization code flow with PKCE), renders the sign in page.
+//	@Tags		Authentication
+//	@Produce	html
+//
+//	@Param		response_type			query		string	true	"must be code"
+//	@Param		client_id				query		string	true	"client id"
+//	@Param		redirect_uri			query		string	true	"registered redirect uri"
+//	@Param		code_challenge			query		string	true	"PKCE challenge"
+//	@Param		code_challenge_method	query		string	true	"must be S256"
+//	@Param		state					query		string	false	"opaque value returned on redirect"
+//	@Param		nonce					query		string	false	"OpenID Connect nonce"
+//	@Success	200
+//
+// @Failure  400       {object}  shared.ProblemDetails   "Invalid authorization request"
+//
+//	@Router		/authorize [get]
+func (c *AuthController) AuthorizeForm(ctx *fiber.Ctx) error {
+	var authorizeRequest contracts.AuthorizeRequest
+
+	if err := ctx.QueryParser(&authorizeRequest); err != nil {
+		return fails.INVALID_AUTHORIZE_REQUEST
+	}
+
+	if err := c.authorize.Validate(toAuthorizeInput(authorizeRequest)); err != nil {
+		return err
+	}
+
+	return renderAuthorize(ctx, fiber.StatusOK, views.AuthorizeView{
+		Action:  ctx.Path(),
+		Request: authorizeRequest,
+	})
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Submits the sign in page, redirects back to the client with an authorization `code`.
+//	@Tags		Authentication
+//	@Accept		application/x-www-form-urlencoded
+//	@Produce	html
+//
+//	@Success	302
+//
+// @Failure  400       {object}  shared.ProblemDetails   "Invalid authorization request"
+// @Failure  401       "Authentication Failed, the sign in page is rendered again"
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
+//
+//	@Router		/authorize [post]
+func (c *AuthController) Authorize(ctx *fiber.Ctx) error {
+	var authorizeRequest contracts.AuthorizeLoginRequest
+
+	if err := ctx.BodyParser(&authorizeRequest); err != nil {
+		return shared.InputUnsupported(fiber.MIMEApplicationForm)
+	}
+
+	input := toAuthorizeInput(authorizeRequest.AuthorizeRequest)
+	input.Email = authorizeRequest.Email
+	input.Password = authorizeRequest.Password
+	input.MFAToken = authorizeRequest.MFAToken
+	input.OTP = authorizeRequest.OTP
+	input.RecoveryCode = authorizeRequest.RecoveryCode
+	input.Device = getDeviceInfo(ctx)
+
+	response, err := c.authorize.Handle(input)
+
+	if challenge, ok := err.(*usecases.MFARequiredError); ok {
+		return renderAuthorize(ctx, fiber.StatusOK, views.AuthorizeView{
+			Action:   ctx.Path(),
+			Request:  authorizeRequest.AuthorizeRequest,
+			Email:    authorizeRequest.Email,
+			MFAToken: challenge.Token,
+		})
+	}
+
+	if err == fails.INVALID_OTP || err == fails.INVALID_RECOVERY_CODE {
+		return renderAuthorize(ctx, fiber.StatusUnauthorized, views.AuthorizeView{
+			Action:   ctx.Path(),
+			Request:  authorizeRequest.AuthorizeRequest,
+			Email:    authorizeRequest.Email,
+			MFAToken: authorizeRequest.MFAToken,
+			Error:    "Invalid authentication or recovery code.",
+		})
+	}
+
+	if err == fails.INVALID_MFA_TOKEN {
+		return renderAuthorize(ctx, fiber.StatusUnauthorized, views.AuthorizeView{
+			Action:  ctx.Path(),
+			Request: authorizeRequest.AuthorizeRequest,
+			Email:   authorizeRequest.Email,
+			Error:   "The sign in expired, please try again.",
+		})
+	}
+
+	if err == fails.USER_AUTH_FAILED {
+		return renderAuthorize(ctx, fiber.StatusUnauthorized, views.AuthorizeView{
+			Action:  ctx.Path(),
+			Request: authorizeRequest.AuthorizeRequest,
+			Email:   authorizeRequest.Email,
+			Error:   "Invalid email or password.",
+		})
+	}
+
+	if err == fails.USER_LOCKED {
+		return renderAuthorize(ctx, fiber.StatusLocked, views.AuthorizeView{
+			Action:  ctx.Path(),
+			Request: authorizeRequest.AuthorizeRequest,
+			Email:   authorizeRequest.Email,
+			Error:   "Too many failed attempts, try again later.",
+		})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Redirect(response.RedirectTo, fiber.StatusFound)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Revokes an `access_token` or `refresh_token` (RFC 7009), ending the session it belongs to.
+//	@Tags		Authentication
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		data			body		contracts.RevokeTokenRequest	true	"Revoke Payload"
+//	@Success	200				{object}	contracts.GenericResponse "Response"
+//
+// @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/revoke [post]
+func (c *AuthController) Revoke(ctx *fiber.Ctx) error {
+	var revokeTokenRequest contracts.RevokeTokenRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &revokeTokenRequest); err != nil {
+		return err
+	}
+
+	response, err := c.revokeToken.Handle(usecases.RevokeTokenInput{
+		Token:         revokeTokenRequest.Token,
+		TokenTypeHint: revokeTokenRequest.TokenTypeHint,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Token introspection (RFC 7662) for downstream services, tells whether a token is still `active` and returns its claims.
+//	@Tags		Internal
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		data			body		contracts.IntrospectTokenRequest	true	"Introspect Payload"
+//	@Success	200				{object}	contracts.IntrospectionResponse "Token State"
+//	@security	BasicAuth
+//
+// @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters"
+// @Failure  401       {object}  shared.ProblemDetails   "Invalid client credentials"
+// @Failure  403       {object}  shared.ProblemDetails   "Client without the token:introspect scope"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/introspect [post]
+func (c *AuthController) Introspect(ctx *fiber.Ctx) error {
+	var introspectTokenRequest contracts.IntrospectTokenRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &introspectTokenRequest); err != nil {
+		return err
+	}
+
+	response, err := c.introspectToken.Handle(usecases.IntrospectTokenInput{
+		Token:         introspectTokenRequest.Token,
+		TokenTypeHint: introspectTokenRequest.TokenTypeHint,
 	})
 
 	if err != nil {
@@ -172,14 +662,15 @@ func (c *AuthController) handleRefreshTokens(ctx *fiber.Ctx) error {
 //
 //	@Router		/profiles [post]
 func (c *AuthController) AttachProfile(ctx *fiber.Ctx) error {
-	authID, err := middlewares.GetUserID(ctx)
+	_, claims, err := middlewares.GetClaims(ctx)
 
 	if err != nil {
 		return err
 	}
 
 	response, err := c.attachProfileUseCase.Handle(usecases.AttachProfileInput{
-		AuthId:           authID,
+		AuthId:           claims.Subject,
+		SessionId:        claims.SessionID,
 		ProfileGrantType: 1,
 	})
 
@@ -213,11 +704,12 @@ func (c *AuthController) Me(ctx *fiber.Ctx) error {
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&contracts.AccountResponse{
-		UserID:     claims.Subject,
-		Email:      claims.Email,
-		ProfileID:  claims.ProfileID,
-		ProfileIds: claims.ProfileIds,
-		Role:       claims.Role,
+		UserID:        claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		ProfileID:     claims.ProfileID,
+		ProfileIds:    claims.ProfileIds,
+		Role:          claims.Role,
 	})
 }
 
@@ -232,6 +724,7 @@ func (c *AuthController) Me(ctx *fiber.Ctx) error {
 //	@Success	200				{object}	contracts.GenericResponse "Value"
 //
 // @Failure  409       {object}  shared.ProblemDetails   "Invalid Email"
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
 // @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
 //
 //	@Router		/availability/check-field [get]
@@ -296,6 +789,7 @@ func (c *AuthController) ResetPassword(ctx *fiber.Ctx) error {
 //
 // @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
 // @Failure  403       {object}  shared.ProblemDetails   "Don't have access to this resource"
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
 // @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
 //
 //	@Router		/forgot-password [post]
@@ -317,6 +811,74 @@ func (c *AuthController) ForgotPassword(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(response)
 }
 
+// // ShowAccount godoc
+//
+//	@Summary	Confirms the email of an account with the token sent to it after signing up.
+//	@Tags		Authentication
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		data			body		contracts.VerifyEmailRequest	true	"VerifyEmail Payload"
+//	@Success	200				{object}	contracts.GenericResponse "Response"
+//
+// @Failure  400       {object}  shared.ProblemDetails   "Invalid or expired token"
+// @Failure  404       {object}  shared.ProblemDetails   "User not found"
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/verify-email [post]
+func (c *AuthController) VerifyEmail(ctx *fiber.Ctx) error {
+	var verifyEmailRequest contracts.VerifyEmailRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &verifyEmailRequest); err != nil {
+		return err
+	}
+
+	response, err := c.verifyEmail.Handle(usecases.VerifyEmailInput{
+		Token: verifyEmailRequest.Token,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Sends another verification email to the authenticated account, the previous token stops working.
+//	@Tags		Authentication
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Success	200				{object}	contracts.GenericResponse "Response"
+//	@security	Bearer
+//
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
+// @Failure  409       {object}  shared.ProblemDetails   "Email already verified"
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/verify-email/resend [post]
+func (c *AuthController) ResendVerificationEmail(ctx *fiber.Ctx) error {
+	authID, err := middlewares.GetUserID(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.resendVerification.Handle(usecases.ResendVerificationEmailInput{
+		AuthId: authID,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
 // // ShowAccount godoc
 //
 //	@Summary	Register an access key to obtain a `profileId`, which allows you to create a profile on the platform.
@@ -330,6 +892,7 @@ func (c *AuthController) ForgotPassword(ctx *fiber.Ctx) error {
 // @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters"
 // @Failure  404       {object}  shared.ProblemDetails   "Role not found"
 // @Failure  409       {object}  shared.ProblemDetails   "Invalid Password or Email already used"
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
 // @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
 //
 //	@Router		/sign-up [post]
@@ -344,6 +907,7 @@ func (c *AuthController) SignUp(ctx *fiber.Ctx) error {
 		Email:    signUpRequest.Email,
 		Password: signUpRequest.Password,
 		Role:     signUpRequest.Role,
+		Device:   getDeviceInfo(ctx),
 	})
 
 	if err != nil {
@@ -365,9 +929,11 @@ func (c *AuthController) SignUp(ctx *fiber.Ctx) error {
 //
 // @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters"
 // @Failure  404       {object}  shared.ProblemDetails   "Role not found"
+// @Failure  403       {object}  shared.ProblemDetails   "Machine token without the group:permissions scope"
 // @Failure  409       {object}  shared.ProblemDetails   "Invalid Password or Email already used"
 // @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
 //
+//	@security	Bearer
 //	@Router		/groups/permissions [post]
 func (c *AuthController) FetchGroupPermissions(ctx *fiber.Ctx) error {
 	var fetchPermissionsRequest contracts.GroupPermissionsRequest
@@ -387,3 +953,655 @@ func (c *AuthController) FetchGroupPermissions(ctx *fiber.Ctx) error {
 
 	return ctx.Status(fiber.StatusOK).JSON(response)
 }
+
+// // ShowAccount godoc
+//
+//	@Summary	Lists the active sessions of the authenticated account, flagging the one used to make the request.
+//	@Tags		Sessions
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Success	200				{object}	contracts.SessionsResponse "Active Sessions"
+//	@security	Bearer
+//
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
+// @Failure  403       {object}  shared.ProblemDetails   "Don't have access to this resource"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/sessions [get]
+func (c *AuthController) ListSessions(ctx *fiber.Ctx) error {
+	_, claims, err := middlewares.GetClaims(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.listSessions.Handle(usecases.ListSessionsInput{
+		AuthId:    claims.Subject,
+		SessionId: claims.SessionID,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Signs out a single session of the authenticated account, e.g. a lost device.
+//	@Tags		Sessions
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		id				path		string	true	"session id"
+//	@Success	200				{object}	contracts.GenericResponse "Response"
+//	@security	Bearer
+//
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
+// @Failure  403       {object}  shared.ProblemDetails   "Don't have access to this resource"
+// @Failure  404       {object}  shared.ProblemDetails   "Session not found"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/sessions/{id} [delete]
+func (c *AuthController) RevokeSession(ctx *fiber.Ctx) error {
+	authID, err := middlewares.GetUserID(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.revokeSession.Handle(usecases.RevokeSessionInput{
+		AuthId:    authID,
+		SessionId: ctx.Params("id"),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Signs out every session of the authenticated account, optionally keeping the current one.
+//	@Tags		Sessions
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		keep_current	query		bool	false	"keep the session used to make the request"
+//	@Success	200				{object}	contracts.GenericResponse "Response"
+//	@security	Bearer
+//
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
+// @Failure  403       {object}  shared.ProblemDetails   "Don't have access to this resource"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/sessions [delete]
+func (c *AuthController) RevokeAllSessions(ctx *fiber.Ctx) error {
+	_, claims, err := middlewares.GetClaims(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.revokeAllSessions.Handle(usecases.RevokeAllSessionsInput{
+		AuthId:      claims.Subject,
+		SessionId:   claims.SessionID,
+		KeepCurrent: ctx.QueryBool("keep_current", false),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Starts the totp enrollment of the authenticated account, returns the secret and the otpauth uri to show as a QR code.
+//	@Tags		MFA
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Success	200				{object}	contracts.TOTPEnrollmentResponse "Enrollment"
+//	@security	Bearer
+//
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
+// @Failure  409       {object}  shared.ProblemDetails   "Two-factor authentication already enabled"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/mfa/totp [post]
+func (c *AuthController) EnrollTOTP(ctx *fiber.Ctx) error {
+	authID, err := middlewares.GetUserID(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.enrollTOTP.Handle(usecases.EnrollTOTPInput{
+		AuthId: authID,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Confirms the totp enrollment with a code of the authenticator app, logins ask for a code from then on. Returns the first recovery codes.
+//	@Tags		MFA
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		data			body		contracts.OTPRequest	true	"OTP Payload"
+//	@Success	200				{object}	contracts.RecoveryCodesResponse "Recovery Codes"
+//	@security	Bearer
+//
+// @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters"
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed or invalid code"
+// @Failure  409       {object}  shared.ProblemDetails   "Not enrolled or already enabled"
+// @Failure  423       {object}  shared.ProblemDetails   "Too many wrong codes, retry later"
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/mfa/totp/confirm [post]
+func (c *AuthController) ConfirmTOTP(ctx *fiber.Ctx) error {
+	var otpRequest contracts.OTPRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &otpRequest); err != nil {
+		return err
+	}
+
+	authID, err := middlewares.GetUserID(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.confirmTOTP.Handle(usecases.TOTPCodeInput{
+		AuthId: authID,
+		OTP:    otpRequest.OTP,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Disables two-factor authentication, a current code of the authenticator app is required.
+//	@Tags		MFA
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		data			body		contracts.OTPRequest	true	"OTP Payload"
+//	@Success	200				{object}	contracts.GenericResponse "Response"
+//	@security	Bearer
+//
+// @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters"
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed or invalid code"
+// @Failure  409       {object}  shared.ProblemDetails   "Two-factor authentication not enabled"
+// @Failure  423       {object}  shared.ProblemDetails   "Too many wrong codes, retry later"
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/mfa/totp [delete]
+func (c *AuthController) DisableTOTP(ctx *fiber.Ctx) error {
+	var otpRequest contracts.OTPRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &otpRequest); err != nil {
+		return err
+	}
+
+	authID, err := middlewares.GetUserID(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.disableTOTP.Handle(usecases.TOTPCodeInput{
+		AuthId: authID,
+		OTP:    otpRequest.OTP,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Replaces the recovery codes of the authenticated account, a current code of the authenticator app is required.
+//	@Tags		MFA
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		data			body		contracts.OTPRequest	true	"OTP Payload"
+//	@Success	200				{object}	contracts.RecoveryCodesResponse "Recovery Codes"
+//	@security	Bearer
+//
+// @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters"
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed or invalid code"
+// @Failure  409       {object}  shared.ProblemDetails   "Two-factor authentication not enabled"
+// @Failure  423       {object}  shared.ProblemDetails   "Too many wrong codes, retry later"
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/mfa/recovery-codes [post]
+func (c *AuthController) RegenerateRecoveryCodes(ctx *fiber.Ctx) error {
+	var otpRequest contracts.OTPRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &otpRequest); err != nil {
+		return err
+	}
+
+	authID, err := middlewares.GetUserID(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.recoveryCodes.Handle(usecases.TOTPCodeInput{
+		AuthId: authID,
+		OTP:    otpRequest.OTP,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Starts a passkey registration, the options go to navigator.credentials.create.
+//	@Tags		WebAuthn
+//	@Produce	json
+//
+//	@Success	200				{object}	protocol.CredentialCreation "Credential Creation Options"
+//	@security	Bearer
+//
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/webauthn/register/begin [post]
+func (c *AuthController) BeginWebAuthnRegistration(ctx *fiber.Ctx) error {
+	authID, err := middlewares.GetUserID(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.beginWebAuthnRegister.Handle(usecases.BeginWebAuthnRegistrationInput{
+		AuthId: authID,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Stores the passkey created by the browser, it can sign in through the webauthn grant from then on.
+//	@Tags		WebAuthn
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		data			body		contracts.WebAuthnRegistrationRequest	true	"Registration Payload"
+//	@Success	201				{object}	contracts.WebAuthnCredentialResponse "Registered Passkey"
+//	@security	Bearer
+//
+// @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters or registration"
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
+// @Failure  409       {object}  shared.ProblemDetails   "Passkey already registered"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/webauthn/register/finish [post]
+func (c *AuthController) FinishWebAuthnRegistration(ctx *fiber.Ctx) error {
+	var registrationRequest contracts.WebAuthnRegistrationRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &registrationRequest); err != nil {
+		return err
+	}
+
+	authID, err := middlewares.GetUserID(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.webAuthnRegister.Handle(usecases.FinishWebAuthnRegistrationInput{
+		AuthId:     authID,
+		Name:       registrationRequest.Name,
+		Credential: registrationRequest.Credential,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Starts a passkey login, the options go to navigator.credentials.get and the result to the webauthn grant.
+//	@Tags		WebAuthn
+//	@Produce	json
+//
+//	@Success	200				{object}	protocol.CredentialAssertion "Credential Request Options"
+//
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/webauthn/login/begin [post]
+func (c *AuthController) BeginWebAuthnLogin(ctx *fiber.Ctx) error {
+	response, err := c.beginWebAuthnLogin.Handle()
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Emails a one-time login code, exchanged through the login_code grant without a password.
+//	@Tags		Authentication
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		data			body		contracts.SendLoginCodeRequest	true	"Login Code Payload"
+//	@Success	200				{object}	contracts.GenericResponse "Response"
+//
+// @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters"
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/login-code [post]
+func (c *AuthController) SendLoginCode(ctx *fiber.Ctx) error {
+	var sendLoginCodeRequest contracts.SendLoginCodeRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &sendLoginCodeRequest); err != nil {
+		return err
+	}
+
+	response, err := c.sendLoginCode.Handle(usecases.SendLoginCodeInput{
+		Email: sendLoginCodeRequest.Email,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Changes the password of the authenticated account, every other session is signed out.
+//	@Tags		Authentication
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		data			body		contracts.ChangePasswordRequest	true	"Change Password Payload"
+//	@Success	200				{object}	contracts.GenericResponse "Response"
+//	@security	Bearer
+//
+// @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters or wrong current password"
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
+// @Failure  409       {object}  shared.ProblemDetails   "The account has no password, set one through forgot-password"
+// @Failure  423       {object}  shared.ProblemDetails   "Too many failed attempts, account or ip locked out"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/password [post]
+func (c *AuthController) ChangePassword(ctx *fiber.Ctx) error {
+	var changePasswordRequest contracts.ChangePasswordRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &changePasswordRequest); err != nil {
+		return err
+	}
+
+	_, claims, err := middlewares.GetClaims(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.changePassword.Handle(usecases.ChangePasswordInput{
+		AuthId:          claims.Subject,
+		SessionId:       claims.SessionID,
+		CurrentPassword: changePasswordRequest.CurrentPassword,
+		NewPassword:     changePasswordRequest.NewPassword,
+		Device:          getDeviceInfo(ctx),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Deletes the authenticated account once the grace period is over, it is disabled and every session signed out until then. The password is needed unless the session signed in within the last 5 minutes.
+//	@Tags		Authentication
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		data			body		contracts.DeleteAccountRequest	false	"Delete Account Payload"
+//	@Success	202				{object}	contracts.AccountDeletionResponse "Deletion Scheduled"
+//	@security	Bearer
+//
+// @Failure  401       {object}  shared.ProblemDetails   "Wrong password or re-authentication required"
+// @Failure  409       {object}  shared.ProblemDetails   "A password was sent but the account has none"
+// @Failure  423       {object}  shared.ProblemDetails   "Too many failed attempts, account or ip locked out"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/ [delete]
+func (c *AuthController) DeleteAccount(ctx *fiber.Ctx) error {
+	var deleteRequest contracts.DeleteAccountRequest
+
+	if len(ctx.Body()) > 0 {
+		if err := shared.ParseBodyAndValidate(ctx, &deleteRequest); err != nil {
+			return err
+		}
+	}
+
+	_, claims, err := middlewares.GetClaims(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.deleteAccount.Handle(usecases.DeleteAccountInput{
+		AuthId:    claims.Subject,
+		SessionId: claims.SessionID,
+		Password:  deleteRequest.Password,
+		Device:    getDeviceInfo(ctx),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Restores an account pending deletion, with the same credentials as login.
+//	@Tags		Authentication
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		data			body		contracts.RestoreAccountRequest	true	"Restore Account Payload"
+//	@Success	200				{object}	contracts.GenericResponse "Response"
+//
+// @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters"
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
+// @Failure  403       {object}  shared.ProblemDetails   "Second factor required, extensions carry the mfa_token to send back with a code"
+// @Failure  409       {object}  shared.ProblemDetails   "Account not pending deletion"
+// @Failure  423       {object}  shared.ProblemDetails   "Too many failed attempts, account or ip locked out"
+// @Failure  429       {object}  shared.ProblemDetails   "Too many requests, retry after the Retry-After header"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/restore [post]
+func (c *AuthController) RestoreAccount(ctx *fiber.Ctx) error {
+	var restoreRequest contracts.RestoreAccountRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &restoreRequest); err != nil {
+		return err
+	}
+
+	response, err := c.restoreAccount.Handle(usecases.RestoreAccountInput{
+		Email:        restoreRequest.Email,
+		Password:     restoreRequest.Password,
+		MFAToken:     restoreRequest.MFAToken,
+		OTP:          restoreRequest.OTP,
+		RecoveryCode: restoreRequest.RecoveryCode,
+		Device:       getDeviceInfo(ctx),
+	})
+
+	if challenge, ok := err.(*usecases.MFARequiredError); ok {
+		return writeMFAChallenge(ctx, challenge)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Lists the external identities linked to the authenticated account.
+//	@Tags		Identities
+//	@Produce	json
+//
+//	@Success	200				{object}	contracts.ExternalIdentitiesResponse "Linked Identities"
+//	@security	Bearer
+//
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/identities [get]
+func (c *AuthController) ListIdentities(ctx *fiber.Ctx) error {
+	authID, err := middlewares.GetUserID(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.listIdentities.Handle(usecases.ListIdentitiesInput{
+		AuthId: authID,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Links the provider of an id token to the authenticated account, the password is needed unless the session signed in within the last 5 minutes.
+//	@Tags		Identities
+//	@Accept		application/json
+//	@Produce	json
+//
+//	@Param		data			body		contracts.LinkIdentityRequest	true	"Link Identity Payload"
+//	@Success	201				{object}	contracts.ExternalIdentityResponse "Linked Identity"
+//	@security	Bearer
+//
+// @Failure  400       {object}  shared.ProblemDetailsExtendend   "Invalid parameters"
+// @Failure  401       {object}  shared.ProblemDetails   "Invalid id token, wrong password or re-authentication required"
+// @Failure  409       {object}  shared.ProblemDetails   "Identity already linked, or a password was sent but the account has none"
+// @Failure  423       {object}  shared.ProblemDetails   "Too many failed attempts, account or ip locked out"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/identities [post]
+func (c *AuthController) LinkIdentity(ctx *fiber.Ctx) error {
+	var linkRequest contracts.LinkIdentityRequest
+
+	if err := shared.ParseBodyAndValidate(ctx, &linkRequest); err != nil {
+		return err
+	}
+
+	_, claims, err := middlewares.GetClaims(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.linkIdentity.Handle(usecases.LinkIdentityInput{
+		AuthId:        claims.Subject,
+		SessionId:     claims.SessionID,
+		Provider:      linkRequest.Provider,
+		IDToken:       linkRequest.IDToken,
+		ProviderNonce: linkRequest.ProviderNonce,
+		Password:      linkRequest.Password,
+		Device:        getDeviceInfo(ctx),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+// // ShowAccount godoc
+//
+//	@Summary	Unlinks an external identity, as long as the account keeps a password, a passkey or another identity.
+//	@Tags		Identities
+//	@Produce	json
+//
+//	@Param		id				path		string	true	"Identity Id"
+//	@Success	200				{object}	contracts.GenericResponse "Response"
+//	@security	Bearer
+//
+// @Failure  401       {object}  shared.ProblemDetails   "Authentication Failed"
+// @Failure  404       {object}  shared.ProblemDetails   "Identity not found"
+// @Failure  409       {object}  shared.ProblemDetails   "Last login method of the account"
+// @Failure  500       {object}  shared.ProblemDetails   "Server failed to provide an valid response"
+//
+//	@Router		/identities/{id} [delete]
+func (c *AuthController) UnlinkIdentity(ctx *fiber.Ctx) error {
+	authID, err := middlewares.GetUserID(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.unlinkIdentity.Handle(usecases.UnlinkIdentityInput{
+		AuthId:     authID,
+		IdentityId: ctx.Params("id"),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
@@ -2,7 +2,11 @@ package internal
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/BeatEcoprove/identityService/config"
+	"github.com/BeatEcoprove/identityService/internal/usecases"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
 	"github.com/BeatEcoprove/identityService/pkg/services"
 	"github.com/gofiber/fiber/v2"
 )
@@ -16,6 +20,7 @@ func NewStaticController() *StaticController {
 
 func (c *StaticController) Route(router fiber.Router) {
 	router.Get(".well-known/jwks.json", c.JWKS)
+	router.Get(".well-known/openid-configuration", c.OpenIDConfiguration)
 }
 
 func (c *StaticController) JWKS(ctx *fiber.Ctx) error {
@@ -27,3 +32,42 @@ func (c *StaticController) JWKS(ctx *fiber.Ctx) error {
 
 	return ctx.Status(fiber.StatusOK).JSON(jwks)
 }
+
+// publicURL is where clients reach the service, PUBLIC_URL when the service
+// runs behind a proxy, the request origin otherwise.
+func publicURL(ctx *fiber.Ctx) string {
+	if url := config.GetConfig().PUBLIC_URL; url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+
+	return ctx.BaseURL()
+}
+
+func accountURL(baseURL, route string) string {
+	return fmt.Sprintf("%s/api/v%s/%s/%s", baseURL, APIVersion, AuthRoutes, route)
+}
+
+func (c *StaticController) OpenIDConfiguration(ctx *fiber.Ctx) error {
+	env := config.GetConfig()
+	baseURL := publicURL(ctx)
+
+	return ctx.Status(fiber.StatusOK).JSON(&contracts.OpenIDConfigurationResponse{
+		Issuer:                                    env.JWT_ISSUER,
+		AuthorizationEndpoint:                     accountURL(baseURL, "authorize"),
+		TokenEndpoint:                             accountURL(baseURL, "token"),
+		JwksURI:                                   baseURL + "/.well-known/jwks.json",
+		RevocationEndpoint:                        accountURL(baseURL, "revoke"),
+		IntrospectionEndpoint:                     accountURL(baseURL, "introspect"),
+		ScopesSupported:                           []string{"openid", "email"},
+		ResponseTypesSupported:                    []string{usecases.ResponseTypeCode},
+		GrantTypesSupported:                       []string{GrantTypePassword, GrantTypeRefreshTokens, GrantTypeAuthorizationCode, GrantTypeClientCredentials, GrantTypeMFAOTP, GrantTypeWebAuthn, GrantTypeLoginCode, GrantTypeIDToken},
+		SubjectTypesSupported:                     []string{"public"},
+		IDTokenSigningAlgValuesSupported:          services.SigningAlgorithms(),
+		TokenEndpointAuthMethodsSupported:         []string{"none", "client_secret_basic", "client_secret_post"},
+		IntrospectionEndpointAuthMethodsSupported: []string{"client_secret_basic"},
+		CodeChallengeMethodsSupported:             []string{services.CodeChallengeS256},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "sid", "email", "email_verified",
+		},
+	})
+}
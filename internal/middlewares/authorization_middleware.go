@@ -29,11 +29,8 @@ func NewAuthorizationMiddleware(
 
 func (am *AuthorizationMiddleware) tokenHandler(ctx *fiber.Ctx, validateToken func(claims *services.AuthClaims, token *jwt.Token) error) error {
 	return jwtware.New(jwtware.Config{
-		Claims: &services.AuthClaims{},
-		SigningKey: jwtware.SigningKey{
-			JWTAlg: jwt.SigningMethodRS256.Name,
-			Key:    services.PubKey,
-		},
+		Claims:  &services.AuthClaims{},
+		KeyFunc: services.VerificationKey,
 		SuccessHandler: func(ctx *fiber.Ctx) error {
 			token, claims, err := GetClaims(ctx)
 
@@ -59,7 +56,7 @@ func (am *AuthorizationMiddleware) tokenHandler(ctx *fiber.Ctx, validateToken fu
 
 func (am *AuthorizationMiddleware) AccessTokenHandler(ctx *fiber.Ctx) error {
 	return am.tokenHandler(ctx, func(claims *services.AuthClaims, token *jwt.Token) error {
-		err := am.tokenService.ValidateToken(claims.Subject, token.Raw, services.AccessTokenKey)
+		err := am.tokenService.ValidateToken(claims.Subject, claims.SessionID, token.Raw, services.AccessTokenKey)
 
 		if err != nil {
 			return fails.INVALID_ACCESS_TOKEN
@@ -2,4 +2,6 @@ package middlewares
 
 type Middlewares struct {
 	Authorization *AuthorizationMiddleware
+	Service       *ServiceAuthMiddleware
+	RateLimit     *RateLimitMiddleware
 }
@@ -0,0 +1,159 @@
+package middlewares
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/BeatEcoprove/identityService/config"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/BeatEcoprove/identityService/pkg/shared"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	RateLimitMiddleware struct {
+		limiter services.IRateLimiter
+	}
+
+	// RateLimitPolicy limits a route per ip and, when the request carries
+	// one, per email, so spreading requests over ips doesn't help against a
+	// single account.
+	RateLimitPolicy struct {
+		Route string
+		IP    services.RateLimit
+		Email services.RateLimit
+	}
+
+	emailRequest struct {
+		Email string `json:"email" form:"email" query:"email"`
+	}
+)
+
+const (
+	RateLimitSignUp         = "sign-up"
+	RateLimitForgotPassword = "forgot-password"
+	RateLimitToken          = "token"
+	RateLimitCheckField     = "check-field"
+	RateLimitVerifyEmail    = "verify-email"
+	RateLimitConfirmEmail   = "confirm-email"
+	RateLimitLoginCode      = "login-code"
+	RateLimitMFA            = "mfa"
+	RateLimitRestore        = "restore"
+	RateLimitWebAuthnLogin  = "webauthn-login"
+)
+
+func NewRateLimitMiddleware(limiter services.IRateLimiter) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		limiter: limiter,
+	}
+}
+
+// NewRateLimitPolicy reads the limits of a route from config.Config, the
+// defaults apply when they are unset or invalid.
+func NewRateLimitPolicy(route string) RateLimitPolicy {
+	env := config.GetConfig()
+
+	switch route {
+	case RateLimitSignUp:
+		return newRateLimitPolicy(route, env.RATE_LIMIT_SIGN_UP_IP, "10/1h", env.RATE_LIMIT_SIGN_UP_EMAIL, "3/1h")
+	case RateLimitForgotPassword:
+		return newRateLimitPolicy(route, env.RATE_LIMIT_FORGOT_PASSWORD_IP, "10/1h", env.RATE_LIMIT_FORGOT_PASSWORD_EMAIL, "3/15m")
+	case RateLimitToken:
+		return newRateLimitPolicy(route, env.RATE_LIMIT_TOKEN_IP, "60/1m", env.RATE_LIMIT_TOKEN_EMAIL, "10/1m")
+	case RateLimitCheckField:
+		return newRateLimitPolicy(route, env.RATE_LIMIT_CHECK_FIELD_IP, "30/1m", env.RATE_LIMIT_CHECK_FIELD_EMAIL, "10/1m")
+	case RateLimitVerifyEmail:
+		return newRateLimitPolicy(route, env.RATE_LIMIT_VERIFY_EMAIL_IP, "10/1h", env.RATE_LIMIT_VERIFY_EMAIL_EMAIL, "3/1h")
+	case RateLimitConfirmEmail:
+		// the token is all the request carries, it is only limited per ip
+		return RateLimitPolicy{
+			Route: route,
+			IP:    parseRateLimit(route, env.RATE_LIMIT_CONFIRM_EMAIL_IP, "30/1h"),
+		}
+	case RateLimitLoginCode:
+		return newRateLimitPolicy(route, env.RATE_LIMIT_LOGIN_CODE_IP, "10/1h", env.RATE_LIMIT_LOGIN_CODE_EMAIL, "3/15m")
+	case RateLimitMFA:
+		return newRateLimitPolicy(route, env.RATE_LIMIT_MFA_IP, "30/1m", env.RATE_LIMIT_MFA_EMAIL, "10/15m")
+	case RateLimitRestore:
+		return newRateLimitPolicy(route, env.RATE_LIMIT_RESTORE_IP, "10/15m", env.RATE_LIMIT_RESTORE_EMAIL, "5/15m")
+	case RateLimitWebAuthnLogin:
+		return newRateLimitPolicy(route, env.RATE_LIMIT_WEBAUTHN_LOGIN_IP, "60/1m", env.RATE_LIMIT_WEBAUTHN_LOGIN_EMAIL, "10/1m")
+	}
+
+	return RateLimitPolicy{Route: route}
+}
+
+func newRateLimitPolicy(route, ipLimit, ipDefault, emailLimit, emailDefault string) RateLimitPolicy {
+	return RateLimitPolicy{
+		Route: route,
+		IP:    parseRateLimit(route, ipLimit, ipDefault),
+		Email: parseRateLimit(route, emailLimit, emailDefault),
+	}
+}
+
+func parseRateLimit(route, raw, fallback string) services.RateLimit {
+	if raw != "" {
+		limit, err := services.ParseRateLimit(raw)
+
+		if err == nil {
+			return limit
+		}
+
+		log.Printf("invalid rate limit %q for %s, using %s: %s", raw, route, fallback, err.Error())
+	}
+
+	limit, _ := services.ParseRateLimit(fallback)
+	return limit
+}
+
+// Handler answers 429 with a Retry-After once the ip or the email of the
+// request went over the limits of the route. The limiter fails open, an
+// unreachable redis doesn't take the public endpoints down with it.
+func (rm *RateLimitMiddleware) Handler(route string) fiber.Handler {
+	policy := NewRateLimitPolicy(route)
+
+	return func(ctx *fiber.Ctx) error {
+		retryAfter, err := rm.limiter.Allow(services.NewRateLimitKey(policy.Route, "ip", ctx.IP()), policy.IP)
+
+		if err == nil && retryAfter == 0 {
+			if email := requestEmail(ctx); email != "" {
+				retryAfter, err = rm.limiter.Allow(services.NewRateLimitKey(policy.Route, "email", email), policy.Email)
+			}
+		}
+
+		if err != nil {
+			log.Printf("failed to check rate limit %s", err.Error())
+			return ctx.Next()
+		}
+
+		if retryAfter > 0 {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(retryAfter.Seconds())))
+			return shared.WriteProblemDetails(ctx, *fails.TOO_MANY_REQUESTS)
+		}
+
+		return ctx.Next()
+	}
+}
+
+// requestEmail finds the email of a request in its query or body, the body
+// stays readable by the handler. Authenticated routes fall back to the email
+// of the access token.
+func requestEmail(ctx *fiber.Ctx) string {
+	var request emailRequest
+
+	if ctx.Method() == fiber.MethodGet {
+		_ = ctx.QueryParser(&request)
+	} else {
+		_ = ctx.BodyParser(&request)
+	}
+
+	if request.Email == "" {
+		if _, claims, err := GetClaims(ctx); err == nil {
+			request.Email = claims.Email
+		}
+	}
+
+	return strings.ToLower(strings.TrimSpace(request.Email))
+}
@@ -0,0 +1,111 @@
+package middlewares
+
+import (
+	"encoding/base64"
+	"slices"
+	"strings"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/BeatEcoprove/identityService/pkg/shared"
+	jwtware "github.com/gofiber/contrib/jwt"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	ServiceAuthMiddleware struct {
+		clientRepository repositories.IOAuthClientRepository
+	}
+)
+
+func NewServiceAuthMiddleware(
+	clientRepository repositories.IOAuthClientRepository,
+) *ServiceAuthMiddleware {
+	return &ServiceAuthMiddleware{
+		clientRepository: clientRepository,
+	}
+}
+
+// BasicAuthHandler only lets through registered clients allowed scope,
+// authenticated with their client id and secret over HTTP Basic.
+func (sm *ServiceAuthMiddleware) BasicAuthHandler(scope domain.Permission) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		client, ok := sm.authenticateClient(ctx)
+
+		if !ok {
+			ctx.Set(fiber.HeaderWWWAuthenticate, `Basic realm="identity"`)
+			return shared.WriteProblemDetails(ctx, *fails.INVALID_CLIENT_CREDENTIALS)
+		}
+
+		if !slices.Contains(client.AllowedScopes(), string(scope)) {
+			return shared.WriteProblemDetails(ctx, *fails.INSUFFICIENT_SCOPE)
+		}
+
+		return ctx.Next()
+	}
+}
+
+func (sm *ServiceAuthMiddleware) authenticateClient(ctx *fiber.Ctx) (*domain.OAuthClient, bool) {
+	clientID, clientSecret, ok := ParseBasicAuth(ctx)
+
+	if !ok || clientID == "" || clientSecret == "" {
+		return nil, false
+	}
+
+	client, err := sm.clientRepository.GetByClientId(clientID)
+
+	if err != nil || !client.VerifySecret(clientSecret) {
+		return nil, false
+	}
+
+	return client, true
+}
+
+// ScopeHandler only lets through machine tokens, issued by the client
+// credentials grant to a client that is still registered, granted scope.
+func (sm *ServiceAuthMiddleware) ScopeHandler(scope domain.Permission) fiber.Handler {
+	return jwtware.New(jwtware.Config{
+		Claims:  &services.AuthClaims{},
+		KeyFunc: services.TypedVerificationKey(services.Machine),
+		SuccessHandler: func(ctx *fiber.Ctx) error {
+			_, claims, err := GetClaims(ctx)
+
+			if err != nil {
+				return shared.WriteProblemDetails(ctx, *fails.INVALID_ACCESS_TOKEN)
+			}
+
+			if !slices.Contains(claims.Scope, string(scope)) {
+				return shared.WriteProblemDetails(ctx, *fails.INSUFFICIENT_SCOPE)
+			}
+
+			if ok := sm.clientRepository.ExistsClientWithClientId(claims.Subject); !ok {
+				return shared.WriteProblemDetails(ctx, *fails.DONT_HAVE_ACCESS_TO_RESOURCE)
+			}
+
+			return ctx.Next()
+		},
+		ErrorHandler: func(ctx *fiber.Ctx, err error) error {
+			return shared.WriteProblemDetails(ctx, *fails.DONT_HAVE_ACCESS_TO_RESOURCE)
+		},
+	})
+}
+
+// ParseBasicAuth reads the client id and secret of an HTTP Basic
+// Authorization header.
+func ParseBasicAuth(ctx *fiber.Ctx) (string, string, bool) {
+	header := ctx.Get(fiber.HeaderAuthorization)
+
+	if len(header) <= 6 || !strings.EqualFold(header[:6], "basic ") {
+		return "", "", false
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(header[6:])
+
+	if err != nil {
+		return "", "", false
+	}
+
+	return strings.Cut(string(raw), ":")
+}
@@ -1,8 +1,11 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/BeatEcoprove/identityService/internal/domain"
 	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+	"gorm.io/gorm"
 )
 
 type (
@@ -15,6 +18,8 @@ type (
 		ExistsUserWithId(id string) bool
 		ExistsUserWithEmail(email string) bool
 		GetUserByEmail(email string) (*domain.IdentityUser, error)
+		GetDeletionsRequestedBefore(before time.Time) ([]domain.IdentityUser, error)
+		Purge(identityUser *domain.IdentityUser) error
 	}
 )
 
@@ -41,3 +46,39 @@ func (repo *AuthRepository) GetUserByEmail(email string) (*domain.IdentityUser,
 
 	return identityUser, nil
 }
+
+func (repo *AuthRepository) GetDeletionsRequestedBefore(before time.Time) ([]domain.IdentityUser, error) {
+	var identityUsers []domain.IdentityUser
+
+	if err := repo.Context.Statement.Where("deletion_requested_at <= ?", before).Find(&identityUsers).Error; err != nil {
+		return nil, err
+	}
+
+	return identityUsers, nil
+}
+
+// Purge erases the account and every row that belongs to it for good, soft
+// deleted rows included, all of it or nothing.
+func (repo *AuthRepository) Purge(identityUser *domain.IdentityUser) error {
+	return repo.Context.Statement.Transaction(func(tx *gorm.DB) error {
+		owned := []any{
+			&domain.Profile{},
+			&domain.RecoveryCode{},
+			&domain.WebAuthnCredential{},
+			&domain.ExternalIdentity{},
+			&domain.PasswordHistory{},
+		}
+
+		for _, model := range owned {
+			if err := tx.Unscoped().Where("auth_id = ?", identityUser.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Unscoped().Where("member_id = ?", identityUser.ID).Delete(&domain.MemberChatPermission{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(identityUser).Error
+	})
+}
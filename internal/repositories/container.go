@@ -1,7 +1,13 @@
 package repositories
 
 type Repositories struct {
-	Auth       IAuthRepository
-	Profile    IProfileRepository
-	MemberChat IMemberChatRepository
+	Auth        IAuthRepository
+	Profile     IProfileRepository
+	MemberChat  IMemberChatRepository
+	OAuthClient IOAuthClientRepository
+
+	RecoveryCode       IRecoveryCodeRepository
+	WebAuthnCredential IWebAuthnCredentialRepository
+	ExternalIdentity   IExternalIdentityRepository
+	PasswordHistory    IPasswordHistoryRepository
 }
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+)
+
+type (
+	ExternalIdentityRepository struct {
+		interfaces.RepositoryBase[*domain.ExternalIdentity]
+	}
+
+	IExternalIdentityRepository interface {
+		interfaces.Repository[*domain.ExternalIdentity]
+		GetByProviderSubject(provider, subject string) (*domain.ExternalIdentity, error)
+		GetByAuthId(authID string) ([]domain.ExternalIdentity, error)
+		Unlink(identity *domain.ExternalIdentity) error
+	}
+)
+
+func NewExternalIdentityRepository(database interfaces.Database) *ExternalIdentityRepository {
+	return &ExternalIdentityRepository{
+		RepositoryBase: *interfaces.NewRepositoryBase[*domain.ExternalIdentity](database),
+	}
+}
+
+func (repo *ExternalIdentityRepository) GetByProviderSubject(provider, subject string) (*domain.ExternalIdentity, error) {
+	var identity domain.ExternalIdentity
+
+	if err := repo.Context.Statement.Where("provider = ? and subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (repo *ExternalIdentityRepository) GetByAuthId(authID string) ([]domain.ExternalIdentity, error) {
+	var identities []domain.ExternalIdentity
+
+	if err := repo.Context.Statement.Where("auth_id = ?", authID).Order("created_at").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// Unlink deletes the row for good, the provider subject may be linked again
+// later, to this account or another one.
+func (repo *ExternalIdentityRepository) Unlink(identity *domain.ExternalIdentity) error {
+	return repo.Context.Statement.DB.Unscoped().Delete(identity).Error
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+)
+
+type (
+	OAuthClientRepository struct {
+		interfaces.RepositoryBase[*domain.OAuthClient]
+	}
+
+	IOAuthClientRepository interface {
+		interfaces.Repository[*domain.OAuthClient]
+		ExistsClientWithClientId(clientID string) bool
+		GetByClientId(clientID string) (*domain.OAuthClient, error)
+	}
+)
+
+func NewOAuthClientRepository(database interfaces.Database) *OAuthClientRepository {
+	return &OAuthClientRepository{
+		RepositoryBase: *interfaces.NewRepositoryBase[*domain.OAuthClient](database),
+	}
+}
+
+func (repo *OAuthClientRepository) ExistsClientWithClientId(clientID string) bool {
+	return repo.Context.Statement.Where("client_id = ?", clientID).First(&domain.OAuthClient{}).Error == nil
+}
+
+func (repo *OAuthClientRepository) GetByClientId(clientID string) (*domain.OAuthClient, error) {
+	var client *domain.OAuthClient
+
+	if err := repo.Context.Statement.Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
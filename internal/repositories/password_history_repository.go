@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+)
+
+type (
+	PasswordHistoryRepository struct {
+		interfaces.RepositoryBase[*domain.PasswordHistory]
+	}
+
+	IPasswordHistoryRepository interface {
+		interfaces.Repository[*domain.PasswordHistory]
+		GetRecentByAuthId(authID string, limit int) ([]domain.PasswordHistory, error)
+		Prune(authID string, keep int) error
+		DeleteByAuthId(authID string) error
+	}
+)
+
+func NewPasswordHistoryRepository(database interfaces.Database) *PasswordHistoryRepository {
+	return &PasswordHistoryRepository{
+		RepositoryBase: *interfaces.NewRepositoryBase[*domain.PasswordHistory](database),
+	}
+}
+
+func (repo *PasswordHistoryRepository) GetRecentByAuthId(authID string, limit int) ([]domain.PasswordHistory, error) {
+	var history []domain.PasswordHistory
+
+	if err := repo.Context.Statement.Where("auth_id = ?", authID).Order("created_at desc").Limit(limit).Find(&history).Error; err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// Prune deletes all but the keep most recent passwords of the account.
+func (repo *PasswordHistoryRepository) Prune(authID string, keep int) error {
+	if keep <= 0 {
+		return repo.DeleteByAuthId(authID)
+	}
+
+	recent := repo.Context.Statement.DB.
+		Model(&domain.PasswordHistory{}).
+		Select("id").
+		Where("auth_id = ?", authID).
+		Order("created_at desc").
+		Limit(keep)
+
+	return repo.Context.Statement.DB.Unscoped().
+		Where("auth_id = ?", authID).
+		Where("id not in (?)", recent).
+		Delete(&domain.PasswordHistory{}).Error
+}
+
+func (repo *PasswordHistoryRepository) DeleteByAuthId(authID string) error {
+	return repo.Context.Statement.DB.Unscoped().Where("auth_id = ?", authID).Delete(&domain.PasswordHistory{}).Error
+}
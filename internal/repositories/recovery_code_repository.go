@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+	"gorm.io/gorm"
+)
+
+type (
+	RecoveryCodeRepository struct {
+		interfaces.RepositoryBase[*domain.RecoveryCode]
+	}
+
+	IRecoveryCodeRepository interface {
+		interfaces.Repository[*domain.RecoveryCode]
+		GetUnusedByAuthId(authID string) ([]domain.RecoveryCode, error)
+		MarkUsed(id string) (bool, error)
+		ReplaceForAuthId(authID string, codes []*domain.RecoveryCode) error
+		DeleteByAuthId(authID string) error
+	}
+)
+
+func NewRecoveryCodeRepository(database interfaces.Database) *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{
+		RepositoryBase: *interfaces.NewRepositoryBase[*domain.RecoveryCode](database),
+	}
+}
+
+func (repo *RecoveryCodeRepository) GetUnusedByAuthId(authID string) ([]domain.RecoveryCode, error) {
+	var codes []domain.RecoveryCode
+
+	if err := repo.Context.Statement.Where("auth_id = ?", authID).Where("used_at is null").Find(&codes).Error; err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// MarkUsed reports false when the code was used in the meantime, two
+// concurrent logins can't both spend it.
+func (repo *RecoveryCodeRepository) MarkUsed(id string) (bool, error) {
+	result := repo.Context.Statement.DB.
+		Model(&domain.RecoveryCode{}).
+		Where("id = ?", id).
+		Where("used_at is null").
+		Update("used_at", gorm.Expr("now()"))
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// ReplaceForAuthId swaps every code of a user for a new set, the previous
+// codes stop working at once.
+func (repo *RecoveryCodeRepository) ReplaceForAuthId(authID string, codes []*domain.RecoveryCode) error {
+	return repo.Context.Statement.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("auth_id = ?", authID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(codes).Error
+	})
+}
+
+func (repo *RecoveryCodeRepository) DeleteByAuthId(authID string) error {
+	return repo.Context.Statement.DB.Unscoped().Where("auth_id = ?", authID).Delete(&domain.RecoveryCode{}).Error
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+)
+
+type (
+	WebAuthnCredentialRepository struct {
+		interfaces.RepositoryBase[*domain.WebAuthnCredential]
+	}
+
+	IWebAuthnCredentialRepository interface {
+		interfaces.Repository[*domain.WebAuthnCredential]
+		GetByAuthId(authID string) ([]domain.WebAuthnCredential, error)
+		ExistsCredentialWithId(credentialID []byte) bool
+	}
+)
+
+func NewWebAuthnCredentialRepository(database interfaces.Database) *WebAuthnCredentialRepository {
+	return &WebAuthnCredentialRepository{
+		RepositoryBase: *interfaces.NewRepositoryBase[*domain.WebAuthnCredential](database),
+	}
+}
+
+func (repo *WebAuthnCredentialRepository) GetByAuthId(authID string) ([]domain.WebAuthnCredential, error) {
+	var credentials []domain.WebAuthnCredential
+
+	if err := repo.Context.Statement.Where("auth_id = ?", authID).Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+func (repo *WebAuthnCredentialRepository) ExistsCredentialWithId(credentialID []byte) bool {
+	return repo.Context.Statement.Where("credential_id = ?", credentialID).First(&domain.WebAuthnCredential{}).Error == nil
+}
@@ -0,0 +1,252 @@
+package usecases
+
+import (
+	"log"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/config"
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/domain/events"
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	DeleteAccountInput struct {
+		AuthId    string
+		SessionId string
+		Password  string
+		Device    services.DeviceInfo
+	}
+
+	// input
+	RestoreAccountInput struct {
+		Email        string
+		Password     string
+		MFAToken     string
+		OTP          string
+		RecoveryCode string
+		Device       services.DeviceInfo
+	}
+
+	// input
+	PurgeDeletedAccountsInput struct {
+		Now time.Time
+	}
+
+	DeleteAccountUseCase struct {
+		authRepo      repositories.IAuthRepository
+		tokenService  services.ITokenService
+		authenticator *passwordAuthenticator
+	}
+
+	RestoreAccountUseCase struct {
+		authRepo      repositories.IAuthRepository
+		authenticator *passwordAuthenticator
+		mfa           *mfaVerifier
+	}
+
+	PurgeDeletedAccountsUseCase struct {
+		authRepo     repositories.IAuthRepository
+		profileRepo  repositories.IProfileRepository
+		tokenService services.ITokenService
+		broker       adapters.Broker
+	}
+)
+
+const defaultAccountDeletionGrace = 30 * 24 * time.Hour
+
+// AccountDeletionGrace is configured in days, an account can be restored for
+// this long after its deletion was requested.
+func AccountDeletionGrace() time.Duration {
+	grace := time.Duration(config.GetConfig().ACCOUNT_DELETION_GRACE_DAYS) * time.Hour * 24
+
+	if grace <= 0 {
+		return defaultAccountDeletionGrace
+	}
+
+	return grace
+}
+
+func NewDeleteAccountUseCase(
+	authRepo repositories.IAuthRepository,
+	tokenService services.ITokenService,
+	attempts services.ILoginAttemptService,
+	broker adapters.Broker,
+) *DeleteAccountUseCase {
+	return &DeleteAccountUseCase{
+		authRepo:      authRepo,
+		tokenService:  tokenService,
+		authenticator: newPasswordAuthenticator(authRepo, attempts, broker),
+	}
+}
+
+func NewRestoreAccountUseCase(
+	authRepo repositories.IAuthRepository,
+	attempts services.ILoginAttemptService,
+	challenges services.IMFAChallengeService,
+	totp services.ITOTPService,
+	recoveryCodes repositories.IRecoveryCodeRepository,
+	broker adapters.Broker,
+) *RestoreAccountUseCase {
+	return &RestoreAccountUseCase{
+		authRepo:      authRepo,
+		authenticator: newPasswordAuthenticator(authRepo, attempts, broker),
+		mfa:           newMFAVerifier(authRepo, challenges, totp, recoveryCodes, broker),
+	}
+}
+
+func NewPurgeDeletedAccountsUseCase(
+	authRepo repositories.IAuthRepository,
+	profileRepo repositories.IProfileRepository,
+	tokenService services.ITokenService,
+	broker adapters.Broker,
+) *PurgeDeletedAccountsUseCase {
+	return &PurgeDeletedAccountsUseCase{
+		authRepo:     authRepo,
+		profileRepo:  profileRepo,
+		tokenService: tokenService,
+		broker:       broker,
+	}
+}
+
+// Handle disables the signed in account and schedules it to be erased once
+// the grace period is over. A wrong password counts towards the login
+// lockout. Every session is signed out, tokens aren't issued again unless the
+// account is restored.
+func (dau *DeleteAccountUseCase) Handle(request DeleteAccountInput) (*contracts.AccountDeletionResponse, error) {
+	identityUser, err := dau.authRepo.Get(request.AuthId)
+
+	if err != nil {
+		return nil, fails.USER_NOT_FOUND
+	}
+
+	if err := dau.authenticator.reauthenticate(dau.tokenService, identityUser, request.SessionId, request.Password, request.Device); err != nil {
+		return nil, err
+	}
+
+	if !identityUser.IsPendingDeletion() {
+		identityUser.RequestDeletion(time.Now())
+
+		if err := dau.authRepo.Update(identityUser); err != nil {
+			return nil, fails.InternalServerError()
+		}
+	}
+
+	// the deletion is scheduled already, a failure here must not report
+	// otherwise
+	if err := dau.tokenService.RevokeSessions(identityUser.ID); err != nil {
+		log.Printf("failed to revoke the sessions of %s: %s", identityUser.ID, err.Error())
+	}
+
+	return &contracts.AccountDeletionResponse{
+		Message:   "The account will be deleted, sign in through restore to keep it.",
+		DeletesAt: identityUser.DeletesAt(AccountDeletionGrace()),
+	}, nil
+}
+
+// Handle cancels a pending deletion, the credentials are checked the way
+// login does, lockout and second factor included. Accounts without a
+// password set one through forgot-password first.
+func (rau *RestoreAccountUseCase) Handle(request RestoreAccountInput) (*contracts.GenericResponse, error) {
+	identityUser, err := rau.login(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !identityUser.IsPendingDeletion() {
+		return nil, fails.ACCOUNT_NOT_PENDING_DELETION
+	}
+
+	identityUser.CancelDeletion()
+
+	if err := rau.authRepo.Update(identityUser); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return &contracts.GenericResponse{
+		Message: "The account was restored, you can sign in again.",
+	}, nil
+}
+
+// login takes the password first and, for accounts with a second factor,
+// the code or a recovery code together with the mfa token of the first step.
+func (rau *RestoreAccountUseCase) login(request RestoreAccountInput) (*domain.IdentityUser, error) {
+	if request.MFAToken != "" {
+		identityUser, _, err := rau.mfa.verify(request.MFAToken, request.OTP, request.RecoveryCode, request.Device)
+		return identityUser, err
+	}
+
+	identityUser, err := rau.authenticator.authenticate(request.Email, request.Password, request.Device)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := challengeMFA(rau.mfa.challenges, identityUser, ""); err != nil {
+		return nil, err
+	}
+
+	return identityUser, nil
+}
+
+// Handle erases the accounts whose grace period ended before request.Now,
+// other services are told through a user_deleted event. An account that
+// fails is left for the next run, the others go on.
+func (pdu *PurgeDeletedAccountsUseCase) Handle(request PurgeDeletedAccountsInput) (int, error) {
+	identityUsers, err := pdu.authRepo.GetDeletionsRequestedBefore(request.Now.Add(-AccountDeletionGrace()))
+
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+
+	for i := range identityUsers {
+		if err := pdu.purge(&identityUsers[i], request.Now); err != nil {
+			log.Printf("failed to purge the account %s: %s", identityUsers[i].ID, err.Error())
+			continue
+		}
+
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (pdu *PurgeDeletedAccountsUseCase) purge(identityUser *domain.IdentityUser, now time.Time) error {
+	profiles, err := pdu.profileRepo.GetAttachProfiles(identityUser.ID)
+
+	if err != nil {
+		return err
+	}
+
+	profileIDs := make([]string, 0, len(profiles))
+
+	for _, profile := range profiles {
+		profileIDs = append(profileIDs, profile.ID)
+	}
+
+	// the event goes first, without it the other services would keep their
+	// data, an account that fails to purge sends it again next run
+	if err := pdu.broker.Publish(&events.UserDeletedEvent{
+		AuthID:              identityUser.ID,
+		Email:               identityUser.Email,
+		ProfileIDs:          profileIDs,
+		DeletionRequestedAt: *identityUser.DeletionRequestedAt,
+		DeletedAt:           now,
+	}, adapters.AuthEventTopic); err != nil {
+		return err
+	}
+
+	if err := pdu.tokenService.RevokeSessions(identityUser.ID); err != nil {
+		return err
+	}
+
+	return pdu.authRepo.Purge(identityUser)
+}
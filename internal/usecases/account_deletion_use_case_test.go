@@ -0,0 +1,235 @@
+package usecases
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/domain/events"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Account_Deletion_UseCase(t *testing.T) {
+	t.Setenv("ACCOUNT_DELETION_GRACE_DAYS", "7")
+	t.Setenv("MFA_ENCRYPTION_KEY", "test-mfa-key")
+	InitTest()
+	SetupLoginAttempts()
+
+	grace := 7 * 24 * time.Hour
+
+	deleteAccount := NewDeleteAccountUseCase(AuthRepository, TokenService, LoginAttemptService, RabbitMq)
+	restoreAccount := NewRestoreAccountUseCase(AuthRepository, LoginAttemptService, MFAChallengeService, TOTPService, RecoveryCodeRepository, RabbitMq)
+	purgeAccounts := NewPurgeDeletedAccountsUseCase(AuthRepository, ProfileRepository, TokenService, RabbitMq)
+	login := NewLoginUseCase(AuthRepository, ProfileRepository, TokenService, LoginAttemptService, MFAChallengeService, RabbitMq)
+
+	RabbitMq.On("Publish", mock.Anything).Return(nil)
+	Redis.On("RemoveFromSet", mock.Anything, mock.Anything).Return(nil)
+
+	// getSignInUser makes the account reachable by email the way login looks
+	// it up.
+	getSignInUser := func(t *testing.T) *domain.IdentityUser {
+		identityUser := getUnverifiedUser(t)
+		AuthRepository.On("ExistsUserWithEmail", identityUser.Email).Return(true)
+		AuthRepository.On("GetUserByEmail", identityUser.Email).Return(identityUser, nil)
+
+		return identityUser
+	}
+
+	t.Run("Should ask an old session to re-authenticate before deleting", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		sessionID := uuid.NewString()
+		storeSession(t, identityUser.ID, sessionID, time.Now().Add(-time.Hour))
+
+		// Act
+		_, err := deleteAccount.Handle(DeleteAccountInput{
+			AuthId:    identityUser.ID,
+			SessionId: sessionID,
+		})
+
+		// Assert
+		evaluateError(t, fails.REAUTHENTICATION_REQUIRED, err)
+		assert.False(t, identityUser.IsPendingDeletion())
+		AuthRepository.AssertNotCalled(t, "Update", identityUser)
+	})
+
+	t.Run("Should refuse a wrong password", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+
+		// Act
+		_, err := deleteAccount.Handle(DeleteAccountInput{
+			AuthId:   identityUser.ID,
+			Password: "WrongPassword1",
+		})
+
+		// Assert
+		evaluateError(t, fails.USER_AUTH_FAILED, err)
+		assert.False(t, identityUser.IsPendingDeletion())
+		Redis.AssertCalled(t, "Increment", services.NewLoginAttemptsKey(services.LoginAccountScope, strings.ToLower(identityUser.Email)), mock.Anything)
+	})
+
+	t.Run("Should not check the password of a locked out account", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		lockOut(identityUser.Email)
+
+		// Act
+		_, err := deleteAccount.Handle(DeleteAccountInput{
+			AuthId:   identityUser.ID,
+			Password: DefaultPassword,
+		})
+
+		// Assert
+		evaluateError(t, fails.USER_LOCKED, err)
+		assert.False(t, identityUser.IsPendingDeletion())
+	})
+
+	t.Run("Should schedule the deletion and sign out every session", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		sessionID := uuid.NewString()
+		AuthRepository.On("Update", identityUser).Return(nil).Once()
+		Redis.On("GetSetMembers", services.NewSessionsKey(identityUser.ID)).Return([]string{sessionID}, nil)
+
+		// Act
+		response, err := deleteAccount.Handle(DeleteAccountInput{
+			AuthId:    identityUser.ID,
+			SessionId: sessionID,
+			Password:  DefaultPassword,
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.True(t, identityUser.IsPendingDeletion())
+		assert.WithinDuration(t, time.Now().Add(grace), response.DeletesAt, time.Minute)
+		AuthRepository.AssertCalled(t, "Update", identityUser)
+		Redis.AssertCalled(t, "RemoveFromSet", services.NewSessionsKey(identityUser.ID), []string{sessionID})
+	})
+
+	t.Run("Should not issue tokens to an account pending deletion", func(t *testing.T) {
+		identityUser := getSignInUser(t)
+		identityUser.RequestDeletion(time.Now())
+
+		// Act
+		_, err := login.Handle(LoginInput{
+			Email:    identityUser.Email,
+			Password: DefaultPassword,
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		evaluateError(t, fails.ACCOUNT_PENDING_DELETION, err)
+	})
+
+	t.Run("Should restore an account pending deletion", func(t *testing.T) {
+		identityUser := getSignInUser(t)
+		identityUser.RequestDeletion(time.Now().Add(-time.Hour))
+		AuthRepository.On("Update", identityUser).Return(nil).Once()
+
+		// Act
+		_, err := restoreAccount.Handle(RestoreAccountInput{
+			Email:    identityUser.Email,
+			Password: DefaultPassword,
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.False(t, identityUser.IsPendingDeletion())
+		AuthRepository.AssertCalled(t, "Update", identityUser)
+	})
+
+	t.Run("Should ask an account with a second factor for a code before restoring", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		identityUser.RequestDeletion(time.Now().Add(-time.Hour))
+		AuthRepository.On("ExistsUserWithEmail", identityUser.Email).Return(true)
+		AuthRepository.On("GetUserByEmail", identityUser.Email).Return(identityUser, nil)
+		Redis.On("SetValue", mock.Anything, mock.Anything, services.MFAChallengeLifetime).Return(nil).Once()
+
+		// Act
+		_, err := restoreAccount.Handle(RestoreAccountInput{
+			Email:    identityUser.Email,
+			Password: DefaultPassword,
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		_, ok := err.(*MFARequiredError)
+		assert.True(t, ok)
+		assert.True(t, identityUser.IsPendingDeletion())
+		AuthRepository.AssertNotCalled(t, "Update", identityUser)
+	})
+
+	t.Run("Should restore an account with a second factor once the code passed", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		identityUser.RequestDeletion(time.Now().Add(-time.Hour))
+		token := storeMFAChallenge(t, services.MFAChallenge{AuthID: identityUser.ID})
+		AuthRepository.On("Update", identityUser).Return(nil).Once()
+		Redis.On("GetValue", services.NewTOTPCounterKey(identityUser.ID)).Return("", nil).Once()
+		Redis.On("SetValue", services.NewTOTPCounterKey(identityUser.ID), mock.Anything, mock.Anything).Return(nil).Once()
+
+		// Act
+		_, err := restoreAccount.Handle(RestoreAccountInput{
+			MFAToken: token,
+			OTP:      currentTOTPCode(t),
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.False(t, identityUser.IsPendingDeletion())
+		Redis.AssertCalled(t, "GetAndDelValue", services.NewMFAChallengeKey(token))
+	})
+
+	t.Run("Should not restore an account that isn't pending deletion", func(t *testing.T) {
+		identityUser := getSignInUser(t)
+
+		// Act
+		_, err := restoreAccount.Handle(RestoreAccountInput{
+			Email:    identityUser.Email,
+			Password: DefaultPassword,
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		evaluateError(t, fails.ACCOUNT_NOT_PENDING_DELETION, err)
+	})
+
+	t.Run("Should erase the accounts past the grace period", func(t *testing.T) {
+		now := time.Now()
+		erased := getUnverifiedUser(t)
+		erased.RequestDeletion(now.Add(-grace - time.Hour))
+		failing := getUnverifiedUser(t)
+		failing.RequestDeletion(now.Add(-grace - time.Minute))
+		profile := domain.NewProfile(erased.ID, domain.Main)
+		profile.GetId()
+
+		AuthRepository.On("GetDeletionsRequestedBefore", now.Add(-grace)).Return([]domain.IdentityUser{*erased, *failing}, nil).Once()
+		ProfileRepository.On("GetAttachProfiles", erased.ID).Return([]domain.Profile{*profile}, nil)
+		ProfileRepository.On("GetAttachProfiles", failing.ID).Return([]domain.Profile{}, nil)
+		Redis.On("GetSetMembers", services.NewSessionsKey(erased.ID)).Return([]string{}, nil)
+		Redis.On("GetSetMembers", services.NewSessionsKey(failing.ID)).Return([]string{}, nil)
+		AuthRepository.On("Purge", mock.MatchedBy(func(identityUser *domain.IdentityUser) bool {
+			return identityUser.ID == erased.ID
+		})).Return(nil).Once()
+		AuthRepository.On("Purge", mock.MatchedBy(func(identityUser *domain.IdentityUser) bool {
+			return identityUser.ID == failing.ID
+		})).Return(errors.ErrUnsupported).Once()
+
+		// Act
+		purged, err := purgeAccounts.Handle(PurgeDeletedAccountsInput{Now: now})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 1, purged)
+		RabbitMq.AssertCalled(t, "Publish", mock.MatchedBy(func(event *events.UserDeletedEvent) bool {
+			return event.AuthID == erased.ID &&
+				event.Email == erased.Email &&
+				assert.ObjectsAreEqual([]string{profile.ID}, event.ProfileIDs) &&
+				event.DeletedAt.Equal(now)
+		}))
+	})
+}
@@ -14,6 +14,7 @@ type (
 	// input
 	AttachProfileInput struct {
 		AuthId           string
+		SessionId        string
 		ProfileGrantType int
 	}
 
@@ -71,13 +72,15 @@ func (apu *AttachProfileUseCase) Handle(request AttachProfileInput) (*contracts.
 	}
 
 	identityUser.IsActive = false
-	accessToken, refreshToken, err := apu.tokenService.CreateAuthenticationTokens(services.TokenPayload{
-		UserID:     identityUser.ID,
-		Email:      identityUser.Email,
-		ProfileID:  profile.ID,
-		Scope:      domain.GetPermissions(*identityUser),
-		ProfileIds: make([]string, 0),
-		Role:       string(identityUser.GetRole()),
+	tokens, err := apu.tokenService.CreateAuthenticationTokens(services.TokenPayload{
+		UserID:        identityUser.ID,
+		SessionID:     request.SessionId,
+		Email:         identityUser.Email,
+		EmailVerified: identityUser.EmailVerified,
+		ProfileID:     profile.ID,
+		Scope:         domain.GetPermissions(*identityUser),
+		ProfileIds:    make([]string, 0),
+		Role:          string(identityUser.GetRole()),
 	})
 
 	if err != nil {
@@ -90,7 +93,6 @@ func (apu *AttachProfileUseCase) Handle(request AttachProfileInput) (*contracts.
 
 	return mappers.ToAuthResponse(
 		identityUser,
-		accessToken,
-		refreshToken,
+		tokens,
 	), nil
 }
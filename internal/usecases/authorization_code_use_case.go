@@ -0,0 +1,66 @@
+package usecases
+
+import (
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	AuthorizationCodeInput struct {
+		Code         string
+		ClientID     string
+		RedirectURI  string
+		CodeVerifier string
+		Device       services.DeviceInfo
+	}
+
+	AuthorizationCodeUseCase struct {
+		authRepo     repositories.IAuthRepository
+		profileRepo  repositories.IProfileRepository
+		tokenService services.ITokenService
+		codeService  services.IAuthorizationCodeService
+	}
+)
+
+func NewAuthorizationCodeUseCase(
+	authRepo repositories.IAuthRepository,
+	profileRepo repositories.IProfileRepository,
+	tokenService services.ITokenService,
+	codeService services.IAuthorizationCodeService,
+) *AuthorizationCodeUseCase {
+	return &AuthorizationCodeUseCase{
+		authRepo:     authRepo,
+		profileRepo:  profileRepo,
+		tokenService: tokenService,
+		codeService:  codeService,
+	}
+}
+
+// Handle exchanges an authorization code for tokens. The code is consumed
+// before anything is checked, a failed attempt burns it.
+func (acu *AuthorizationCodeUseCase) Handle(input AuthorizationCodeInput) (*contracts.AuthResponse, error) {
+	grant, err := acu.codeService.ConsumeCode(input.Code)
+
+	if err != nil {
+		return nil, fails.INVALID_AUTHORIZATION_CODE
+	}
+
+	if grant.ClientID != input.ClientID || grant.RedirectURI != input.RedirectURI {
+		return nil, fails.INVALID_AUTHORIZATION_CODE
+	}
+
+	if !services.VerifyCodeChallenge(input.CodeVerifier, grant.CodeChallenge, grant.CodeChallengeMethod) {
+		return nil, fails.INVALID_AUTHORIZATION_CODE
+	}
+
+	identityUser, err := acu.authRepo.Get(grant.AuthID)
+
+	if err != nil {
+		return nil, fails.USER_NOT_FOUND
+	}
+
+	return issueTokens(acu.profileRepo, acu.tokenService, identityUser, input.Device, grant.ClientID, grant.Nonce)
+}
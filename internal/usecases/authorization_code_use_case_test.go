@@ -0,0 +1,251 @@
+package usecases
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testRedirectURI  = "https://app.beat.pt/callback"
+	testClientID     = "beat-spa"
+	testCodeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+func getCodeChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func storeAuthorizationCode(t *testing.T, grant services.AuthorizationGrant) string {
+	code := uuid.New().String()
+
+	rawGrant, err := json.Marshal(grant)
+	assert.Nil(t, err)
+
+	Redis.On("GetAndDelValue", services.NewAuthorizationCodeKey(code)).Return(string(rawGrant), nil)
+	return code
+}
+
+func Test_Authorize_UseCase(t *testing.T) {
+	InitTest()
+	SetupLoginAttempts()
+
+	var sut *AuthorizeUseCase = NewAuthorizeUseCase(
+		AuthRepository,
+		OAuthClientRepository,
+		services.NewAuthorizationCodeService(Redis),
+		LoginAttemptService,
+		MFAChallengeService,
+		TOTPService,
+		RecoveryCodeRepository,
+		RabbitMq,
+	)
+
+	client := domain.NewOAuthClient(testClientID, "", nil, []string{testRedirectURI, "https://other.beat.pt/callback"})
+	other := domain.NewOAuthClient("beat-admin", "", nil, []string{"https://admin.beat.pt/callback"})
+	OAuthClientRepository.On("GetByClientId", testClientID).Return(client, nil)
+	OAuthClientRepository.On("GetByClientId", other.ClientID).Return(other, nil)
+	OAuthClientRepository.On("GetByClientId", mock.Anything).Return((*domain.OAuthClient)(nil), errors.ErrUnsupported)
+
+	getAuthorizeInput := func() AuthorizeInput {
+		return AuthorizeInput{
+			ResponseType:        ResponseTypeCode,
+			ClientID:            testClientID,
+			RedirectURI:         testRedirectURI,
+			State:               "af0ifjsldkj",
+			CodeChallenge:       getCodeChallenge(testCodeVerifier),
+			CodeChallengeMethod: services.CodeChallengeS256,
+		}
+	}
+
+	t.Run("Should refuse a redirect uri that is not allowed", func(t *testing.T) {
+		input := getAuthorizeInput()
+		input.RedirectURI = "https://evil.example.com/callback"
+
+		// Act
+		err := sut.Validate(input)
+
+		// Assert
+		evaluateError(t, fails.INVALID_REDIRECT_URI, err)
+	})
+
+	t.Run("Should refuse a client that isn't registered", func(t *testing.T) {
+		input := getAuthorizeInput()
+		input.ClientID = "unknown"
+
+		// Act
+		err := sut.Validate(input)
+
+		// Assert
+		evaluateError(t, fails.UNKNOWN_CLIENT, err)
+	})
+
+	t.Run("Should refuse a redirect uri registered by another client", func(t *testing.T) {
+		input := getAuthorizeInput()
+		input.ClientID = other.ClientID
+
+		// Act
+		err := sut.Validate(input)
+
+		// Assert
+		evaluateError(t, fails.INVALID_REDIRECT_URI, err)
+	})
+
+	t.Run("Should refuse a request without a S256 code challenge", func(t *testing.T) {
+		input := getAuthorizeInput()
+		input.CodeChallengeMethod = "plain"
+
+		// Act
+		err := sut.Validate(input)
+
+		// Assert
+		evaluateError(t, fails.INVALID_AUTHORIZE_REQUEST, err)
+	})
+
+	t.Run("Should redirect back with the code and state", func(t *testing.T) {
+		var data LoginInputFaker = LoginInputFaker{}
+		generateFakeData(&data)
+
+		identityUser, err := getIdentityUser(data.Email, DefaultPassword, 0)
+		assert.Nil(t, err)
+
+		input := getAuthorizeInput()
+		input.Email = data.Email
+		input.Password = DefaultPassword
+
+		AuthRepository.On("ExistsUserWithEmail", data.Email).Return(true)
+		AuthRepository.On("GetUserByEmail", data.Email).Return(identityUser, nil)
+		Redis.On("SetValue", mock.Anything, mock.Anything, services.AuthorizationCodeLifetime).Return(nil)
+
+		// Act
+		response, err := sut.Handle(input)
+
+		// Assert
+		assert.Nil(t, err)
+
+		redirectTo, err := url.Parse(response.RedirectTo)
+		assert.Nil(t, err)
+		assert.Equal(t, "app.beat.pt", redirectTo.Host)
+		assert.Equal(t, input.State, redirectTo.Query().Get("state"))
+		assert.NotEmpty(t, redirectTo.Query().Get("code"))
+	})
+}
+
+func Test_Authorization_Code_UseCase(t *testing.T) {
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	t.Setenv("JWT_REFRESH_EXPIRED", "1")
+	InitTest()
+
+	var sut *AuthorizationCodeUseCase = NewAuthorizationCodeUseCase(
+		AuthRepository,
+		ProfileRepository,
+		TokenService,
+		services.NewAuthorizationCodeService(Redis),
+	)
+
+	getGrant := func() services.AuthorizationGrant {
+		return services.AuthorizationGrant{
+			AuthID:              uuid.New().String(),
+			ClientID:            testClientID,
+			RedirectURI:         testRedirectURI,
+			CodeChallenge:       getCodeChallenge(testCodeVerifier),
+			CodeChallengeMethod: services.CodeChallengeS256,
+			Nonce:               "n-0S6_WzA2Mj",
+		}
+	}
+
+	t.Run("Should not exchange an unknown code", func(t *testing.T) {
+		// Act
+		Redis.On("GetAndDelValue", services.NewAuthorizationCodeKey("unknown")).Return("", errors.ErrUnsupported)
+
+		_, err := sut.Handle(AuthorizationCodeInput{
+			Code:         "unknown",
+			ClientID:     testClientID,
+			RedirectURI:  testRedirectURI,
+			CodeVerifier: testCodeVerifier,
+		})
+
+		// Assert
+		evaluateError(t, fails.INVALID_AUTHORIZATION_CODE, err)
+	})
+
+	t.Run("Should not exchange a code with the wrong verifier", func(t *testing.T) {
+		code := storeAuthorizationCode(t, getGrant())
+
+		// Act
+		_, err := sut.Handle(AuthorizationCodeInput{
+			Code:         code,
+			ClientID:     testClientID,
+			RedirectURI:  testRedirectURI,
+			CodeVerifier: "wrong-verifier-wrong-verifier-wrong-verifier",
+		})
+
+		// Assert
+		evaluateError(t, fails.INVALID_AUTHORIZATION_CODE, err)
+	})
+
+	t.Run("Should not exchange a code for another redirect uri", func(t *testing.T) {
+		code := storeAuthorizationCode(t, getGrant())
+
+		// Act
+		_, err := sut.Handle(AuthorizationCodeInput{
+			Code:         code,
+			ClientID:     testClientID,
+			RedirectURI:  "https://other.beat.pt/callback",
+			CodeVerifier: testCodeVerifier,
+		})
+
+		// Assert
+		evaluateError(t, fails.INVALID_AUTHORIZATION_CODE, err)
+	})
+
+	t.Run("Should issue tokens carrying the nonce and client of the authorization request", func(t *testing.T) {
+		grant := getGrant()
+		code := storeAuthorizationCode(t, grant)
+
+		var data LoginInputFaker = LoginInputFaker{}
+		generateFakeData(&data)
+
+		identityUser, err := getIdentityUser(data.Email, DefaultPassword, 0)
+		assert.Nil(t, err)
+		identityUser.ID = grant.AuthID
+
+		AuthRepository.On("Get", grant.AuthID).Return(identityUser, nil)
+		ProfileRepository.On("GetAttachProfiles", grant.AuthID).Return([]domain.Profile{*domain.NewProfile(grant.AuthID, domain.Main)}, nil)
+		Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		Redis.On("GetValue", mock.Anything).Return("", errors.ErrUnsupported)
+		Redis.On("AddToSet", services.NewSessionsKey(grant.AuthID), mock.Anything, mock.Anything).Return(nil)
+
+		// Act
+		response, err := sut.Handle(AuthorizationCodeInput{
+			Code:         code,
+			ClientID:     testClientID,
+			RedirectURI:  testRedirectURI,
+			CodeVerifier: testCodeVerifier,
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+
+		claims := getIDTokenClaims(t, response.IDToken)
+		assert.Equal(t, grant.Nonce, claims.Nonce)
+		assert.Equal(t, jwt.ClaimStrings{testClientID}, claims.Audience)
+		Redis.AssertCalled(t, "SetValue", services.NewSessionKey(grant.AuthID, claims.SessionID), mock.MatchedBy(func(rawSession string) bool {
+			var session services.Session
+			return json.Unmarshal([]byte(rawSession), &session) == nil && session.ClientID == testClientID
+		}), mock.Anything)
+	})
+}
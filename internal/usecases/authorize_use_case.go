@@ -0,0 +1,152 @@
+package usecases
+
+import (
+	"net/url"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	AuthorizeInput struct {
+		ResponseType        string
+		ClientID            string
+		RedirectURI         string
+		State               string
+		Nonce               string
+		CodeChallenge       string
+		CodeChallengeMethod string
+		Email               string
+		Password            string
+		MFAToken            string
+		OTP                 string
+		RecoveryCode        string
+		Device              services.DeviceInfo
+	}
+
+	AuthorizeUseCase struct {
+		clientRepo    repositories.IOAuthClientRepository
+		authenticator *passwordAuthenticator
+		mfa           *mfaVerifier
+		codeService   services.IAuthorizationCodeService
+	}
+)
+
+const ResponseTypeCode = "code"
+
+func NewAuthorizeUseCase(
+	authRepo repositories.IAuthRepository,
+	clientRepo repositories.IOAuthClientRepository,
+	codeService services.IAuthorizationCodeService,
+	attempts services.ILoginAttemptService,
+	challenges services.IMFAChallengeService,
+	totp services.ITOTPService,
+	recoveryCodes repositories.IRecoveryCodeRepository,
+	broker adapters.Broker,
+) *AuthorizeUseCase {
+	return &AuthorizeUseCase{
+		clientRepo:    clientRepo,
+		authenticator: newPasswordAuthenticator(authRepo, attempts, broker),
+		mfa:           newMFAVerifier(authRepo, challenges, totp, recoveryCodes, broker),
+		codeService:   codeService,
+	}
+}
+
+// Validate checks the authorization request before the user is asked for
+// credentials, the client must be registered with the redirect uri. Only the
+// code flow with a S256 PKCE challenge is supported.
+func (au *AuthorizeUseCase) Validate(input AuthorizeInput) error {
+	if input.ClientID == "" {
+		return fails.INVALID_AUTHORIZE_REQUEST
+	}
+
+	client, err := au.clientRepo.GetByClientId(input.ClientID)
+
+	if err != nil {
+		return fails.UNKNOWN_CLIENT
+	}
+
+	if !client.AllowsRedirectURI(input.RedirectURI) {
+		return fails.INVALID_REDIRECT_URI
+	}
+
+	if input.ResponseType != ResponseTypeCode {
+		return fails.INVALID_AUTHORIZE_REQUEST
+	}
+
+	if input.CodeChallenge == "" || input.CodeChallengeMethod != services.CodeChallengeS256 {
+		return fails.INVALID_AUTHORIZE_REQUEST
+	}
+
+	return nil
+}
+
+func (au *AuthorizeUseCase) Handle(input AuthorizeInput) (*contracts.AuthorizationResponse, error) {
+	if err := au.Validate(input); err != nil {
+		return nil, err
+	}
+
+	identityUser, err := au.login(input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := au.codeService.CreateCode(services.AuthorizationGrant{
+		AuthID:              identityUser.ID,
+		ClientID:            input.ClientID,
+		RedirectURI:         input.RedirectURI,
+		CodeChallenge:       input.CodeChallenge,
+		CodeChallengeMethod: input.CodeChallengeMethod,
+		Nonce:               input.Nonce,
+	})
+
+	if err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	redirectTo, err := url.Parse(input.RedirectURI)
+
+	if err != nil {
+		return nil, fails.INVALID_REDIRECT_URI
+	}
+
+	query := redirectTo.Query()
+	query.Set("code", code)
+
+	if input.State != "" {
+		query.Set("state", input.State)
+	}
+
+	redirectTo.RawQuery = query.Encode()
+
+	return &contracts.AuthorizationResponse{
+		RedirectTo: redirectTo.String(),
+	}, nil
+}
+
+// login takes the password first and, for accounts with a second factor,
+// the code or a recovery code together with the mfa token of the first step.
+func (au *AuthorizeUseCase) login(input AuthorizeInput) (*domain.IdentityUser, error) {
+	if input.MFAToken != "" {
+		identityUser, _, err := au.mfa.verify(input.MFAToken, input.OTP, input.RecoveryCode, input.Device)
+		return identityUser, err
+	}
+
+	identityUser, err := au.authenticator.authenticate(input.Email, input.Password, input.Device)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := challengeMFA(au.mfa.challenges, identityUser, input.Nonce); err != nil {
+		return nil, err
+	}
+
+	return identityUser, nil
+}
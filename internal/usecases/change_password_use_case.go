@@ -0,0 +1,109 @@
+package usecases
+
+import (
+	"log"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	ChangePasswordInput struct {
+		AuthId          string
+		SessionId       string
+		CurrentPassword string
+		NewPassword     string
+		Device          services.DeviceInfo
+	}
+
+	ChangePasswordUseCase struct {
+		authRepo     repositories.IAuthRepository
+		tokenService services.ITokenService
+		emailService services.IEmailService
+		breaches     services.IBreachedPasswordService
+		historyRepo  repositories.IPasswordHistoryRepository
+
+		authenticator *passwordAuthenticator
+	}
+)
+
+func NewChangePasswordUseCase(
+	authRepo repositories.IAuthRepository,
+	tokenService services.ITokenService,
+	emailService services.IEmailService,
+	breaches services.IBreachedPasswordService,
+	historyRepo repositories.IPasswordHistoryRepository,
+	attempts services.ILoginAttemptService,
+	broker adapters.Broker,
+) *ChangePasswordUseCase {
+	return &ChangePasswordUseCase{
+		authRepo:      authRepo,
+		tokenService:  tokenService,
+		emailService:  emailService,
+		breaches:      breaches,
+		historyRepo:   historyRepo,
+		authenticator: newPasswordAuthenticator(authRepo, attempts, broker),
+	}
+}
+
+// Handle replaces the password of the signed in account. Wrong current
+// passwords count towards the login lockout. Every other session is signed
+// out, whoever knew the old password loses access.
+func (cpu *ChangePasswordUseCase) Handle(request ChangePasswordInput) (*contracts.GenericResponse, error) {
+	identityUser, err := cpu.authRepo.Get(request.AuthId)
+
+	if err != nil {
+		return nil, fails.USER_NOT_FOUND
+	}
+
+	if err := cpu.authenticator.verify(identityUser, request.CurrentPassword, request.Device); err != nil {
+		if err == fails.USER_AUTH_FAILED {
+			return nil, fails.INVALID_CURRENT_PASSWORD
+		}
+
+		return nil, err
+	}
+
+	if err := validateNewPassword(cpu.breaches, request.NewPassword, identityUser.Email); err != nil {
+		return nil, err
+	}
+
+	if err := checkPasswordReuse(cpu.historyRepo, identityUser, request.NewPassword); err != nil {
+		return nil, err
+	}
+
+	previous := domain.NewPasswordHistory(identityUser)
+
+	if err := identityUser.SetPassword(request.NewPassword); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	if err := cpu.authRepo.Update(identityUser); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	rememberPassword(cpu.historyRepo, previous)
+
+	// the password is changed already, a failure here must not report
+	// otherwise
+	if err := cpu.tokenService.RevokeSessions(identityUser.ID, request.SessionId); err != nil {
+		log.Printf("failed to revoke the sessions of %s: %s", identityUser.ID, err.Error())
+	}
+
+	if err := cpu.emailService.Send(services.EmailInput{
+		To:       identityUser.Email,
+		Template: services.NewPasswordChangedTemplate(request.Device, time.Now()),
+	}); err != nil {
+		log.Println("Failed to send email of password changed")
+	}
+
+	return &contracts.GenericResponse{
+		Message: "The password was changed with success.",
+	}, nil
+}
@@ -0,0 +1,322 @@
+package usecases
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// breachedRange is the range line of password as a corpus lists it.
+func breachedRange(password string, count int) (string, string) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	return hash[:5], fmt.Sprintf("%s:%d", hash[5:], count)
+}
+
+func Test_Change_Password_UseCase(t *testing.T) {
+	InitTest()
+	SetupLoginAttempts()
+
+	var sut *ChangePasswordUseCase = NewChangePasswordUseCase(
+		AuthRepository,
+		TokenService,
+		EmailService,
+		BreachedPasswordService,
+		PasswordHistoryRepository,
+		LoginAttemptService,
+		RabbitMq,
+	)
+
+	AuthRepository.On("Update", mock.Anything).Return(nil)
+	RabbitMq.On("Publish", mock.Anything).Return(nil)
+	PasswordHistoryRepository.On("Create").Return(nil)
+	PasswordHistoryRepository.On("Prune", mock.Anything, mock.Anything).Return(nil)
+
+	t.Run("Should refuse a wrong current password", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+
+		// Act
+		_, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			CurrentPassword: "WrongPassword1",
+			NewPassword:     "NewPassword1",
+		})
+
+		// Assert
+		evaluateError(t, fails.INVALID_CURRENT_PASSWORD, err)
+		AuthRepository.AssertNotCalled(t, "Update", identityUser)
+		Redis.AssertCalled(t, "Increment", services.NewLoginAttemptsKey(services.LoginAccountScope, strings.ToLower(identityUser.Email)), mock.Anything)
+	})
+
+	t.Run("Should not check the current password of a locked out account", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		lockOut(identityUser.Email)
+
+		// Act
+		_, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			CurrentPassword: DefaultPassword,
+			NewPassword:     "NewPassword1",
+		})
+
+		// Assert
+		evaluateError(t, fails.USER_LOCKED, err)
+		AuthRepository.AssertNotCalled(t, "Update", identityUser)
+	})
+
+	t.Run("Should refuse an account without a password without counting a failure", func(t *testing.T) {
+		identityUser := getFederatedUser()
+
+		// Act
+		_, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			CurrentPassword: "Password1",
+			NewPassword:     "NewPassword1",
+		})
+
+		// Assert
+		evaluateError(t, fails.PASSWORD_NOT_SET, err)
+		Redis.AssertNotCalled(t, "Increment", services.NewLoginAttemptsKey(services.LoginAccountScope, strings.ToLower(identityUser.Email)), mock.Anything)
+	})
+
+	t.Run("Should refuse a weak new password", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+
+		// Act
+		_, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			CurrentPassword: DefaultPassword,
+			NewPassword:     "weakpassword",
+		})
+
+		// Assert
+		evaluateValidationError(t, "weak-password", err, "digit", "upper", "strength")
+		AuthRepository.AssertNotCalled(t, "Update", identityUser)
+	})
+
+	t.Run("Should report every rule the new password breaks", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		identityUser.Email = "john.doe@beat.pt"
+
+		// Act
+		_, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			CurrentPassword: DefaultPassword,
+			NewPassword:     "JOHN1",
+		})
+
+		// Assert
+		evaluateValidationError(t, "weak-password", err, "min_length", "lower", "banned", "strength")
+	})
+
+	t.Run("Should refuse common passwords hidden behind capitals and symbols", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+
+		// Act
+		_, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			CurrentPassword: DefaultPassword,
+			NewPassword:     "P@ssw0rd123",
+		})
+
+		// Assert
+		evaluateValidationError(t, "weak-password", err, "strength")
+	})
+
+	t.Run("Should change the password and sign out the other sessions", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		currentSession := uuid.NewString()
+		otherSession := uuid.NewString()
+		previousPassword := identityUser.Password
+
+		PasswordHistoryRepository.On("GetRecentByAuthId", identityUser.ID, 4).Return([]domain.PasswordHistory{}, nil)
+
+		Redis.On("GetSetMembers", services.NewSessionsKey(identityUser.ID)).Return([]string{currentSession, otherSession}, nil)
+		Redis.On("DelValue", mock.Anything).Return(nil)
+		Redis.On("RemoveFromSet", services.NewSessionsKey(identityUser.ID), mock.Anything).Return(nil)
+
+		// Act
+		response, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			SessionId:       currentSession,
+			CurrentPassword: DefaultPassword,
+			NewPassword:     "NewPassword1",
+			Device:          services.DeviceInfo{IP: "127.0.0.1", UserAgent: "beat-tests"},
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotNil(t, response)
+		assert.True(t, services.CheckPasswordHash("NewPassword1", identityUser.Salt, identityUser.Password))
+
+		Redis.AssertCalled(t, "RemoveFromSet", services.NewSessionsKey(identityUser.ID), []string{otherSession})
+		Redis.AssertNotCalled(t, "RemoveFromSet", services.NewSessionsKey(identityUser.ID), []string{currentSession})
+		Redis.AssertCalled(t, "DelValue", []adapters.RedisKey{
+			services.NewAccessTokenKey(identityUser.ID, otherSession),
+			services.NewRefreshTokenKey(identityUser.ID, otherSession),
+			services.NewSessionKey(identityUser.ID, otherSession),
+		})
+
+		email, err := EmailService.Last()
+		assert.Nil(t, err)
+		assert.Equal(t, identityUser.Email, email.To)
+		assert.Equal(t, "password-changed", email.Template.ID)
+		assert.Equal(t, "127.0.0.1", email.Template.Paramters["ip"])
+
+		PasswordHistoryRepository.AssertCalled(t, "Prune", identityUser.ID, 4)
+		assert.NotEqual(t, previousPassword, identityUser.Password)
+	})
+
+	t.Run("Should refuse the current password", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		assert.Nil(t, identityUser.SetPassword("Tr0ub4dor&3x"))
+
+		// Act
+		_, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			CurrentPassword: "Tr0ub4dor&3x",
+			NewPassword:     "Tr0ub4dor&3x",
+		})
+
+		// Assert
+		evaluateError(t, fails.PASSWORD_REUSED, err)
+		PasswordHistoryRepository.AssertNotCalled(t, "GetRecentByAuthId", identityUser.ID, 4)
+	})
+
+	t.Run("Should refuse a password kept in the history", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+
+		previousUser := &domain.IdentityUser{}
+		previousUser.ID = identityUser.ID
+		assert.Nil(t, previousUser.SetPassword("Tr0ub4dor&3x"))
+
+		PasswordHistoryRepository.On("GetRecentByAuthId", identityUser.ID, 4).Return([]domain.PasswordHistory{
+			*domain.NewPasswordHistory(previousUser),
+		}, nil)
+
+		// Act
+		_, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			CurrentPassword: DefaultPassword,
+			NewPassword:     "Tr0ub4dor&3x",
+		})
+
+		// Assert
+		evaluateError(t, fails.PASSWORD_REUSED, err)
+		AuthRepository.AssertNotCalled(t, "Update", identityUser)
+	})
+
+	t.Run("Should refuse a password found in a local range directory", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		prefix, line := breachedRange("Tr0ub4dor&3x", 3)
+
+		corpus := t.TempDir()
+		assert.Nil(t, os.WriteFile(filepath.Join(corpus, prefix+".txt"), []byte(line+"\r\n"), 0o600))
+
+		sut := NewChangePasswordUseCase(AuthRepository, TokenService, EmailService,
+			services.NewBreachedPasswordService(services.NewDirRangeProvider(corpus)), PasswordHistoryRepository, LoginAttemptService, RabbitMq)
+
+		// Act
+		_, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			CurrentPassword: DefaultPassword,
+			NewPassword:     "Tr0ub4dor&3x",
+		})
+
+		// Assert
+		evaluateError(t, fails.PASSWORD_BREACHED, err)
+		AuthRepository.AssertNotCalled(t, "Update", identityUser)
+	})
+
+	t.Run("Should only send the hash prefix to the range api", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		prefix, line := breachedRange("Tr0ub4dor&3x", 3)
+		_, padding := breachedRange("NewPassword1", 0)
+
+		var requested string
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = r.URL.Path
+			assert.Equal(t, "true", r.Header.Get("Add-Padding"))
+			fmt.Fprintf(w, "%s\r\n%s", line, padding)
+		}))
+		t.Cleanup(api.Close)
+
+		sut := NewChangePasswordUseCase(AuthRepository, TokenService, EmailService,
+			services.NewBreachedPasswordService(services.NewHTTPRangeProvider(api.URL+"/range")), PasswordHistoryRepository, LoginAttemptService, RabbitMq)
+
+		// Act
+		_, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			CurrentPassword: DefaultPassword,
+			NewPassword:     "Tr0ub4dor&3x",
+		})
+
+		// Assert
+		evaluateError(t, fails.PASSWORD_BREACHED, err)
+		assert.Equal(t, "/range/"+prefix, requested)
+	})
+
+	t.Run("Should let the password through when the range api is down", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		Redis.On("GetSetMembers", services.NewSessionsKey(identityUser.ID)).Return([]string{}, nil)
+		PasswordHistoryRepository.On("GetRecentByAuthId", identityUser.ID, 4).Return([]domain.PasswordHistory{}, nil)
+
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		t.Cleanup(api.Close)
+
+		sut := NewChangePasswordUseCase(AuthRepository, TokenService, EmailService,
+			services.NewBreachedPasswordService(services.NewHTTPRangeProvider(api.URL)), PasswordHistoryRepository, LoginAttemptService, RabbitMq)
+
+		// Act
+		_, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			CurrentPassword: DefaultPassword,
+			NewPassword:     "Tr0ub4dor&3x",
+		})
+
+		// Assert
+		assert.Nil(t, err)
+	})
+
+	t.Run("Should allow reuse when the history is off", func(t *testing.T) {
+		t.Setenv("PASSWORD_HISTORY_SIZE", "-1")
+		InitTest()
+		SetupLoginAttempts()
+
+		sut := NewChangePasswordUseCase(AuthRepository, TokenService, EmailService, BreachedPasswordService, PasswordHistoryRepository, LoginAttemptService, RabbitMq)
+		identityUser := getUnverifiedUser(t)
+		assert.Nil(t, identityUser.SetPassword("Tr0ub4dor&3x"))
+
+		AuthRepository.On("Update", mock.Anything).Return(nil)
+		RabbitMq.On("Publish", mock.Anything).Return(nil)
+		Redis.On("GetSetMembers", services.NewSessionsKey(identityUser.ID)).Return([]string{}, nil)
+
+		// Act
+		_, err := sut.Handle(ChangePasswordInput{
+			AuthId:          identityUser.ID,
+			CurrentPassword: "Tr0ub4dor&3x",
+			NewPassword:     "Tr0ub4dor&3x",
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		PasswordHistoryRepository.AssertNotCalled(t, "Create")
+	})
+}
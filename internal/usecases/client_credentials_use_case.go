@@ -0,0 +1,67 @@
+package usecases
+
+import (
+	"strings"
+
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	ClientCredentialsInput struct {
+		ClientID     string
+		ClientSecret string
+		Scope        []string
+	}
+
+	ClientCredentialsUseCase struct {
+		clientRepo   repositories.IOAuthClientRepository
+		tokenService services.ITokenService
+	}
+)
+
+func NewClientCredentialsUseCase(
+	clientRepo repositories.IOAuthClientRepository,
+	tokenService services.ITokenService,
+) *ClientCredentialsUseCase {
+	return &ClientCredentialsUseCase{
+		clientRepo:   clientRepo,
+		tokenService: tokenService,
+	}
+}
+
+// Handle authenticates a registered client and issues it a machine token
+// carrying the requested scopes, or every allowed scope when none is asked.
+func (ccu *ClientCredentialsUseCase) Handle(input ClientCredentialsInput) (*contracts.MachineTokenResponse, error) {
+	if input.ClientID == "" || input.ClientSecret == "" {
+		return nil, fails.INVALID_CLIENT_CREDENTIALS
+	}
+
+	client, err := ccu.clientRepo.GetByClientId(input.ClientID)
+
+	if err != nil || !client.VerifySecret(input.ClientSecret) {
+		return nil, fails.INVALID_CLIENT_CREDENTIALS
+	}
+
+	scope, ok := client.GrantScopes(input.Scope)
+
+	if !ok {
+		return nil, fails.INVALID_SCOPE
+	}
+
+	token, err := ccu.tokenService.CreateMachineToken(client.ClientID, scope)
+
+	if err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return &contracts.MachineTokenResponse{
+		TokenType:   "Bearer",
+		AccessToken: token.Token,
+		ExpiresIn:   int64(services.AccessTokenLifetime().Seconds()),
+		Scope:       strings.Join(scope, " "),
+	}, nil
+}
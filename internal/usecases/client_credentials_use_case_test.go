@@ -0,0 +1,97 @@
+package usecases
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const testClientSecret = "s3rv1ce-s3cret"
+
+func getOAuthClient(t *testing.T, scopes ...string) *domain.OAuthClient {
+	client := domain.NewOAuthClient(uuid.New().String(), "", scopes, nil)
+	assert.Nil(t, client.SetSecret(testClientSecret))
+
+	OAuthClientRepository.On("GetByClientId", client.ClientID).Return(client, nil)
+	return client
+}
+
+func Test_Client_Credentials_UseCase(t *testing.T) {
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	InitTest()
+
+	var sut *ClientCredentialsUseCase = NewClientCredentialsUseCase(
+		OAuthClientRepository,
+		TokenService,
+	)
+
+	t.Run("Should not authenticate an unknown client", func(t *testing.T) {
+		// Act
+		OAuthClientRepository.On("GetByClientId", "unknown").Return((*domain.OAuthClient)(nil), errors.ErrUnsupported)
+
+		_, err := sut.Handle(ClientCredentialsInput{
+			ClientID:     "unknown",
+			ClientSecret: testClientSecret,
+		})
+
+		// Assert
+		evaluateError(t, fails.INVALID_CLIENT_CREDENTIALS, err)
+	})
+
+	t.Run("Should not authenticate a client with the wrong secret", func(t *testing.T) {
+		client := getOAuthClient(t, string(domain.ScopeGroupPermissions))
+
+		// Act
+		_, err := sut.Handle(ClientCredentialsInput{
+			ClientID:     client.ClientID,
+			ClientSecret: "wrong-secret",
+		})
+
+		// Assert
+		evaluateError(t, fails.INVALID_CLIENT_CREDENTIALS, err)
+	})
+
+	t.Run("Should refuse a scope the client isn't allowed", func(t *testing.T) {
+		client := getOAuthClient(t, string(domain.ScopeGroupPermissions))
+
+		// Act
+		_, err := sut.Handle(ClientCredentialsInput{
+			ClientID:     client.ClientID,
+			ClientSecret: testClientSecret,
+			Scope:        []string{"profile:delete"},
+		})
+
+		// Assert
+		evaluateError(t, fails.INVALID_SCOPE, err)
+	})
+
+	t.Run("Should issue a machine token with every allowed scope", func(t *testing.T) {
+		client := getOAuthClient(t, string(domain.ScopeGroupPermissions), "profile:view")
+
+		// Act
+		response, err := sut.Handle(ClientCredentialsInput{
+			ClientID:     client.ClientID,
+			ClientSecret: testClientSecret,
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, client.Scopes, response.Scope)
+		assert.Equal(t, int64(10*60), response.ExpiresIn)
+
+		var claims services.AuthClaims
+		assert.Nil(t, services.GetClaims(response.AccessToken, &claims, services.Machine))
+		assert.Equal(t, client.ClientID, claims.Subject)
+		assert.Empty(t, claims.SessionID)
+		assert.Equal(t, strings.Fields(client.Scopes), claims.Scope)
+
+		// a machine token is not an access token
+		assert.False(t, services.ValidateToken(response.AccessToken))
+	})
+}
@@ -12,5 +12,44 @@ type UseCases struct {
 	CheckFields      *CheckFieldUseCase
 	FetchPermissions *FetchGroupUserPermissionsUseCase
 
+	ListSessions      *ListSessionsUseCase
+	RevokeSession     *RevokeSessionUseCase
+	RevokeAllSessions *RevokeAllSessionsUseCase
+	RevokeToken       *RevokeTokenUseCase
+	IntrospectToken   *IntrospectTokenUseCase
+
+	Authorize         *AuthorizeUseCase
+	AuthorizationCode *AuthorizationCodeUseCase
+	ClientCredentials *ClientCredentialsUseCase
+
+	VerifyEmail             *VerifyEmailUseCase
+	ResendVerificationEmail *ResendVerificationEmailUseCase
+
+	MFAOTP      *MFAOTPUseCase
+	EnrollTOTP  *EnrollTOTPUseCase
+	ConfirmTOTP *ConfirmTOTPUseCase
+	DisableTOTP *DisableTOTPUseCase
+
+	RegenerateRecoveryCodes *RegenerateRecoveryCodesUseCase
+
+	BeginWebAuthnRegistration  *BeginWebAuthnRegistrationUseCase
+	FinishWebAuthnRegistration *FinishWebAuthnRegistrationUseCase
+	BeginWebAuthnLogin         *BeginWebAuthnLoginUseCase
+	WebAuthn                   *WebAuthnUseCase
+
+	SendLoginCode *SendLoginCodeUseCase
+	LoginCode     *LoginCodeUseCase
+
+	FederatedLogin *FederatedLoginUseCase
+	ListIdentities *ListIdentitiesUseCase
+	LinkIdentity   *LinkIdentityUseCase
+	UnlinkIdentity *UnlinkIdentityUseCase
+
+	ChangePassword *ChangePasswordUseCase
+
+	DeleteAccount        *DeleteAccountUseCase
+	RestoreAccount       *RestoreAccountUseCase
+	PurgeDeletedAccounts *PurgeDeletedAccountsUseCase
+
 	ProfileCreateService helpers.IProfileCreateService
 }
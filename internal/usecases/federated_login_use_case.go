@@ -0,0 +1,175 @@
+package usecases
+
+import (
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/internal/usecases/helpers"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	FederatedLoginInput struct {
+		Provider      string
+		IDToken       string
+		ProviderNonce string
+		Role          string
+		Nonce         string
+		Device        services.DeviceInfo
+	}
+
+	FederatedLoginUseCase struct {
+		authRepo     repositories.IAuthRepository
+		profileRepo  repositories.IProfileRepository
+		identityRepo repositories.IExternalIdentityRepository
+		tokenService services.ITokenService
+		federation   services.IFederationService
+		challenges   services.IMFAChallengeService
+		broker       adapters.Broker
+
+		createProfileHelper helpers.IProfileCreateService
+	}
+)
+
+func NewFederatedLoginUseCase(
+	authRepo repositories.IAuthRepository,
+	profileRepo repositories.IProfileRepository,
+	identityRepo repositories.IExternalIdentityRepository,
+	tokenService services.ITokenService,
+	federation services.IFederationService,
+	challenges services.IMFAChallengeService,
+	broker adapters.Broker,
+	createProfileHelper helpers.IProfileCreateService,
+) *FederatedLoginUseCase {
+	return &FederatedLoginUseCase{
+		authRepo:            authRepo,
+		profileRepo:         profileRepo,
+		identityRepo:        identityRepo,
+		tokenService:        tokenService,
+		federation:          federation,
+		challenges:          challenges,
+		broker:              broker,
+		createProfileHelper: createProfileHelper,
+	}
+}
+
+// Handle signs in with the id token of an external provider. The provider
+// replaces the password only, accounts with a second factor are still asked
+// for it.
+func (fu *FederatedLoginUseCase) Handle(input FederatedLoginInput) (*contracts.AuthResponse, error) {
+	identity, err := verifyFederatedIdentity(fu.federation, input.Provider, input.IDToken, input.ProviderNonce)
+
+	if err != nil {
+		return nil, err
+	}
+
+	identityUser, created, err := fu.findOrCreateUser(identity, input.Role)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !created {
+		if err := challengeMFA(fu.challenges, identityUser, input.Nonce); err != nil {
+			return nil, err
+		}
+	}
+
+	return issueTokens(fu.profileRepo, fu.tokenService, identityUser, input.Device, "", input.Nonce)
+}
+
+// findOrCreateUser follows an identity that was linked before, otherwise it
+// links the account with the provider's verified email or signs one up.
+// Accounts whose email was never confirmed aren't linked, whoever signed
+// them up may not own the email.
+func (fu *FederatedLoginUseCase) findOrCreateUser(identity *services.FederatedIdentity, role string) (*domain.IdentityUser, bool, error) {
+	if linked, err := fu.identityRepo.GetByProviderSubject(identity.Provider, identity.Subject); err == nil {
+		identityUser, err := fu.authRepo.Get(linked.AuthID)
+
+		if err != nil {
+			return nil, false, fails.USER_NOT_FOUND
+		}
+
+		return identityUser, false, nil
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, false, fails.FEDERATED_EMAIL_NOT_VERIFIED
+	}
+
+	identityUser, err := fu.authRepo.GetUserByEmail(identity.Email)
+
+	if err != nil {
+		identityUser, err = fu.signUp(identity, role)
+		return identityUser, err == nil, err
+	}
+
+	if !identityUser.EmailVerified {
+		return nil, false, fails.ACCOUNT_EMAIL_NOT_VERIFIED
+	}
+
+	if _, err := linkIdentity(fu.identityRepo, fu.broker, identityUser, identity); err != nil {
+		return nil, false, err
+	}
+
+	return identityUser, false, nil
+}
+
+// signUp creates the account the way SignUpUseCase does, without a
+// password, one can be set through forgot-password.
+func (fu *FederatedLoginUseCase) signUp(identity *services.FederatedIdentity, role string) (*domain.IdentityUser, error) {
+	if role == "" {
+		role = string(domain.AuthClient)
+	}
+
+	authRole, err := domain.GetRole(domain.AuthRole(role))
+
+	if err != nil {
+		return nil, fails.ROLE_NOT_FOUND
+	}
+
+	identityUser := domain.NewIdentityUser(
+		identity.Email,
+		"",
+		domain.AuthRole(authRole),
+	)
+
+	identityUser.GetId()
+	identityUser.VerifyEmail()
+
+	signUpTransaction, err := fu.authRepo.BeginTransaction()
+
+	if err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	if err := signUpTransaction.Create(identityUser); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	if err := signUpTransaction.Create(domain.NewExternalIdentity(
+		identityUser.ID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+	)); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	if _, err := fu.createProfileHelper.CreateProfile(signUpTransaction, helpers.CreateProfileInput{
+		AuthID: identityUser.ID,
+		Email:  identityUser.Email,
+		Role:   identityUser.GetRole(),
+	}); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	if err := signUpTransaction.Commit(); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return identityUser, nil
+}
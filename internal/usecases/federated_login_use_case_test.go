@@ -0,0 +1,314 @@
+package usecases
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/domain/events"
+	"github.com/BeatEcoprove/identityService/internal/usecases/helpers"
+	"github.com/BeatEcoprove/identityService/internal/usecases/utils"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const fakeIdPClientID = "beat-app"
+
+// fakeIdP is a local OpenID Connect provider, it publishes the discovery
+// document and the JWKS of its single signing key.
+type fakeIdP struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	kid       string
+	jwksCalls atomic.Int32
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	idp := &fakeIdP{key: key, kid: uuid.NewString()}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   idp.server.URL,
+			"jwks_uri": idp.server.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.jwksCalls.Add(1)
+
+		json.NewEncoder(w).Encode(services.JWKS{Keys: []services.JWK{{
+			Kty: "RSA",
+			Kid: idp.kid,
+			Use: "sig",
+			Alg: services.RS256,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (idp *fakeIdP) sign(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = idp.kid
+
+	idToken, err := token.SignedString(key)
+	assert.Nil(t, err)
+
+	return idToken
+}
+
+// issue returns an id token for subject with a verified email, claims
+// overrides the defaults.
+func (idp *fakeIdP) issue(t *testing.T, subject, email string, claims jwt.MapClaims) string {
+	defaults := jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            fakeIdPClientID,
+		"sub":            subject,
+		"email":          email,
+		"email_verified": true,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+
+	for claim, value := range claims {
+		defaults[claim] = value
+	}
+
+	return idp.sign(t, idp.key, defaults)
+}
+
+func setupFederatedTokens() {
+	Redis.On("GetValue", mock.Anything).Return("", errors.ErrUnsupported).Once()
+}
+
+func Test_Federated_Login_UseCase(t *testing.T) {
+	idp := newFakeIdP(t)
+
+	t.Setenv("OIDC_PROVIDER_ISSUER", idp.server.URL)
+	t.Setenv("OIDC_PROVIDER_CLIENT_ID", fakeIdPClientID)
+	t.Setenv("MFA_ENCRYPTION_KEY", "test-mfa-key")
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	t.Setenv("JWT_REFRESH_EXPIRED", "1")
+	InitTest()
+
+	var sut *FederatedLoginUseCase = NewFederatedLoginUseCase(
+		AuthRepository,
+		ProfileRepository,
+		ExternalIdentityRepository,
+		TokenService,
+		FederationService,
+		MFAChallengeService,
+		RabbitMq,
+		helpers.NewProfileCreateService(ProfileRepository, RabbitMq, Redis),
+	)
+
+	RabbitMq.On("Publish", mock.Anything).Return(nil)
+	Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	Redis.On("AddToSet", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	ProfileRepository.On("GetAttachProfiles", mock.Anything).Return([]domain.Profile{*domain.NewProfile(uuid.NewString(), domain.Main)}, nil)
+
+	t.Run("Should refuse a provider that isn't configured", func(t *testing.T) {
+		// Act
+		_, err := sut.Handle(FederatedLoginInput{
+			Provider: services.GoogleProvider,
+			IDToken:  idp.issue(t, uuid.NewString(), "user@beat.pt", nil),
+		})
+
+		// Assert
+		evaluateError(t, fails.UNKNOWN_IDENTITY_PROVIDER, err)
+	})
+
+	t.Run("Should refuse tokens issued to another client, expired or signed by another key", func(t *testing.T) {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.Nil(t, err)
+
+		idTokens := []string{
+			idp.issue(t, uuid.NewString(), "user@beat.pt", jwt.MapClaims{"aud": "another-app"}),
+			idp.issue(t, uuid.NewString(), "user@beat.pt", jwt.MapClaims{"iss": "https://another-issuer"}),
+			idp.issue(t, uuid.NewString(), "user@beat.pt", jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}),
+			idp.sign(t, otherKey, jwt.MapClaims{
+				"iss": idp.server.URL,
+				"aud": fakeIdPClientID,
+				"sub": uuid.NewString(),
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}),
+		}
+
+		for _, idToken := range idTokens {
+			// Act
+			_, err := sut.Handle(FederatedLoginInput{
+				Provider: services.DefaultOIDCProvider,
+				IDToken:  idToken,
+			})
+
+			// Assert
+			evaluateError(t, fails.INVALID_ID_TOKEN, err)
+		}
+	})
+
+	t.Run("Should not link or create an account for an unverified email", func(t *testing.T) {
+		subject := uuid.NewString()
+		ExternalIdentityRepository.On("GetByProviderSubject", services.DefaultOIDCProvider, subject).Return((*domain.ExternalIdentity)(nil), errors.ErrUnsupported)
+
+		// Act
+		_, err := sut.Handle(FederatedLoginInput{
+			Provider: services.DefaultOIDCProvider,
+			IDToken:  idp.issue(t, subject, "user@beat.pt", jwt.MapClaims{"email_verified": "false"}),
+		})
+
+		// Assert
+		evaluateError(t, fails.FEDERATED_EMAIL_NOT_VERIFIED, err)
+	})
+
+	t.Run("Should sign up an unknown user with a verified email", func(t *testing.T) {
+		subject := uuid.NewString()
+		email := "new-" + subject + "@beat.pt"
+		transRepo := new(utils.MockTransaction)
+
+		ExternalIdentityRepository.On("GetByProviderSubject", services.DefaultOIDCProvider, subject).Return((*domain.ExternalIdentity)(nil), errors.ErrUnsupported)
+		AuthRepository.On("GetUserByEmail", email).Return(&domain.IdentityUser{}, errors.ErrUnsupported)
+		AuthRepository.On("BeginTransaction").Return(transRepo, nil).Once()
+		transRepo.MockRepositoryBase.On("Create").Return(nil)
+		transRepo.On("Commit").Return(nil)
+		ProfileRepository.On("GetMainProfileByAuthId", mock.Anything).Return((*domain.Profile)(nil), errors.ErrUnsupported).Once()
+		setupFederatedTokens()
+
+		// Act
+		response, err := sut.Handle(FederatedLoginInput{
+			Provider: services.DefaultOIDCProvider,
+			IDToken:  idp.issue(t, subject, email, nil),
+			Nonce:    "login-nonce",
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+
+		claims := getIDTokenClaims(t, response.IDToken)
+		assert.Equal(t, email, claims.Email)
+		assert.True(t, claims.EmailVerified)
+
+		// the account, its linked identity and the main profile
+		transRepo.MockRepositoryBase.AssertNumberOfCalls(t, "Create", 3)
+		transRepo.AssertCalled(t, "Commit")
+		RabbitMq.AssertCalled(t, "Publish", mock.Anything)
+	})
+
+	t.Run("Should link an existing account with the same verified email", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		identityUser.VerifyEmail()
+		subject := uuid.NewString()
+
+		ExternalIdentityRepository.On("GetByProviderSubject", services.DefaultOIDCProvider, subject).Return((*domain.ExternalIdentity)(nil), errors.ErrUnsupported)
+		AuthRepository.On("GetUserByEmail", identityUser.Email).Return(identityUser, nil)
+		ExternalIdentityRepository.On("Create").Return(nil).Once()
+		setupFederatedTokens()
+
+		// Act
+		response, err := sut.Handle(FederatedLoginInput{
+			Provider: services.DefaultOIDCProvider,
+			IDToken:  idp.issue(t, subject, identityUser.Email, nil),
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+		ExternalIdentityRepository.AssertCalled(t, "Create")
+		RabbitMq.AssertCalled(t, "Publish", mock.MatchedBy(func(event *events.IdentityLinkedEvent) bool {
+			return event.AuthID == identityUser.ID && event.Provider == services.DefaultOIDCProvider && event.Subject == subject
+		}))
+	})
+
+	t.Run("Should not link an account whose email was never confirmed", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		subject := uuid.NewString()
+
+		ExternalIdentityRepository.On("GetByProviderSubject", services.DefaultOIDCProvider, subject).Return((*domain.ExternalIdentity)(nil), errors.ErrUnsupported)
+		AuthRepository.On("GetUserByEmail", identityUser.Email).Return(identityUser, nil)
+
+		// Act
+		_, err := sut.Handle(FederatedLoginInput{
+			Provider: services.DefaultOIDCProvider,
+			IDToken:  idp.issue(t, subject, identityUser.Email, nil),
+		})
+
+		// Assert
+		evaluateError(t, fails.ACCOUNT_EMAIL_NOT_VERIFIED, err)
+	})
+
+	t.Run("Should sign in a linked identity by its subject and check the provider nonce", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		subject := uuid.NewString()
+		linked := domain.NewExternalIdentity(identityUser.ID, services.DefaultOIDCProvider, subject, identityUser.Email)
+
+		ExternalIdentityRepository.On("GetByProviderSubject", services.DefaultOIDCProvider, subject).Return(linked, nil)
+		idToken := idp.issue(t, subject, "changed@beat.pt", jwt.MapClaims{"email_verified": false, "nonce": "n-0S6_WzA2Mj"})
+
+		// Act
+		_, err := sut.Handle(FederatedLoginInput{
+			Provider:      services.DefaultOIDCProvider,
+			IDToken:       idToken,
+			ProviderNonce: "another-nonce",
+		})
+
+		evaluateError(t, fails.INVALID_ID_TOKEN, err)
+
+		setupFederatedTokens()
+		response, err := sut.Handle(FederatedLoginInput{
+			Provider:      services.DefaultOIDCProvider,
+			IDToken:       idToken,
+			ProviderNonce: "n-0S6_WzA2Mj",
+			Nonce:         "login-nonce",
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, identityUser.Email, getIDTokenClaims(t, response.IDToken).Email)
+	})
+
+	t.Run("Should still ask for the second factor", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		subject := uuid.NewString()
+		linked := domain.NewExternalIdentity(identityUser.ID, services.DefaultOIDCProvider, subject, identityUser.Email)
+
+		ExternalIdentityRepository.On("GetByProviderSubject", services.DefaultOIDCProvider, subject).Return(linked, nil)
+
+		// Act
+		response, err := sut.Handle(FederatedLoginInput{
+			Provider: services.DefaultOIDCProvider,
+			IDToken:  idp.issue(t, subject, identityUser.Email, nil),
+		})
+
+		// Assert
+		assert.Nil(t, response)
+
+		_, ok := err.(*MFARequiredError)
+		assert.True(t, ok)
+	})
+
+	t.Run("Should keep the provider keys between logins", func(t *testing.T) {
+		assert.Equal(t, int32(1), idp.jwksCalls.Load())
+	})
+}
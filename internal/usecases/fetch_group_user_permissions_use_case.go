@@ -8,7 +8,6 @@ import (
 
 type (
 	FetchGroupUserPermissionsInput struct {
-		// AuthId   string FIXME: right now it doens't need authentication, but later must authenticate the microservice
 		GroupID  string
 		MmeberID string
 	}
@@ -52,7 +52,7 @@ func Test_ForgotPassword_UseCase(t *testing.T) {
 		identityIdenty, err := getIdentityUser(
 			input.Email,
 			DefaultPassword,
-			int(domain.Main),
+			0,
 		)
 
 		assert.Equal(t, err, nil)
@@ -74,7 +74,7 @@ func Test_ForgotPassword_UseCase(t *testing.T) {
 		identityIdenty, err := getIdentityUser(
 			input.Email,
 			DefaultPassword,
-			int(domain.Main),
+			0,
 		)
 
 		assert.Equal(t, err, nil)
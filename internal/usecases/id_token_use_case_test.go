@@ -0,0 +1,122 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getIDTokenClaims(t *testing.T, token string) *services.IDTokenClaims {
+	var claims services.IDTokenClaims
+
+	assert.Nil(t, services.GetClaims(token, &claims, services.ID))
+	return &claims
+}
+
+func Test_ID_Token_UseCase(t *testing.T) {
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	t.Setenv("JWT_REFRESH_EXPIRED", "1")
+	t.Setenv("JWT_AUDIENCE", "Beat")
+	InitTest()
+	SetupLoginAttempts()
+	Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	t.Run("Should issue an id token carrying the login nonce", func(t *testing.T) {
+		var sut *LoginUseCase = NewLoginUseCase(
+			AuthRepository,
+			ProfileRepository,
+			TokenService,
+			LoginAttemptService,
+			MFAChallengeService,
+			RabbitMq,
+		)
+
+		var data LoginInputFaker = LoginInputFaker{}
+		generateFakeData(&data)
+
+		identityUser, err := getIdentityUser(data.Email, DefaultPassword, 0)
+		assert.Nil(t, err)
+		identityUser.ID = uuid.New().String()
+
+		AuthRepository.On("ExistsUserWithEmail", data.Email).Return(true)
+		AuthRepository.On("GetUserByEmail", data.Email).Return(identityUser, nil)
+		ProfileRepository.On("GetAttachProfiles", identityUser.ID).Return([]domain.Profile{*domain.NewProfile(identityUser.ID, domain.Main)}, nil)
+		Redis.On("GetValue", mock.Anything).Return("", errors.ErrUnsupported).Once()
+		Redis.On("AddToSet", services.NewSessionsKey(identityUser.ID), mock.Anything, mock.Anything).Return(nil)
+
+		// Act
+		response, err := sut.Handle(LoginInput{
+			Email:    data.Email,
+			Password: DefaultPassword,
+			Nonce:    "n-0S6_WzA2Mj",
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.IDToken)
+
+		claims := getIDTokenClaims(t, response.IDToken)
+		assert.Equal(t, identityUser.ID, claims.Subject)
+		assert.Equal(t, identityUser.Email, claims.Email)
+		assert.Equal(t, "n-0S6_WzA2Mj", claims.Nonce)
+		assert.Equal(t, jwt.ClaimStrings{"Beat"}, claims.Audience)
+		assert.NotEmpty(t, claims.SessionID)
+		assert.NotNil(t, claims.AuthTime)
+		assert.False(t, services.ValidateToken(response.IDToken))
+		Redis.AssertCalled(t, "AddToSet", services.NewSessionsKey(identityUser.ID), services.RefreshTokenLifetime(), []string{claims.SessionID})
+	})
+
+	t.Run("Should keep the original auth time and client and drop the nonce on refresh", func(t *testing.T) {
+		var sut *RefreshTokensUseCase = NewRefreshTokensUseCase(
+			AuthRepository,
+			ProfileRepository,
+			TokenService,
+			RabbitMq,
+		)
+
+		var data LoginInputFaker = LoginInputFaker{}
+		generateFakeData(&data)
+
+		identityUser, err := getIdentityUser(data.Email, DefaultPassword, 0)
+		assert.Nil(t, err)
+
+		input := RefreshTokensInput{
+			AuthId:    uuid.New().String(),
+			SessionId: uuid.New().String(),
+			Token:     uuid.New().String(),
+			ProfileId: uuid.New().String(),
+		}
+		identityUser.ID = input.AuthId
+
+		authTime := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+		rawSession, err := json.Marshal(services.Session{ID: input.SessionId, CreatedAt: authTime, LastUsedAt: authTime, ClientID: testClientID})
+		assert.Nil(t, err)
+
+		AuthRepository.On("Get", input.AuthId).Return(identityUser, nil)
+		ProfileRepository.On("IsProfileFromUserId", input.AuthId, input.ProfileId).Return(true)
+		ProfileRepository.On("Get", input.ProfileId).Return(domain.NewProfile(input.AuthId, domain.Main), nil)
+		Redis.On("GetAndDelValue", services.NewRefreshTokenKey(input.AuthId, input.SessionId)).Return(input.Token, nil)
+		Redis.On("GetValue", services.NewSessionKey(input.AuthId, input.SessionId)).Return(string(rawSession), nil)
+		Redis.On("AddToSet", services.NewSessionsKey(input.AuthId), mock.Anything, mock.Anything).Return(nil)
+
+		// Act
+		response, err := sut.Handle(input)
+
+		// Assert
+		assert.Nil(t, err)
+
+		claims := getIDTokenClaims(t, response.IDToken)
+		assert.Equal(t, input.SessionId, claims.SessionID)
+		assert.Equal(t, authTime.Unix(), claims.AuthTime.Unix())
+		assert.Empty(t, claims.Nonce)
+		assert.Equal(t, jwt.ClaimStrings{testClientID}, claims.Audience)
+	})
+}
@@ -0,0 +1,294 @@
+package usecases
+
+import (
+	"crypto/subtle"
+	"log"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/domain/events"
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/mappers"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	ListIdentitiesInput struct {
+		AuthId string
+	}
+
+	// input
+	LinkIdentityInput struct {
+		AuthId        string
+		SessionId     string
+		Provider      string
+		IDToken       string
+		ProviderNonce string
+		Password      string
+		Device        services.DeviceInfo
+	}
+
+	// input
+	UnlinkIdentityInput struct {
+		AuthId     string
+		IdentityId string
+	}
+
+	ListIdentitiesUseCase struct {
+		identityRepo repositories.IExternalIdentityRepository
+	}
+
+	LinkIdentityUseCase struct {
+		authRepo      repositories.IAuthRepository
+		identityRepo  repositories.IExternalIdentityRepository
+		tokenService  services.ITokenService
+		federation    services.IFederationService
+		authenticator *passwordAuthenticator
+		broker        adapters.Broker
+	}
+
+	UnlinkIdentityUseCase struct {
+		authRepo       repositories.IAuthRepository
+		identityRepo   repositories.IExternalIdentityRepository
+		credentialRepo repositories.IWebAuthnCredentialRepository
+		broker         adapters.Broker
+	}
+)
+
+// ReauthenticationWindow is how long after signing in a session may link an
+// identity, or delete the account, without asking for the password again.
+const ReauthenticationWindow = 5 * time.Minute
+
+func NewListIdentitiesUseCase(
+	identityRepo repositories.IExternalIdentityRepository,
+) *ListIdentitiesUseCase {
+	return &ListIdentitiesUseCase{
+		identityRepo: identityRepo,
+	}
+}
+
+func NewLinkIdentityUseCase(
+	authRepo repositories.IAuthRepository,
+	identityRepo repositories.IExternalIdentityRepository,
+	tokenService services.ITokenService,
+	federation services.IFederationService,
+	attempts services.ILoginAttemptService,
+	broker adapters.Broker,
+) *LinkIdentityUseCase {
+	return &LinkIdentityUseCase{
+		authRepo:      authRepo,
+		identityRepo:  identityRepo,
+		tokenService:  tokenService,
+		federation:    federation,
+		authenticator: newPasswordAuthenticator(authRepo, attempts, broker),
+		broker:        broker,
+	}
+}
+
+func NewUnlinkIdentityUseCase(
+	authRepo repositories.IAuthRepository,
+	identityRepo repositories.IExternalIdentityRepository,
+	credentialRepo repositories.IWebAuthnCredentialRepository,
+	broker adapters.Broker,
+) *UnlinkIdentityUseCase {
+	return &UnlinkIdentityUseCase{
+		authRepo:       authRepo,
+		identityRepo:   identityRepo,
+		credentialRepo: credentialRepo,
+		broker:         broker,
+	}
+}
+
+// verifyFederatedIdentity checks the id token, and the nonce the client sent
+// the provider when there is one.
+func verifyFederatedIdentity(federation services.IFederationService, provider, idToken, providerNonce string) (*services.FederatedIdentity, error) {
+	identity, err := federation.VerifyIDToken(provider, idToken)
+
+	if err != nil {
+		switch err {
+		case services.ErrUnknownIdentityProvider:
+			return nil, fails.UNKNOWN_IDENTITY_PROVIDER
+		case services.ErrFetchingProviderKeys:
+			log.Printf("failed to fetch the keys of %s", provider)
+			return nil, fails.InternalServerError()
+		}
+
+		return nil, fails.INVALID_ID_TOKEN
+	}
+
+	if providerNonce != "" && subtle.ConstantTimeCompare([]byte(providerNonce), []byte(identity.Nonce)) != 1 {
+		return nil, fails.INVALID_ID_TOKEN
+	}
+
+	return identity, nil
+}
+
+// linkIdentity stores the link and tells the owner through an
+// identity_linked event.
+func linkIdentity(
+	identityRepo repositories.IExternalIdentityRepository,
+	broker adapters.Broker,
+	identityUser *domain.IdentityUser,
+	identity *services.FederatedIdentity,
+) (*domain.ExternalIdentity, error) {
+	externalIdentity := domain.NewExternalIdentity(
+		identityUser.ID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+	)
+
+	if err := identityRepo.Create(externalIdentity); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	if err := broker.Publish(&events.IdentityLinkedEvent{
+		AuthID:   identityUser.ID,
+		Email:    identityUser.Email,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		LinkedAt: time.Now(),
+	}, adapters.AuthEventTopic); err != nil {
+		log.Printf("failed to send kafka event %s", err.Error())
+	}
+
+	return externalIdentity, nil
+}
+
+// reauthenticate accepts the account password, or a session that signed in
+// less than ReauthenticationWindow ago, accounts without a password only
+// have the latter. Wrong passwords count towards the login lockout.
+func (pa *passwordAuthenticator) reauthenticate(
+	tokenService services.ITokenService,
+	identityUser *domain.IdentityUser,
+	sessionID, password string,
+	device services.DeviceInfo,
+) error {
+	if password != "" {
+		return pa.verify(identityUser, password, device)
+	}
+
+	return reauthenticateSession(tokenService, identityUser, sessionID)
+}
+
+// reauthenticateSession accepts a session that signed in less than
+// ReauthenticationWindow ago.
+func reauthenticateSession(tokenService services.ITokenService, identityUser *domain.IdentityUser, sessionID string) error {
+	sessions, err := tokenService.GetSessions(identityUser.ID)
+
+	if err != nil {
+		return fails.InternalServerError()
+	}
+
+	for _, session := range sessions {
+		if session.ID == sessionID && time.Since(session.CreatedAt) <= ReauthenticationWindow {
+			return nil
+		}
+	}
+
+	return fails.REAUTHENTICATION_REQUIRED
+}
+
+func (liu *ListIdentitiesUseCase) Handle(request ListIdentitiesInput) (*contracts.ExternalIdentitiesResponse, error) {
+	identities, err := liu.identityRepo.GetByAuthId(request.AuthId)
+
+	if err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return mappers.ToExternalIdentitiesResponse(identities), nil
+}
+
+// Handle links the provider of the id token to the signed in account, the
+// provider subject can't be linked to another account at the same time.
+func (liu *LinkIdentityUseCase) Handle(request LinkIdentityInput) (*contracts.ExternalIdentityResponse, error) {
+	identityUser, err := liu.authRepo.Get(request.AuthId)
+
+	if err != nil {
+		return nil, fails.USER_NOT_FOUND
+	}
+
+	if err := liu.authenticator.reauthenticate(liu.tokenService, identityUser, request.SessionId, request.Password, request.Device); err != nil {
+		return nil, err
+	}
+
+	identity, err := verifyFederatedIdentity(liu.federation, request.Provider, request.IDToken, request.ProviderNonce)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := liu.identityRepo.GetByProviderSubject(identity.Provider, identity.Subject); err == nil {
+		return nil, fails.IDENTITY_ALREADY_LINKED
+	}
+
+	externalIdentity, err := linkIdentity(liu.identityRepo, liu.broker, identityUser, identity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return mappers.ToExternalIdentityResponse(externalIdentity), nil
+}
+
+// Handle unlinks an identity as long as the account keeps another way to
+// sign in, a password, a passkey or another identity.
+func (uiu *UnlinkIdentityUseCase) Handle(request UnlinkIdentityInput) (*contracts.GenericResponse, error) {
+	identityUser, err := uiu.authRepo.Get(request.AuthId)
+
+	if err != nil {
+		return nil, fails.USER_NOT_FOUND
+	}
+
+	identities, err := uiu.identityRepo.GetByAuthId(identityUser.ID)
+
+	if err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	var unlinked *domain.ExternalIdentity
+
+	for i := range identities {
+		if identities[i].ID == request.IdentityId {
+			unlinked = &identities[i]
+		}
+	}
+
+	if unlinked == nil {
+		return nil, fails.IDENTITY_NOT_FOUND
+	}
+
+	if len(identities) == 1 && !identityUser.HasPassword() {
+		credentials, err := uiu.credentialRepo.GetByAuthId(identityUser.ID)
+
+		if err != nil {
+			return nil, fails.InternalServerError()
+		}
+
+		if len(credentials) == 0 {
+			return nil, fails.LAST_LOGIN_METHOD
+		}
+	}
+
+	if err := uiu.identityRepo.Unlink(unlinked); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	if err := uiu.broker.Publish(&events.IdentityUnlinkedEvent{
+		AuthID:     identityUser.ID,
+		Email:      identityUser.Email,
+		Provider:   unlinked.Provider,
+		Subject:    unlinked.Subject,
+		UnlinkedAt: time.Now(),
+	}, adapters.AuthEventTopic); err != nil {
+		log.Printf("failed to send kafka event %s", err.Error())
+	}
+
+	return &contracts.GenericResponse{
+		Message: "Identity unlinked.",
+	}, nil
+}
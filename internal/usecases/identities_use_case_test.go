@@ -0,0 +1,283 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/domain/events"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// getFederatedUser returns an account signed up through a provider, it has
+// no password.
+func getFederatedUser() *domain.IdentityUser {
+	identityUser := &domain.IdentityUser{
+		Email:         "federated-" + uuid.NewString() + "@beat.pt",
+		Role:          domain.AuthClient,
+		EmailVerified: true,
+	}
+
+	identityUser.ID = uuid.NewString()
+	AuthRepository.On("Get", identityUser.ID).Return(identityUser, nil)
+
+	return identityUser
+}
+
+// storeSession makes sessionID a session of the user that signed in at
+// createdAt.
+func storeSession(t *testing.T, authID, sessionID string, createdAt time.Time) {
+	rawSession, err := json.Marshal(services.Session{
+		ID:         sessionID,
+		CreatedAt:  createdAt,
+		LastUsedAt: time.Now(),
+	})
+	assert.Nil(t, err)
+
+	Redis.On("GetSetMembers", services.NewSessionsKey(authID)).Return([]string{sessionID}, nil)
+	Redis.On("GetValue", services.NewSessionKey(authID, sessionID)).Return(string(rawSession), nil)
+}
+
+func Test_Identities_UseCase(t *testing.T) {
+	idp := newFakeIdP(t)
+
+	t.Setenv("OIDC_PROVIDER_ISSUER", idp.server.URL)
+	t.Setenv("OIDC_PROVIDER_CLIENT_ID", fakeIdPClientID)
+	InitTest()
+
+	list := NewListIdentitiesUseCase(ExternalIdentityRepository)
+	link := NewLinkIdentityUseCase(AuthRepository, ExternalIdentityRepository, TokenService, FederationService, LoginAttemptService, RabbitMq)
+	unlink := NewUnlinkIdentityUseCase(AuthRepository, ExternalIdentityRepository, WebAuthnCredentialRepository, RabbitMq)
+
+	RabbitMq.On("Publish", mock.Anything).Return(nil)
+	SetupLoginAttempts()
+	ExternalIdentityRepository.On("Create").Return(nil)
+	ExternalIdentityRepository.On("Unlink", mock.Anything).Return(nil)
+
+	t.Run("Should list the linked identities", func(t *testing.T) {
+		identityUser := getFederatedUser()
+		ExternalIdentityRepository.On("GetByAuthId", identityUser.ID).Return([]domain.ExternalIdentity{
+			*domain.NewExternalIdentity(identityUser.ID, services.GoogleProvider, uuid.NewString(), identityUser.Email),
+			*domain.NewExternalIdentity(identityUser.ID, services.DefaultOIDCProvider, uuid.NewString(), identityUser.Email),
+		}, nil).Once()
+
+		// Act
+		response, err := list.Handle(ListIdentitiesInput{AuthId: identityUser.ID})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Len(t, response.Identities, 2)
+		assert.Equal(t, services.GoogleProvider, response.Identities[0].Provider)
+	})
+
+	t.Run("Should ask an old session to re-authenticate before linking", func(t *testing.T) {
+		identityUser := getFederatedUser()
+		sessionID := uuid.NewString()
+		storeSession(t, identityUser.ID, sessionID, time.Now().Add(-time.Hour))
+
+		// Act
+		_, err := link.Handle(LinkIdentityInput{
+			AuthId:    identityUser.ID,
+			SessionId: sessionID,
+			Provider:  services.DefaultOIDCProvider,
+			IDToken:   idp.issue(t, uuid.NewString(), identityUser.Email, nil),
+		})
+
+		// Assert
+		evaluateError(t, fails.REAUTHENTICATION_REQUIRED, err)
+		ExternalIdentityRepository.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("Should refuse a wrong password", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+
+		// Act
+		_, err := link.Handle(LinkIdentityInput{
+			AuthId:   identityUser.ID,
+			Provider: services.DefaultOIDCProvider,
+			IDToken:  idp.issue(t, uuid.NewString(), identityUser.Email, nil),
+			Password: "WrongPassword1",
+		})
+
+		// Assert
+		evaluateError(t, fails.USER_AUTH_FAILED, err)
+	})
+
+	t.Run("Should count a wrong password towards the login lockout", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+
+		// Act
+		_, err := link.Handle(LinkIdentityInput{
+			AuthId:   identityUser.ID,
+			Provider: services.DefaultOIDCProvider,
+			IDToken:  idp.issue(t, uuid.NewString(), identityUser.Email, nil),
+			Password: "WrongPassword1",
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		evaluateError(t, fails.USER_AUTH_FAILED, err)
+		Redis.AssertCalled(t, "Increment", services.NewLoginAttemptsKey(services.LoginAccountScope, strings.ToLower(identityUser.Email)), mock.Anything)
+		Redis.AssertCalled(t, "Increment", services.NewLoginAttemptsKey(services.LoginIPScope, testIP), mock.Anything)
+	})
+
+	t.Run("Should not count a password sent for an account without one", func(t *testing.T) {
+		identityUser := getFederatedUser()
+
+		// Act
+		_, err := link.Handle(LinkIdentityInput{
+			AuthId:   identityUser.ID,
+			Provider: services.DefaultOIDCProvider,
+			IDToken:  idp.issue(t, uuid.NewString(), identityUser.Email, nil),
+			Password: "Password1",
+		})
+
+		// Assert
+		evaluateError(t, fails.PASSWORD_NOT_SET, err)
+		Redis.AssertNotCalled(t, "Increment", services.NewLoginAttemptsKey(services.LoginAccountScope, strings.ToLower(identityUser.Email)), mock.Anything)
+	})
+
+	t.Run("Should not check the password of a locked out account", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		lockOut(identityUser.Email)
+
+		// Act
+		_, err := link.Handle(LinkIdentityInput{
+			AuthId:   identityUser.ID,
+			Provider: services.DefaultOIDCProvider,
+			IDToken:  idp.issue(t, uuid.NewString(), identityUser.Email, nil),
+			Password: DefaultPassword,
+		})
+
+		// Assert
+		evaluateError(t, fails.USER_LOCKED, err)
+		ExternalIdentityRepository.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("Should link an identity after the password was checked", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		subject := uuid.NewString()
+		ExternalIdentityRepository.On("GetByProviderSubject", services.DefaultOIDCProvider, subject).Return((*domain.ExternalIdentity)(nil), errors.ErrUnsupported)
+
+		// Act
+		response, err := link.Handle(LinkIdentityInput{
+			AuthId:   identityUser.ID,
+			Provider: services.DefaultOIDCProvider,
+			IDToken:  idp.issue(t, subject, "other-email@beat.pt", nil),
+			Password: DefaultPassword,
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, services.DefaultOIDCProvider, response.Provider)
+		assert.Equal(t, "other-email@beat.pt", response.Email)
+		RabbitMq.AssertCalled(t, "Publish", mock.MatchedBy(func(event *events.IdentityLinkedEvent) bool {
+			return event.AuthID == identityUser.ID && event.Subject == subject
+		}))
+	})
+
+	t.Run("Should link an identity from a session that just signed in", func(t *testing.T) {
+		identityUser := getFederatedUser()
+		sessionID := uuid.NewString()
+		subject := uuid.NewString()
+		storeSession(t, identityUser.ID, sessionID, time.Now().Add(-time.Minute))
+		ExternalIdentityRepository.On("GetByProviderSubject", services.DefaultOIDCProvider, subject).Return((*domain.ExternalIdentity)(nil), errors.ErrUnsupported)
+
+		// Act
+		_, err := link.Handle(LinkIdentityInput{
+			AuthId:    identityUser.ID,
+			SessionId: sessionID,
+			Provider:  services.DefaultOIDCProvider,
+			IDToken:   idp.issue(t, subject, identityUser.Email, nil),
+		})
+
+		// Assert
+		assert.Nil(t, err)
+	})
+
+	t.Run("Should not link an identity that is linked already", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		subject := uuid.NewString()
+		ExternalIdentityRepository.On("GetByProviderSubject", services.DefaultOIDCProvider, subject).Return(
+			domain.NewExternalIdentity(uuid.NewString(), services.DefaultOIDCProvider, subject, ""), nil,
+		)
+
+		// Act
+		_, err := link.Handle(LinkIdentityInput{
+			AuthId:   identityUser.ID,
+			Provider: services.DefaultOIDCProvider,
+			IDToken:  idp.issue(t, subject, identityUser.Email, nil),
+			Password: DefaultPassword,
+		})
+
+		// Assert
+		evaluateError(t, fails.IDENTITY_ALREADY_LINKED, err)
+	})
+
+	t.Run("Should only unlink identities of the account", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		ExternalIdentityRepository.On("GetByAuthId", identityUser.ID).Return([]domain.ExternalIdentity{}, nil).Once()
+
+		// Act
+		_, err := unlink.Handle(UnlinkIdentityInput{AuthId: identityUser.ID, IdentityId: uuid.NewString()})
+
+		// Assert
+		evaluateError(t, fails.IDENTITY_NOT_FOUND, err)
+	})
+
+	t.Run("Should not unlink the last way to sign in", func(t *testing.T) {
+		identityUser := getFederatedUser()
+		identity := domain.NewExternalIdentity(identityUser.ID, services.GoogleProvider, uuid.NewString(), identityUser.Email)
+
+		ExternalIdentityRepository.On("GetByAuthId", identityUser.ID).Return([]domain.ExternalIdentity{*identity}, nil).Once()
+		WebAuthnCredentialRepository.On("GetByAuthId", identityUser.ID).Return([]domain.WebAuthnCredential{}, nil).Once()
+
+		// Act
+		_, err := unlink.Handle(UnlinkIdentityInput{AuthId: identityUser.ID, IdentityId: identity.ID})
+
+		// Assert
+		evaluateError(t, fails.LAST_LOGIN_METHOD, err)
+		ExternalIdentityRepository.AssertNotCalled(t, "Unlink", mock.Anything)
+	})
+
+	t.Run("Should unlink an identity while a passkey is left", func(t *testing.T) {
+		identityUser := getFederatedUser()
+		identity := domain.NewExternalIdentity(identityUser.ID, services.GoogleProvider, uuid.NewString(), identityUser.Email)
+
+		ExternalIdentityRepository.On("GetByAuthId", identityUser.ID).Return([]domain.ExternalIdentity{*identity}, nil).Once()
+		WebAuthnCredentialRepository.On("GetByAuthId", identityUser.ID).Return([]domain.WebAuthnCredential{{AuthID: identityUser.ID}}, nil).Once()
+
+		// Act
+		_, err := unlink.Handle(UnlinkIdentityInput{AuthId: identityUser.ID, IdentityId: identity.ID})
+
+		// Assert
+		assert.Nil(t, err)
+		ExternalIdentityRepository.AssertCalled(t, "Unlink", mock.MatchedBy(func(unlinked *domain.ExternalIdentity) bool {
+			return unlinked.ID == identity.ID
+		}))
+		RabbitMq.AssertCalled(t, "Publish", mock.MatchedBy(func(event *events.IdentityUnlinkedEvent) bool {
+			return event.AuthID == identityUser.ID && event.Provider == services.GoogleProvider && event.Subject == identity.Subject
+		}))
+	})
+
+	t.Run("Should unlink the only identity of an account with a password", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		identity := domain.NewExternalIdentity(identityUser.ID, services.AppleProvider, uuid.NewString(), identityUser.Email)
+
+		ExternalIdentityRepository.On("GetByAuthId", identityUser.ID).Return([]domain.ExternalIdentity{*identity}, nil).Once()
+
+		// Act
+		_, err := unlink.Handle(UnlinkIdentityInput{AuthId: identityUser.ID, IdentityId: identity.ID})
+
+		// Assert
+		assert.Nil(t, err)
+		WebAuthnCredentialRepository.AssertNotCalled(t, "GetByAuthId", identityUser.ID)
+	})
+}
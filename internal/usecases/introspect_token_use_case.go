@@ -0,0 +1,76 @@
+package usecases
+
+import (
+	"strings"
+
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	IntrospectTokenInput struct {
+		Token         string
+		TokenTypeHint string
+	}
+
+	IntrospectTokenUseCase struct {
+		authRepo     repositories.IAuthRepository
+		tokenService services.ITokenService
+	}
+)
+
+func NewIntrospectTokenUseCase(
+	authRepo repositories.IAuthRepository,
+	tokenService services.ITokenService,
+) *IntrospectTokenUseCase {
+	return &IntrospectTokenUseCase{
+		authRepo:     authRepo,
+		tokenService: tokenService,
+	}
+}
+
+// Handle follows RFC 7662: a token is only active when it was signed by us,
+// did not expire and is still the current token of a live session.
+func (itu *IntrospectTokenUseCase) Handle(request IntrospectTokenInput) (*contracts.IntrospectionResponse, error) {
+	inactive := &contracts.IntrospectionResponse{Active: false}
+
+	claims, tokenType, ok := parseAnyToken(request.Token, request.TokenTypeHint)
+
+	if !ok {
+		return inactive, nil
+	}
+
+	tokenKey, tokenHint := services.AccessTokenKey, AccessTokenHint
+
+	if tokenType == services.Refresh {
+		tokenKey, tokenHint = services.RefreshTokenKey, RefreshTokenHint
+	}
+
+	if err := itu.tokenService.ValidateToken(claims.Subject, claims.SessionID, request.Token, tokenKey); err != nil {
+		return inactive, nil
+	}
+
+	if ok := itu.authRepo.ExistsUserWithId(claims.Subject); !ok {
+		return inactive, nil
+	}
+
+	return &contracts.IntrospectionResponse{
+		Active:        true,
+		Scope:         strings.Join(claims.Scope, " "),
+		TokenType:     tokenHint,
+		Exp:           claims.ExpiresAt.Unix(),
+		Iat:           claims.IssuedAt.Unix(),
+		Sub:           claims.Subject,
+		Aud:           claims.Audience,
+		Iss:           claims.Issuer,
+		Jti:           claims.ID,
+		SessionID:     claims.SessionID,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		ProfileID:     claims.ProfileID,
+		ProfileIds:    claims.ProfileIds,
+		Role:          claims.Role,
+	}, nil
+}
@@ -0,0 +1,79 @@
+package usecases
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func getIntrospectionToken(t *testing.T, authId, sessionId string) string {
+	accessToken, err := services.CreateJwtToken(services.TokenPayload{
+		UserID:    authId,
+		SessionID: sessionId,
+		Email:     "user@beat.pt",
+		Scope:     []string{"profile:view", "profile:update"},
+		Role:      "client",
+		Duration:  time.Minute,
+		Type:      services.Access,
+	})
+
+	assert.Nil(t, err)
+	return accessToken.Token
+}
+
+func Test_Introspect_Token_UseCase(t *testing.T) {
+	InitTest()
+
+	var sut *IntrospectTokenUseCase = NewIntrospectTokenUseCase(
+		AuthRepository,
+		TokenService,
+	)
+
+	t.Run("Should be inactive when the token is not valid", func(t *testing.T) {
+		// Act
+		response, err := sut.Handle(IntrospectTokenInput{Token: "not-a-token"})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.False(t, response.Active)
+		assert.Empty(t, response.Sub)
+	})
+
+	t.Run("Should be inactive when the session was revoked", func(t *testing.T) {
+		authId, sessionId := uuid.New().String(), uuid.New().String()
+		token := getIntrospectionToken(t, authId, sessionId)
+
+		// Act
+		Redis.On("GetValue", services.NewAccessTokenKey(authId, sessionId)).Return("", errors.ErrUnsupported)
+
+		response, err := sut.Handle(IntrospectTokenInput{Token: token})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.False(t, response.Active)
+	})
+
+	t.Run("Should return the claims of an active token", func(t *testing.T) {
+		authId, sessionId := uuid.New().String(), uuid.New().String()
+		token := getIntrospectionToken(t, authId, sessionId)
+
+		// Act
+		Redis.On("GetValue", services.NewAccessTokenKey(authId, sessionId)).Return(token, nil)
+		AuthRepository.On("ExistsUserWithId", authId).Return(true)
+
+		response, err := sut.Handle(IntrospectTokenInput{Token: token})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.True(t, response.Active)
+		assert.Equal(t, authId, response.Sub)
+		assert.Equal(t, sessionId, response.SessionID)
+		assert.Equal(t, "profile:view profile:update", response.Scope)
+		assert.Equal(t, AccessTokenHint, response.TokenType)
+		assert.Greater(t, response.Exp, time.Now().Unix())
+	})
+}
@@ -0,0 +1,38 @@
+package usecases
+
+import (
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/mappers"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	ListSessionsInput struct {
+		AuthId    string
+		SessionId string
+	}
+
+	ListSessionsUseCase struct {
+		tokenService services.ITokenService
+	}
+)
+
+func NewListSessionsUseCase(
+	tokenService services.ITokenService,
+) *ListSessionsUseCase {
+	return &ListSessionsUseCase{
+		tokenService: tokenService,
+	}
+}
+
+func (lsu *ListSessionsUseCase) Handle(request ListSessionsInput) (*contracts.SessionsResponse, error) {
+	sessions, err := lsu.tokenService.GetSessions(request.AuthId)
+
+	if err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return mappers.ToSessionsResponse(sessions, request.SessionId), nil
+}
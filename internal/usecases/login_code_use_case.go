@@ -0,0 +1,125 @@
+package usecases
+
+import (
+	"log"
+
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	SendLoginCodeInput struct {
+		Email string
+	}
+
+	// input
+	LoginCodeInput struct {
+		Email  string
+		Code   string
+		Nonce  string
+		Device services.DeviceInfo
+	}
+
+	SendLoginCodeUseCase struct {
+		authRepo     repositories.IAuthRepository
+		loginCodes   services.ILoginCodeService
+		emailService services.IEmailService
+	}
+
+	LoginCodeUseCase struct {
+		authRepo     repositories.IAuthRepository
+		profileRepo  repositories.IProfileRepository
+		tokenService services.ITokenService
+		loginCodes   services.ILoginCodeService
+		challenges   services.IMFAChallengeService
+	}
+)
+
+func NewSendLoginCodeUseCase(
+	authRepo repositories.IAuthRepository,
+	loginCodes services.ILoginCodeService,
+	emailService services.IEmailService,
+) *SendLoginCodeUseCase {
+	return &SendLoginCodeUseCase{
+		authRepo:     authRepo,
+		loginCodes:   loginCodes,
+		emailService: emailService,
+	}
+}
+
+func NewLoginCodeUseCase(
+	authRepo repositories.IAuthRepository,
+	profileRepo repositories.IProfileRepository,
+	tokenService services.ITokenService,
+	loginCodes services.ILoginCodeService,
+	challenges services.IMFAChallengeService,
+) *LoginCodeUseCase {
+	return &LoginCodeUseCase{
+		authRepo:     authRepo,
+		profileRepo:  profileRepo,
+		tokenService: tokenService,
+		loginCodes:   loginCodes,
+		challenges:   challenges,
+	}
+}
+
+// Handle emails a one-time code to the account. Unknown emails get the same
+// answer, so it can't be used to find out which emails are registered.
+func (slu *SendLoginCodeUseCase) Handle(request SendLoginCodeInput) (*contracts.GenericResponse, error) {
+	response := &contracts.GenericResponse{
+		Message: "If the email is registered, a login code was sent to it.",
+	}
+
+	identityUser, err := slu.authRepo.GetUserByEmail(request.Email)
+
+	if err != nil {
+		return response, nil
+	}
+
+	code, err := slu.loginCodes.CreateCode(identityUser.ID)
+
+	if err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	if err := slu.emailService.Send(services.EmailInput{
+		To:       identityUser.Email,
+		Template: services.NewLoginCodeTemplate(code),
+	}); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return response, nil
+}
+
+// Handle exchanges the emailed code for tokens. The code replaces the
+// password only, accounts with a second factor are still asked for it.
+func (lcu *LoginCodeUseCase) Handle(input LoginCodeInput) (*contracts.AuthResponse, error) {
+	identityUser, err := lcu.authRepo.GetUserByEmail(input.Email)
+
+	if err != nil {
+		return nil, fails.INVALID_LOGIN_CODE
+	}
+
+	if err := lcu.loginCodes.ConsumeCode(identityUser.ID, input.Code); err != nil {
+		return nil, fails.INVALID_LOGIN_CODE
+	}
+
+	// reading the code proved the user owns the email
+	if !identityUser.EmailVerified {
+		identityUser.VerifyEmail()
+
+		if err := lcu.authRepo.Update(identityUser); err != nil {
+			log.Printf("failed to verify email %s", err.Error())
+		}
+	}
+
+	if err := challengeMFA(lcu.challenges, identityUser, input.Nonce); err != nil {
+		return nil, err
+	}
+
+	return issueTokens(lcu.profileRepo, lcu.tokenService, identityUser, input.Device, "", input.Nonce)
+}
@@ -0,0 +1,152 @@
+package usecases
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// sendLoginCode returns the code of the email together with the hash kept
+// in redis.
+func sendLoginCode(t *testing.T, sut *SendLoginCodeUseCase, identityUser *domain.IdentityUser) (string, string) {
+	AuthRepository.On("GetUserByEmail", identityUser.Email).Return(identityUser, nil)
+
+	_, err := sut.Handle(SendLoginCodeInput{Email: identityUser.Email})
+	assert.Nil(t, err)
+
+	email, err := EmailService.Last()
+	assert.Nil(t, err)
+	assert.Equal(t, identityUser.Email, email.To)
+	assert.Equal(t, "login-code", email.Template.ID)
+
+	var storedCode string
+
+	for _, call := range Redis.Calls {
+		if call.Method == "SetValue" && call.Arguments.Get(0).(adapters.RedisKey).Key == services.NewLoginCodeKey(identityUser.ID).Key {
+			storedCode = call.Arguments.String(1)
+		}
+	}
+
+	return email.Template.Paramters["code"], storedCode
+}
+
+func Test_Login_Code_UseCase(t *testing.T) {
+	t.Setenv("MFA_ENCRYPTION_KEY", "test-mfa-key")
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	t.Setenv("JWT_REFRESH_EXPIRED", "1")
+	InitTest()
+
+	send := NewSendLoginCodeUseCase(AuthRepository, LoginCodeService, EmailService)
+
+	var sut *LoginCodeUseCase = NewLoginCodeUseCase(
+		AuthRepository,
+		ProfileRepository,
+		TokenService,
+		LoginCodeService,
+		MFAChallengeService,
+	)
+
+	RabbitMq.On("Publish", mock.Anything).Return(nil)
+	Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	Redis.On("DelValue", mock.Anything).Return(nil)
+	AuthRepository.On("Update", mock.Anything).Return(nil)
+
+	t.Run("Should answer the same for an unknown email without sending anything", func(t *testing.T) {
+		AuthRepository.On("GetUserByEmail", "nobody@beat.pt").Return(&domain.IdentityUser{}, errors.ErrUnsupported)
+		sent := len(RabbitMq.Calls)
+
+		// Act
+		response, err := send.Handle(SendLoginCodeInput{Email: "nobody@beat.pt"})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.Message)
+		assert.Len(t, RabbitMq.Calls, sent)
+	})
+
+	t.Run("Should email a code and only keep its hash", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+
+		// Act
+		code, storedCode := sendLoginCode(t, send, identityUser)
+
+		// Assert
+		assert.Regexp(t, "^[0-9a-f]{4}-[0-9A-Z]{3}$", code)
+		assert.NotEmpty(t, storedCode)
+		assert.NotContains(t, storedCode, code)
+		Redis.AssertCalled(t, "SetValue", services.NewLoginCodeKey(identityUser.ID), storedCode, services.LoginCodeLifetime)
+	})
+
+	t.Run("Should count a wrong code", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		_, storedCode := sendLoginCode(t, send, identityUser)
+
+		Redis.On("GetValue", services.NewLoginCodeKey(identityUser.ID)).Return(storedCode, nil).Once()
+		Redis.On("Increment", services.NewLoginCodeAttemptsKey(identityUser.ID), services.LoginCodeLifetime).Return(int64(1), nil).Once()
+
+		// Act
+		_, err := sut.Handle(LoginCodeInput{Email: identityUser.Email, Code: "0000-AAA"})
+
+		// Assert
+		evaluateError(t, fails.INVALID_LOGIN_CODE, err)
+		Redis.AssertNotCalled(t, "GetAndDelValue", services.NewLoginCodeKey(identityUser.ID))
+	})
+
+	t.Run("Should drop the code after too many wrong guesses", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		_, storedCode := sendLoginCode(t, send, identityUser)
+
+		Redis.On("GetValue", services.NewLoginCodeKey(identityUser.ID)).Return(storedCode, nil).Once()
+		Redis.On("Increment", services.NewLoginCodeAttemptsKey(identityUser.ID), services.LoginCodeLifetime).Return(int64(services.MaxLoginCodeAttempts), nil).Once()
+
+		// Act
+		_, err := sut.Handle(LoginCodeInput{Email: identityUser.Email, Code: "0000-AAA"})
+
+		// Assert
+		evaluateError(t, fails.INVALID_LOGIN_CODE, err)
+		Redis.AssertCalled(t, "DelValue", []adapters.RedisKey{services.NewLoginCodeKey(identityUser.ID), services.NewLoginCodeAttemptsKey(identityUser.ID)})
+	})
+
+	t.Run("Should issue tokens for the emailed code once and verify the email", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		code, storedCode := sendLoginCode(t, send, identityUser)
+
+		Redis.On("GetValue", services.NewLoginCodeKey(identityUser.ID)).Return(storedCode, nil).Once()
+		Redis.On("GetAndDelValue", services.NewLoginCodeKey(identityUser.ID)).Return(storedCode, nil).Once()
+		Redis.On("GetValue", mock.Anything).Return("", errors.ErrUnsupported).Once()
+		Redis.On("AddToSet", services.NewSessionsKey(identityUser.ID), mock.Anything, mock.Anything).Return(nil)
+		ProfileRepository.On("GetAttachProfiles", identityUser.ID).Return([]domain.Profile{*domain.NewProfile(identityUser.ID, domain.Main)}, nil)
+
+		// Act
+		response, err := sut.Handle(LoginCodeInput{Email: identityUser.Email, Code: code})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+		assert.True(t, identityUser.EmailVerified)
+		Redis.AssertCalled(t, "GetAndDelValue", services.NewLoginCodeKey(identityUser.ID))
+	})
+
+	t.Run("Should still ask for the second factor", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		code, storedCode := sendLoginCode(t, send, identityUser)
+
+		Redis.On("GetValue", services.NewLoginCodeKey(identityUser.ID)).Return(storedCode, nil).Once()
+		Redis.On("GetAndDelValue", services.NewLoginCodeKey(identityUser.ID)).Return(storedCode, nil).Once()
+
+		// Act
+		response, err := sut.Handle(LoginCodeInput{Email: identityUser.Email, Code: code})
+
+		// Assert
+		assert.Nil(t, response)
+
+		_, ok := err.(*MFARequiredError)
+		assert.True(t, ok)
+	})
+}
@@ -0,0 +1,136 @@
+package usecases
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/domain/events"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testIP = "203.0.113.7"
+
+func Test_Login_Lockout_UseCase(t *testing.T) {
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	t.Setenv("JWT_REFRESH_EXPIRED", "1")
+	t.Setenv("LOGIN_MAX_ATTEMPTS", "5")
+	t.Setenv("LOGIN_LOCKOUT", "1")
+	InitTest()
+
+	var sut *LoginUseCase = NewLoginUseCase(
+		AuthRepository,
+		ProfileRepository,
+		TokenService,
+		LoginAttemptService,
+		MFAChallengeService,
+		RabbitMq,
+	)
+
+	getLockoutUser := func() *domain.IdentityUser {
+		var data LoginInputFaker = LoginInputFaker{}
+		generateFakeData(&data)
+
+		// attempts are counted on the lowercased email
+		identityUser, err := getIdentityUser(strings.ToLower(data.Email), DefaultPassword, 0)
+		assert.Nil(t, err)
+		identityUser.ID = uuid.New().String()
+
+		AuthRepository.On("ExistsUserWithEmail", identityUser.Email).Return(true)
+		AuthRepository.On("GetUserByEmail", identityUser.Email).Return(identityUser, nil)
+		Redis.On("TimeToLive", services.NewLoginLockKey(services.LoginIPScope, testIP)).Return(time.Duration(-2), nil)
+		Redis.On("Increment", services.NewLoginAttemptsKey(services.LoginIPScope, testIP), mock.Anything).Return(int64(1), nil)
+
+		return identityUser
+	}
+
+	t.Run("Should refuse a locked account before checking the password", func(t *testing.T) {
+		identityUser := getLockoutUser()
+
+		// Act
+		Redis.On("TimeToLive", services.NewLoginLockKey(services.LoginAccountScope, identityUser.Email)).Return(3*time.Minute, nil)
+
+		_, err := sut.Handle(LoginInput{
+			Email:    identityUser.Email,
+			Password: DefaultPassword,
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		evaluateError(t, fails.USER_LOCKED, err)
+		AuthRepository.AssertNotCalled(t, "ExistsUserWithEmail", identityUser.Email)
+	})
+
+	t.Run("Should only count a failure under the limit", func(t *testing.T) {
+		identityUser := getLockoutUser()
+		attemptsKey := services.NewLoginAttemptsKey(services.LoginAccountScope, identityUser.Email)
+
+		// Act
+		Redis.On("TimeToLive", services.NewLoginLockKey(services.LoginAccountScope, identityUser.Email)).Return(time.Duration(-2), nil)
+		Redis.On("Increment", attemptsKey, mock.Anything).Return(int64(2), nil)
+
+		_, err := sut.Handle(LoginInput{
+			Email:    identityUser.Email,
+			Password: "WrongPassword1",
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		evaluateError(t, fails.USER_AUTH_FAILED, err)
+		Redis.AssertCalled(t, "Increment", attemptsKey, mock.Anything)
+		Redis.AssertNotCalled(t, "SetValue", services.NewLoginLockKey(services.LoginAccountScope, identityUser.Email), mock.Anything, mock.Anything)
+	})
+
+	t.Run("Should lock the account and warn the owner on the last failure", func(t *testing.T) {
+		identityUser := getLockoutUser()
+		lockKey := services.NewLoginLockKey(services.LoginAccountScope, identityUser.Email)
+
+		// Act
+		Redis.On("TimeToLive", lockKey).Return(time.Duration(-2), nil)
+		Redis.On("Increment", services.NewLoginAttemptsKey(services.LoginAccountScope, identityUser.Email), mock.Anything).Return(int64(6), nil)
+		Redis.On("SetValue", lockKey, int64(6), 2*time.Minute).Return(nil)
+		RabbitMq.On("Publish", mock.Anything).Return(nil)
+
+		_, err := sut.Handle(LoginInput{
+			Email:    identityUser.Email,
+			Password: "WrongPassword1",
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		evaluateError(t, fails.USER_LOCKED, err)
+		Redis.AssertCalled(t, "SetValue", lockKey, int64(6), 2*time.Minute)
+		RabbitMq.AssertCalled(t, "Publish", mock.MatchedBy(func(event *events.UserLockedEvent) bool {
+			return event.AuthID == identityUser.ID && event.Attempts == 6 && event.IP == testIP
+		}))
+	})
+
+	t.Run("Should clear the failures of the account on success", func(t *testing.T) {
+		identityUser := getLockoutUser()
+		attemptsKey := services.NewLoginAttemptsKey(services.LoginAccountScope, identityUser.Email)
+
+		// Act
+		Redis.On("TimeToLive", services.NewLoginLockKey(services.LoginAccountScope, identityUser.Email)).Return(time.Duration(-2), nil)
+		Redis.On("DelValue", []adapters.RedisKey{attemptsKey}).Return(nil)
+		Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		Redis.On("GetValue", mock.Anything).Return("", nil)
+		Redis.On("AddToSet", services.NewSessionsKey(identityUser.ID), mock.Anything, mock.Anything).Return(nil)
+		ProfileRepository.On("GetAttachProfiles", identityUser.ID).Return([]domain.Profile{*domain.NewProfile(identityUser.ID, domain.Main)}, nil)
+
+		_, err := sut.Handle(LoginInput{
+			Email:    identityUser.Email,
+			Password: DefaultPassword,
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		Redis.AssertCalled(t, "DelValue", []adapters.RedisKey{attemptsKey})
+	})
+}
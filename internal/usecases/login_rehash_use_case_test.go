@@ -0,0 +1,151 @@
+package usecases
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/config"
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/usecases/utils"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// setLegacyPassword stores password the way accounts did before argon2id,
+// bcrypt over the password with a random salt appended.
+func setLegacyPassword(t *testing.T, identityUser *domain.IdentityUser, password string) {
+	rawSalt := make([]byte, 11)
+	_, err := rand.Read(rawSalt)
+	assert.Nil(t, err)
+	salt := base64.StdEncoding.EncodeToString(rawSalt)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password+salt), bcrypt.MinCost)
+	assert.Nil(t, err)
+
+	identityUser.Salt = salt
+	identityUser.Password = string(hash)
+}
+
+func Test_Login_Rehash_UseCase(t *testing.T) {
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	t.Setenv("JWT_REFRESH_EXPIRED", "1")
+	InitTest()
+
+	var sut *LoginUseCase = NewLoginUseCase(
+		AuthRepository,
+		ProfileRepository,
+		TokenService,
+		LoginAttemptService,
+		MFAChallengeService,
+		RabbitMq,
+	)
+
+	Redis.On("TimeToLive", mock.Anything).Return(time.Duration(-2), nil)
+	Redis.On("DelValue", mock.Anything).Return(nil)
+	Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	Redis.On("GetValue", mock.Anything).Return("", nil)
+	Redis.On("AddToSet", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	getRehashUser := func() *domain.IdentityUser {
+		identityUser := &domain.IdentityUser{
+			Email: "rehash-" + uuid.NewString() + "@beat.pt",
+			Role:  domain.AuthClient,
+		}
+
+		identityUser.ID = uuid.NewString()
+		AuthRepository.On("ExistsUserWithEmail", identityUser.Email).Return(true)
+		AuthRepository.On("GetUserByEmail", identityUser.Email).Return(identityUser, nil)
+		ProfileRepository.On("GetAttachProfiles", identityUser.ID).Return([]domain.Profile{*domain.NewProfile(identityUser.ID, domain.Main)}, nil)
+
+		return identityUser
+	}
+
+	t.Run("Should sign in and upgrade a legacy bcrypt hash", func(t *testing.T) {
+		identityUser := getRehashUser()
+		setLegacyPassword(t, identityUser, DefaultPassword)
+		AuthRepository.On("Update", identityUser).Return(nil).Once()
+
+		// Act
+		_, err := sut.Handle(LoginInput{
+			Email:    identityUser.Email,
+			Password: DefaultPassword,
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		AuthRepository.AssertCalled(t, "Update", identityUser)
+		assert.True(t, strings.HasPrefix(identityUser.Password, "$argon2id$v=19$m=19456,t=2,p=1$"))
+		assert.Empty(t, identityUser.Salt)
+		assert.True(t, services.CheckPasswordHash(DefaultPassword, identityUser.Salt, identityUser.Password))
+	})
+
+	t.Run("Should not upgrade a legacy hash when the password is wrong", func(t *testing.T) {
+		identityUser := getRehashUser()
+		setLegacyPassword(t, identityUser, DefaultPassword)
+		legacyHash := identityUser.Password
+		Redis.On("Increment", mock.Anything, mock.Anything).Return(int64(1), nil)
+
+		// Act
+		_, err := sut.Handle(LoginInput{
+			Email:    identityUser.Email,
+			Password: "WrongPassword1",
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		assert.NotNil(t, err)
+		assert.Equal(t, legacyHash, identityUser.Password)
+		AuthRepository.AssertNotCalled(t, "Update", identityUser)
+	})
+
+	t.Run("Should leave a current argon2id hash alone", func(t *testing.T) {
+		identityUser := getRehashUser()
+		assert.Nil(t, identityUser.SetPassword(DefaultPassword))
+		currentHash := identityUser.Password
+
+		// Act
+		_, err := sut.Handle(LoginInput{
+			Email:    identityUser.Email,
+			Password: DefaultPassword,
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, currentHash, identityUser.Password)
+		AuthRepository.AssertNotCalled(t, "Update", identityUser)
+	})
+
+	t.Run("Should upgrade a hash once the costs are raised", func(t *testing.T) {
+		identityUser := getRehashUser()
+		assert.Nil(t, identityUser.SetPassword(DefaultPassword))
+		AuthRepository.On("Update", identityUser).Return(nil).Once()
+
+		// the costs are read from the config when hashing
+		dotEnvPath, err := utils.GetDotEnvPath()
+		assert.Nil(t, err)
+
+		t.Setenv("PASSWORD_HASH_ITERATIONS", "3")
+		config.LoadEnv(dotEnvPath)
+		t.Cleanup(func() { config.LoadEnv(dotEnvPath) })
+
+		// Act
+		_, err = sut.Handle(LoginInput{
+			Email:    identityUser.Email,
+			Password: DefaultPassword,
+			Device:   services.DeviceInfo{IP: testIP},
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		AuthRepository.AssertCalled(t, "Update", identityUser)
+		assert.True(t, strings.HasPrefix(identityUser.Password, "$argon2id$v=19$m=19456,t=3,p=1$"))
+	})
+}
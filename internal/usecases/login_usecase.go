@@ -1,8 +1,12 @@
 package usecases
 
 import (
+	"log"
+
 	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/domain/events"
 	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
 	"github.com/BeatEcoprove/identityService/pkg/contracts"
 	fails "github.com/BeatEcoprove/identityService/pkg/errors"
 	"github.com/BeatEcoprove/identityService/pkg/mappers"
@@ -14,12 +18,23 @@ type (
 	LoginInput struct {
 		Email    string
 		Password string
+		Nonce    string
+		Device   services.DeviceInfo
 	}
 
 	LoginUseCase struct {
-		authRepo     repositories.IAuthRepository
-		profileRepo  repositories.IProfileRepository
-		tokenService services.ITokenService
+		authenticator *passwordAuthenticator
+		challenges    services.IMFAChallengeService
+		profileRepo   repositories.IProfileRepository
+		tokenService  services.ITokenService
+	}
+
+	// passwordAuthenticator checks email and password for every grant that
+	// takes them, failures are counted and lock the account or the ip out.
+	passwordAuthenticator struct {
+		authRepo repositories.IAuthRepository
+		attempts services.ILoginAttemptService
+		broker   adapters.Broker
 	}
 )
 
@@ -27,34 +42,206 @@ func NewLoginUseCase(
 	authRepo repositories.IAuthRepository,
 	profileRepo repositories.IProfileRepository,
 	tokenService services.ITokenService,
+	attempts services.ILoginAttemptService,
+	challenges services.IMFAChallengeService,
+	broker adapters.Broker,
 ) *LoginUseCase {
 	return &LoginUseCase{
-		authRepo:     authRepo,
-		profileRepo:  profileRepo,
-		tokenService: tokenService,
+		authenticator: newPasswordAuthenticator(authRepo, attempts, broker),
+		challenges:    challenges,
+		profileRepo:   profileRepo,
+		tokenService:  tokenService,
+	}
+}
+
+func newPasswordAuthenticator(
+	authRepo repositories.IAuthRepository,
+	attempts services.ILoginAttemptService,
+	broker adapters.Broker,
+) *passwordAuthenticator {
+	return &passwordAuthenticator{
+		authRepo: authRepo,
+		attempts: attempts,
+		broker:   broker,
 	}
 }
 
 func (as *LoginUseCase) Handle(input LoginInput) (*contracts.AuthResponse, error) {
-	if ok := as.authRepo.ExistsUserWithEmail(input.Email); !ok {
-		return nil, fails.USER_AUTH_FAILED
+	identityUser, err := as.authenticator.authenticate(input.Email, input.Password, input.Device)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := challengeMFA(as.challenges, identityUser, input.Nonce); err != nil {
+		return nil, err
+	}
+
+	return issueTokens(as.profileRepo, as.tokenService, identityUser, input.Device, "", input.Nonce)
+}
+
+// authenticate refuses locked out accounts and ips before looking at the
+// credentials. A lockout is only lifted by time, a successful login merely
+// clears the failures of the account.
+func (pa *passwordAuthenticator) authenticate(email, password string, device services.DeviceInfo) (*domain.IdentityUser, error) {
+	if err := pa.refuseLockedOut(email, device); err != nil {
+		return nil, err
+	}
+
+	identityUser, err := checkCredentials(pa.authRepo, email, password)
+
+	if err != nil {
+		return nil, pa.fail(email, device, err)
+	}
+
+	pa.succeed(email)
+	pa.rehashPassword(identityUser, password)
+
+	return identityUser, nil
+}
+
+// verify checks the password of an account that is known already, such as a
+// signed in user confirming it. Failures count towards the same lockout as
+// logins, a stolen access token can't be used to guess the password.
+// Accounts without a password are told so, they have nothing to guess and
+// must not lock their owner out of the other ways to sign in.
+func (pa *passwordAuthenticator) verify(identityUser *domain.IdentityUser, password string, device services.DeviceInfo) error {
+	if !identityUser.HasPassword() {
+		return fails.PASSWORD_NOT_SET
+	}
+
+	if err := pa.refuseLockedOut(identityUser.Email, device); err != nil {
+		return err
+	}
+
+	if !services.CheckPasswordHash(password, identityUser.Salt, identityUser.Password) {
+		return pa.fail(identityUser.Email, device, fails.USER_AUTH_FAILED)
+	}
+
+	pa.succeed(identityUser.Email)
+
+	return nil
+}
+
+func (pa *passwordAuthenticator) refuseLockedOut(email string, device services.DeviceInfo) error {
+	lockedFor, err := pa.attempts.LockedFor(email, device.IP)
+
+	if err != nil {
+		log.Printf("failed to read login attempts %s", err.Error())
+	}
+
+	if lockedFor > 0 {
+		return fails.USER_LOCKED
+	}
+
+	return nil
+}
+
+// fail counts the failure, err is reported unless it locked the account.
+func (pa *passwordAuthenticator) fail(email string, device services.DeviceInfo, err error) error {
+	if pa.registerFailure(email, device) {
+		return fails.USER_LOCKED
 	}
 
-	if err := services.ValidatePassword(input.Password); err != nil {
+	return err
+}
+
+func (pa *passwordAuthenticator) succeed(email string) {
+	if err := pa.attempts.Reset(email); err != nil {
+		log.Printf("failed to reset login attempts %s", err.Error())
+	}
+}
+
+// rehashPassword upgrades a legacy or outdated hash while the password is
+// at hand, the login goes on when it fails and is retried the next time.
+func (pa *passwordAuthenticator) rehashPassword(identityUser *domain.IdentityUser, password string) {
+	if !identityUser.PasswordNeedsRehash() {
+		return
+	}
+
+	if err := identityUser.SetPassword(password); err != nil {
+		log.Printf("failed to rehash the password of %s: %s", identityUser.ID, err.Error())
+		return
+	}
+
+	if err := pa.authRepo.Update(identityUser); err != nil {
+		log.Printf("failed to store the rehashed password of %s: %s", identityUser.ID, err.Error())
+	}
+}
+
+// registerFailure reports whether the failure locked the account, its owner
+// is warned through a user_locked event. Unknown emails are locked out as
+// well, so lockouts don't tell which emails are registered.
+func (pa *passwordAuthenticator) registerFailure(email string, device services.DeviceInfo) bool {
+	lockout, err := pa.attempts.RegisterFailure(email, device.IP)
+
+	if err != nil {
+		log.Printf("failed to register login attempt %s", err.Error())
+		return false
+	}
+
+	if lockout == nil {
+		return false
+	}
+
+	identityUser, err := pa.authRepo.GetUserByEmail(email)
+
+	if err != nil {
+		return true
+	}
+
+	if err := pa.broker.Publish(&events.UserLockedEvent{
+		AuthID:      identityUser.ID,
+		Email:       identityUser.Email,
+		Attempts:    lockout.Attempts,
+		IP:          device.IP,
+		UserAgent:   device.UserAgent,
+		LockedUntil: lockout.LockedUntil,
+	}, adapters.AuthEventTopic); err != nil {
+		log.Printf("failed to send kafka event %s", err.Error())
+	}
+
+	return true
+}
+
+// checkCredentials checks email and password, every failure is reported the
+// same way so it can't be used to find out which emails are registered. The
+// password policy isn't checked here, it may have changed since the password
+// was set.
+func checkCredentials(authRepo repositories.IAuthRepository, email, password string) (*domain.IdentityUser, error) {
+	if ok := authRepo.ExistsUserWithEmail(email); !ok {
 		return nil, fails.USER_AUTH_FAILED
 	}
 
-	identityUser, err := as.authRepo.GetUserByEmail(input.Email)
+	identityUser, err := authRepo.GetUserByEmail(email)
 
 	if err != nil {
 		return nil, fails.USER_AUTH_FAILED
 	}
 
-	if !services.CheckPasswordHash(input.Password, identityUser.Salt, identityUser.Password) {
+	if !services.CheckPasswordHash(password, identityUser.Salt, identityUser.Password) {
 		return nil, fails.USER_AUTH_FAILED
 	}
 
-	attachedProfiles, err := as.profileRepo.GetAttachProfiles(identityUser.ID)
+	return identityUser, nil
+}
+
+// issueTokens starts a new session for an authenticated user, with the main
+// profile selected. clientID is the oauth client the user authorized, empty
+// for first party logins.
+func issueTokens(
+	profileRepo repositories.IProfileRepository,
+	tokenService services.ITokenService,
+	identityUser *domain.IdentityUser,
+	device services.DeviceInfo,
+	clientID string,
+	nonce string,
+) (*contracts.AuthResponse, error) {
+	if identityUser.IsPendingDeletion() {
+		return nil, fails.ACCOUNT_PENDING_DELETION
+	}
+
+	attachedProfiles, err := profileRepo.GetAttachProfiles(identityUser.ID)
 
 	if err != nil {
 		return nil, fails.USER_AUTH_FAILED
@@ -62,13 +249,17 @@ func (as *LoginUseCase) Handle(input LoginInput) (*contracts.AuthResponse, error
 
 	mainProfile, subProfiles := domain.FilterProfiles(attachedProfiles)
 
-	accessToken, refreshToken, err := as.tokenService.CreateAuthenticationTokens(services.TokenPayload{
-		UserID:     identityUser.ID,
-		Email:      identityUser.Email,
-		ProfileID:  mainProfile.ID,
-		ProfileIds: mappers.MapProfileIdsToString(subProfiles),
-		Scope:      domain.GetPermissions(*identityUser),
-		Role:       string(identityUser.GetRole()),
+	tokens, err := tokenService.CreateAuthenticationTokens(services.TokenPayload{
+		UserID:        identityUser.ID,
+		Email:         identityUser.Email,
+		EmailVerified: identityUser.EmailVerified,
+		ProfileID:     mainProfile.ID,
+		ProfileIds:    mappers.MapProfileIdsToString(subProfiles),
+		Scope:         domain.GetPermissions(*identityUser),
+		Role:          string(identityUser.GetRole()),
+		Device:        device,
+		Nonce:         nonce,
+		ClientID:      clientID,
 	})
 
 	if err != nil {
@@ -77,7 +268,6 @@ func (as *LoginUseCase) Handle(input LoginInput) (*contracts.AuthResponse, error
 
 	return mappers.ToAuthResponse(
 		identityUser,
-		accessToken,
-		refreshToken,
+		tokens,
 	), nil
 }
@@ -1,12 +1,16 @@
 package usecases
 
 import (
+	"errors"
 	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/BeatEcoprove/identityService/internal/domain"
 	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 type (
@@ -16,6 +20,15 @@ type (
 	}
 )
 
+func toLoginInput(input LoginInputFaker) LoginInput {
+	return LoginInput{
+		Email:    input.Email,
+		Password: input.Password,
+	}
+}
+
+// getIdentityUser makes an account with the role at the index role of
+// InputFaker's oneof, 0 is a client and 1 an organization.
 func getIdentityUser(
 	email string,
 	password string,
@@ -23,7 +36,7 @@ func getIdentityUser(
 ) (*domain.IdentityUser, error) {
 	identityUser := &domain.IdentityUser{
 		Email: email,
-		Role:  domain.AuthRole(role),
+		Role:  []domain.AuthRole{domain.AuthClient, domain.AuthOrganization}[role],
 	}
 
 	err := identityUser.SetPassword(password)
@@ -31,28 +44,41 @@ func getIdentityUser(
 }
 
 func getProfilesById(identityId string, length int) []domain.Profile {
-	attachedProfiles := make([]domain.Profile, length)
+	attachedProfiles := make([]domain.Profile, 0, length)
 
+	// login signs in with the main profile, the first one always is
 	for i := 0; i < length; i++ {
-		attachedProfiles = append(attachedProfiles, *domain.NewProfile(
-			identityId,
-			domain.GrantType(rand.Intn(2)),
-		))
+		role := domain.Main
+
+		if i > 0 {
+			role = domain.GrantType(rand.Intn(2))
+		}
+
+		attachedProfiles = append(attachedProfiles, *domain.NewProfile(identityId, role))
 	}
 
 	return attachedProfiles
 }
 
 func Test_LogIn_UseCase(t *testing.T) {
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	t.Setenv("JWT_REFRESH_EXPIRED", "1")
 	InitTest()
+	SetupLoginAttempts()
 	SetupRedis()
 
 	var sut *LoginUseCase = NewLoginUseCase(
 		AuthRepository,
 		ProfileRepository,
 		TokenService,
+		LoginAttemptService,
+		MFAChallengeService,
+		RabbitMq,
 	)
 
+	Redis.On("GetValue", mock.Anything).Return("", errors.ErrUnsupported)
+	Redis.On("AddToSet", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
 	t.Run("Should fail to login when user does not exists", func(t *testing.T) {
 		var input LoginInputFaker = LoginInputFaker{}
 		generateFakeData(&input)
@@ -60,8 +86,7 @@ func Test_LogIn_UseCase(t *testing.T) {
 		// Act
 		AuthRepository.On("ExistsUserWithEmail", input.Email).Return(false)
 
-		sut.Handle(LoginInput(input))
-		_, err := sut.Handle(LoginInput(input))
+		_, err := sut.Handle(toLoginInput(input))
 
 		// Assert
 		evaluateError(t, fails.USER_AUTH_FAILED, err)
@@ -71,14 +96,18 @@ func Test_LogIn_UseCase(t *testing.T) {
 		var input LoginInputFaker = LoginInputFaker{}
 		generateFakeData(&input)
 
+		identityUser, err := getIdentityUser(input.Email, DefaultPassword, 0)
+		assert.Nil(t, err)
+
 		// Act
 		AuthRepository.On("ExistsUserWithEmail", input.Email).Return(true)
+		AuthRepository.On("GetUserByEmail", input.Email).Return(identityUser, nil)
 
-		sut.Handle(LoginInput(input))
-		_, err := sut.Handle(LoginInput(input))
+		_, err = sut.Handle(toLoginInput(input))
 
 		// Assert
 		evaluateError(t, fails.USER_AUTH_FAILED, err)
+		Redis.AssertCalled(t, "Increment", services.NewLoginAttemptsKey(services.LoginAccountScope, strings.ToLower(input.Email)), mock.Anything)
 	})
 
 	t.Run("Should Login User", func(t *testing.T) {
@@ -89,7 +118,7 @@ func Test_LogIn_UseCase(t *testing.T) {
 		identityUser, err := getIdentityUser(
 			input.Email,
 			input.Password,
-			int(domain.AuthClient),
+			0,
 		)
 
 		assert.Equal(t, err, nil)
@@ -101,18 +130,14 @@ func Test_LogIn_UseCase(t *testing.T) {
 		AuthRepository.On("GetUserByEmail", input.Email).Return(identityUser, nil)
 		ProfileRepository.On("GetAttachProfiles", identityUser.GetId()).Return(profiles, nil)
 
-		sut.Handle(LoginInput(input))
-		response, err := sut.Handle(LoginInput(input))
+		response, err := sut.Handle(toLoginInput(input))
 
 		// Assert
-		role, _ := domain.GetRole(domain.AuthRole(identityUser.Role))
-
 		assert.Nil(t, err)
+		assert.Equal(t, "Bearer", response.TokenType)
 		assert.NotEmpty(t, response.AccessToken)
-		assert.Greater(t, response.AccessTokenExpiration, 0)
+		assert.Greater(t, response.ExpiresIn, int64(0))
 		assert.NotEmpty(t, response.RefreshToken)
-		assert.Greater(t, response.RefreshTokenExpiration, 0)
-		assert.Equal(t, response.Details.Email, input.Email)
-		assert.Equal(t, response.Details.Role, role)
+		assert.Equal(t, domain.GetPermissions(*identityUser), response.Scope)
 	})
 }
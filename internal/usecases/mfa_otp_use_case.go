@@ -0,0 +1,213 @@
+package usecases
+
+import (
+	"log"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/domain/events"
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	MFAOTPInput struct {
+		MFAToken     string
+		OTP          string
+		RecoveryCode string
+		Device       services.DeviceInfo
+	}
+
+	MFAOTPUseCase struct {
+		verifier     *mfaVerifier
+		profileRepo  repositories.IProfileRepository
+		tokenService services.ITokenService
+	}
+
+	// MFARequiredError is returned instead of tokens when the account has a
+	// second factor, Token is exchanged together with a valid code.
+	MFARequiredError struct {
+		Token     string
+		ExpiresIn int64
+	}
+
+	// mfaVerifier checks the second factor sent back with a mfa token, a
+	// totp code or, when the authenticator app is lost, a recovery code.
+	mfaVerifier struct {
+		authRepo      repositories.IAuthRepository
+		challenges    services.IMFAChallengeService
+		totp          services.ITOTPService
+		recoveryCodes repositories.IRecoveryCodeRepository
+		broker        adapters.Broker
+	}
+)
+
+func NewMFAOTPUseCase(
+	authRepo repositories.IAuthRepository,
+	profileRepo repositories.IProfileRepository,
+	tokenService services.ITokenService,
+	challenges services.IMFAChallengeService,
+	totp services.ITOTPService,
+	recoveryCodes repositories.IRecoveryCodeRepository,
+	broker adapters.Broker,
+) *MFAOTPUseCase {
+	return &MFAOTPUseCase{
+		verifier:     newMFAVerifier(authRepo, challenges, totp, recoveryCodes, broker),
+		profileRepo:  profileRepo,
+		tokenService: tokenService,
+	}
+}
+
+func newMFAVerifier(
+	authRepo repositories.IAuthRepository,
+	challenges services.IMFAChallengeService,
+	totp services.ITOTPService,
+	recoveryCodes repositories.IRecoveryCodeRepository,
+	broker adapters.Broker,
+) *mfaVerifier {
+	return &mfaVerifier{
+		authRepo:      authRepo,
+		challenges:    challenges,
+		totp:          totp,
+		recoveryCodes: recoveryCodes,
+		broker:        broker,
+	}
+}
+
+func (e *MFARequiredError) Error() string {
+	return fails.MFA_REQUIRED.Error()
+}
+
+func (mu *MFAOTPUseCase) Handle(input MFAOTPInput) (*contracts.AuthResponse, error) {
+	identityUser, challenge, err := mu.verifier.verify(input.MFAToken, input.OTP, input.RecoveryCode, input.Device)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return issueTokens(mu.profileRepo, mu.tokenService, identityUser, input.Device, "", challenge.Nonce)
+}
+
+// challengeMFA holds back a login that passed the password when the account
+// has a second factor.
+func challengeMFA(challenges services.IMFAChallengeService, identityUser *domain.IdentityUser, nonce string) error {
+	if !identityUser.HasMFA() {
+		return nil
+	}
+
+	token, err := challenges.CreateChallenge(services.MFAChallenge{
+		AuthID: identityUser.ID,
+		Nonce:  nonce,
+	})
+
+	if err != nil {
+		return fails.InternalServerError()
+	}
+
+	return &MFARequiredError{
+		Token:     token,
+		ExpiresIn: int64(services.MFAChallengeLifetime.Seconds()),
+	}
+}
+
+// verify checks the code, or the recovery code when one is sent, against the
+// account behind the mfa token. Wrong codes are counted and eventually drop
+// the token.
+func (mv *mfaVerifier) verify(token, otp, recoveryCode string, device services.DeviceInfo) (*domain.IdentityUser, *services.MFAChallenge, error) {
+	challenge, err := mv.challenges.GetChallenge(token)
+
+	if err != nil {
+		return nil, nil, fails.INVALID_MFA_TOKEN
+	}
+
+	identityUser, err := mv.authRepo.Get(challenge.AuthID)
+
+	if err != nil || !identityUser.HasMFA() {
+		return nil, nil, fails.INVALID_MFA_TOKEN
+	}
+
+	var failure error
+
+	if recoveryCode != "" {
+		if !mv.useRecoveryCode(identityUser, recoveryCode, device) {
+			failure = fails.INVALID_RECOVERY_CODE
+		}
+	} else if !checkTOTPCode(mv.totp, identityUser, otp) {
+		failure = fails.INVALID_OTP
+	}
+
+	if failure != nil {
+		if err := mv.challenges.RegisterFailure(token); err != nil {
+			log.Printf("failed to register mfa attempt %s", err.Error())
+		}
+
+		return nil, nil, failure
+	}
+
+	if err := mv.challenges.ConsumeChallenge(token); err != nil {
+		return nil, nil, fails.INVALID_MFA_TOKEN
+	}
+
+	return identityUser, challenge, nil
+}
+
+// checkTOTPCode verifies a code of the enrolled secret, confirmed or not.
+func checkTOTPCode(totp services.ITOTPService, identityUser *domain.IdentityUser, otp string) bool {
+	secret, err := services.DecryptTOTPSecret(identityUser.TotpSecret)
+
+	if err != nil {
+		log.Printf("failed to decrypt totp secret %s", err.Error())
+		return false
+	}
+
+	ok, err := totp.Verify(identityUser.ID, secret, otp)
+
+	if err != nil {
+		log.Printf("failed to verify totp code %s", err.Error())
+		return false
+	}
+
+	return ok
+}
+
+// useRecoveryCode spends a matching unused code, the owner is told through a
+// recovery_code_used event in case it wasn't them.
+func (mv *mfaVerifier) useRecoveryCode(identityUser *domain.IdentityUser, code string, device services.DeviceInfo) bool {
+	codes, err := mv.recoveryCodes.GetUnusedByAuthId(identityUser.ID)
+
+	if err != nil {
+		log.Printf("failed to read recovery codes %s", err.Error())
+		return false
+	}
+
+	for _, recoveryCode := range codes {
+		if !recoveryCode.Matches(code) {
+			continue
+		}
+
+		used, err := mv.recoveryCodes.MarkUsed(recoveryCode.ID)
+
+		if err != nil || !used {
+			return false
+		}
+
+		if err := mv.broker.Publish(&events.RecoveryCodeUsedEvent{
+			AuthID:    identityUser.ID,
+			Email:     identityUser.Email,
+			Remaining: len(codes) - 1,
+			IP:        device.IP,
+			UserAgent: device.UserAgent,
+			UsedAt:    time.Now(),
+		}, adapters.AuthEventTopic); err != nil {
+			log.Printf("failed to send kafka event %s", err.Error())
+		}
+
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,163 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+func getMFAUser(t *testing.T) *domain.IdentityUser {
+	var data LoginInputFaker
+	generateFakeData(&data)
+
+	identityUser, err := getIdentityUser(data.Email, DefaultPassword, 0)
+	assert.Nil(t, err)
+	identityUser.ID = uuid.NewString()
+
+	encryptedSecret, err := services.EncryptTOTPSecret(testTOTPSecret)
+	assert.Nil(t, err)
+
+	identityUser.EnrollTOTP(encryptedSecret)
+	identityUser.EnableTOTP()
+
+	AuthRepository.On("Get", identityUser.ID).Return(identityUser, nil)
+	return identityUser
+}
+
+func storeMFAChallenge(t *testing.T, challenge services.MFAChallenge) string {
+	token := uuid.NewString()
+
+	rawChallenge, err := json.Marshal(challenge)
+	assert.Nil(t, err)
+
+	Redis.On("GetValue", services.NewMFAChallengeKey(token)).Return(string(rawChallenge), nil)
+	Redis.On("GetAndDelValue", services.NewMFAChallengeKey(token)).Return(string(rawChallenge), nil)
+	return token
+}
+
+func currentTOTPCode(t *testing.T) string {
+	code, err := services.GenerateTOTPCode(testTOTPSecret, time.Now())
+	assert.Nil(t, err)
+
+	return code
+}
+
+func Test_MFA_OTP_UseCase(t *testing.T) {
+	t.Setenv("MFA_ENCRYPTION_KEY", "test-mfa-key")
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	t.Setenv("JWT_REFRESH_EXPIRED", "1")
+	InitTest()
+	SetupLoginAttempts()
+
+	var sut *MFAOTPUseCase = NewMFAOTPUseCase(
+		AuthRepository,
+		ProfileRepository,
+		TokenService,
+		MFAChallengeService,
+		TOTPService,
+		RecoveryCodeRepository,
+		RabbitMq,
+	)
+
+	t.Run("Should hold back the tokens of a password login when the account has a second factor", func(t *testing.T) {
+		login := NewLoginUseCase(
+			AuthRepository,
+			ProfileRepository,
+			TokenService,
+			LoginAttemptService,
+			MFAChallengeService,
+			RabbitMq,
+		)
+
+		identityUser := getMFAUser(t)
+
+		AuthRepository.On("ExistsUserWithEmail", identityUser.Email).Return(true)
+		AuthRepository.On("GetUserByEmail", identityUser.Email).Return(identityUser, nil)
+		Redis.On("SetValue", mock.Anything, mock.Anything, services.MFAChallengeLifetime).Return(nil).Once()
+
+		// Act
+		response, err := login.Handle(LoginInput{
+			Email:    identityUser.Email,
+			Password: DefaultPassword,
+		})
+
+		// Assert
+		assert.Nil(t, response)
+
+		challenge, ok := err.(*MFARequiredError)
+		assert.True(t, ok)
+		assert.NotEmpty(t, challenge.Token)
+		Redis.AssertCalled(t, "SetValue", services.NewMFAChallengeKey(challenge.Token), mock.Anything, services.MFAChallengeLifetime)
+		ProfileRepository.AssertNotCalled(t, "GetAttachProfiles", identityUser.ID)
+	})
+
+	t.Run("Should refuse an unknown mfa token", func(t *testing.T) {
+		token := uuid.NewString()
+		Redis.On("GetValue", services.NewMFAChallengeKey(token)).Return("", nil)
+
+		// Act
+		_, err := sut.Handle(MFAOTPInput{MFAToken: token, OTP: "123456"})
+
+		// Assert
+		evaluateError(t, fails.INVALID_MFA_TOKEN, err)
+	})
+
+	t.Run("Should count a wrong code against the mfa token", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		token := storeMFAChallenge(t, services.MFAChallenge{AuthID: identityUser.ID})
+
+		// Act
+		_, err := sut.Handle(MFAOTPInput{MFAToken: token, OTP: "000000"})
+
+		// Assert
+		evaluateError(t, fails.INVALID_OTP, err)
+		Redis.AssertCalled(t, "Increment", services.NewMFAChallengeAttemptsKey(token), services.MFAChallengeLifetime)
+		Redis.AssertNotCalled(t, "GetAndDelValue", services.NewMFAChallengeKey(token))
+	})
+
+	t.Run("Should refuse a code that was already used", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		token := storeMFAChallenge(t, services.MFAChallenge{AuthID: identityUser.ID})
+		usedCounter := time.Now().Unix()/int64(services.TOTPPeriod.Seconds()) + 1
+
+		Redis.On("GetValue", services.NewTOTPCounterKey(identityUser.ID)).Return(strconv.FormatInt(usedCounter, 10), nil).Once()
+
+		// Act
+		_, err := sut.Handle(MFAOTPInput{MFAToken: token, OTP: currentTOTPCode(t)})
+
+		// Assert
+		evaluateError(t, fails.INVALID_OTP, err)
+	})
+
+	t.Run("Should issue tokens for a valid code and consume the mfa token", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		token := storeMFAChallenge(t, services.MFAChallenge{AuthID: identityUser.ID, Nonce: "n-0S6_WzA2Mj"})
+
+		Redis.On("GetValue", services.NewTOTPCounterKey(identityUser.ID)).Return("", nil).Once()
+		Redis.On("GetValue", mock.Anything).Return("", errors.ErrUnsupported).Once()
+		Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		Redis.On("AddToSet", services.NewSessionsKey(identityUser.ID), mock.Anything, mock.Anything).Return(nil)
+		ProfileRepository.On("GetAttachProfiles", identityUser.ID).Return([]domain.Profile{*domain.NewProfile(identityUser.ID, domain.Main)}, nil)
+
+		// Act
+		response, err := sut.Handle(MFAOTPInput{MFAToken: token, OTP: currentTOTPCode(t)})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+		assert.Equal(t, "n-0S6_WzA2Mj", getIDTokenClaims(t, response.IDToken).Nonce)
+		Redis.AssertCalled(t, "GetAndDelValue", services.NewMFAChallengeKey(token))
+		Redis.AssertCalled(t, "SetValue", services.NewTOTPCounterKey(identityUser.ID), mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,84 @@
+package usecases
+
+import (
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	RegenerateRecoveryCodesUseCase struct {
+		authRepo      repositories.IAuthRepository
+		recoveryCodes repositories.IRecoveryCodeRepository
+		totp          services.ITOTPService
+	}
+)
+
+const RecoveryCodeCount = 10
+
+func NewRegenerateRecoveryCodesUseCase(
+	authRepo repositories.IAuthRepository,
+	recoveryCodes repositories.IRecoveryCodeRepository,
+	totp services.ITOTPService,
+) *RegenerateRecoveryCodesUseCase {
+	return &RegenerateRecoveryCodesUseCase{
+		authRepo:      authRepo,
+		recoveryCodes: recoveryCodes,
+		totp:          totp,
+	}
+}
+
+// Handle asks for a current code before replacing the recovery codes, the
+// previous ones stop working. Wrong codes count towards the same limit as
+// the totp settings.
+func (rru *RegenerateRecoveryCodesUseCase) Handle(request TOTPCodeInput) (*contracts.RecoveryCodesResponse, error) {
+	identityUser, err := rru.authRepo.Get(request.AuthId)
+
+	if err != nil {
+		return nil, fails.USER_NOT_FOUND
+	}
+
+	if !identityUser.HasMFA() {
+		return nil, fails.MFA_NOT_ENROLLED
+	}
+
+	if err := verifyTOTPCode(rru.totp, identityUser, request.OTP); err != nil {
+		return nil, err
+	}
+
+	return replaceRecoveryCodes(rru.recoveryCodes, identityUser.ID)
+}
+
+// replaceRecoveryCodes returns the new codes in clear, the only time they
+// are shown.
+func replaceRecoveryCodes(recoveryCodes repositories.IRecoveryCodeRepository, authID string) (*contracts.RecoveryCodesResponse, error) {
+	codes := make([]string, RecoveryCodeCount)
+	entities := make([]*domain.RecoveryCode, RecoveryCodeCount)
+
+	for i := range codes {
+		code, err := services.GenerateRecoveryCode()
+
+		if err != nil {
+			return nil, fails.InternalServerError()
+		}
+
+		entity, err := domain.NewRecoveryCode(authID, code)
+
+		if err != nil {
+			return nil, fails.InternalServerError()
+		}
+
+		codes[i] = code
+		entities[i] = entity
+	}
+
+	if err := recoveryCodes.ReplaceForAuthId(authID, entities); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return &contracts.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}, nil
+}
@@ -0,0 +1,168 @@
+package usecases
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/domain/events"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getRecoveryCodes(t *testing.T, authID string, codes ...string) []domain.RecoveryCode {
+	recoveryCodes := make([]domain.RecoveryCode, len(codes))
+
+	for i, code := range codes {
+		recoveryCode, err := domain.NewRecoveryCode(authID, code)
+		assert.Nil(t, err)
+
+		recoveryCodes[i] = *recoveryCode
+	}
+
+	RecoveryCodeRepository.On("GetUnusedByAuthId", authID).Return(recoveryCodes, nil)
+	return recoveryCodes
+}
+
+func Test_Recovery_Codes_UseCase(t *testing.T) {
+	t.Setenv("MFA_ENCRYPTION_KEY", "test-mfa-key")
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	t.Setenv("JWT_REFRESH_EXPIRED", "1")
+	InitTest()
+	SetupLoginAttempts()
+
+	mfaOTP := NewMFAOTPUseCase(
+		AuthRepository,
+		ProfileRepository,
+		TokenService,
+		MFAChallengeService,
+		TOTPService,
+		RecoveryCodeRepository,
+		RabbitMq,
+	)
+
+	regenerate := NewRegenerateRecoveryCodesUseCase(AuthRepository, RecoveryCodeRepository, TOTPService)
+
+	RabbitMq.On("Publish", mock.Anything).Return(nil)
+
+	t.Run("Should generate codes that are unique and only stored hashed", func(t *testing.T) {
+		codes := map[string]bool{}
+
+		for range RecoveryCodeCount {
+			code, err := services.GenerateRecoveryCode()
+			assert.Nil(t, err)
+			assert.Regexp(t, "^[a-z2-9]{5}-[a-z2-9]{5}$", code)
+
+			recoveryCode, err := domain.NewRecoveryCode("auth", code)
+			assert.Nil(t, err)
+			assert.NotContains(t, recoveryCode.Code, domain.NormalizeRecoveryCode(code))
+			assert.True(t, recoveryCode.Matches(code))
+
+			codes[code] = true
+		}
+
+		assert.Len(t, codes, RecoveryCodeCount)
+	})
+
+	t.Run("Should refuse a wrong recovery code and count it against the mfa token", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		token := storeMFAChallenge(t, services.MFAChallenge{AuthID: identityUser.ID})
+		getRecoveryCodes(t, identityUser.ID, "abcde-fghij")
+
+		// Act
+		_, err := mfaOTP.Handle(MFAOTPInput{MFAToken: token, RecoveryCode: "zzzzz-zzzzz"})
+
+		// Assert
+		evaluateError(t, fails.INVALID_RECOVERY_CODE, err)
+		Redis.AssertCalled(t, "Increment", services.NewMFAChallengeAttemptsKey(token), services.MFAChallengeLifetime)
+		RecoveryCodeRepository.AssertNotCalled(t, "MarkUsed", mock.Anything)
+	})
+
+	t.Run("Should refuse a recovery code spent by a concurrent login", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		token := storeMFAChallenge(t, services.MFAChallenge{AuthID: identityUser.ID})
+		codes := getRecoveryCodes(t, identityUser.ID, "abcde-fghij")
+
+		RecoveryCodeRepository.On("MarkUsed", codes[0].ID).Return(false, nil).Once()
+
+		// Act
+		_, err := mfaOTP.Handle(MFAOTPInput{MFAToken: token, RecoveryCode: "abcde-fghij"})
+
+		// Assert
+		evaluateError(t, fails.INVALID_RECOVERY_CODE, err)
+	})
+
+	t.Run("Should issue tokens for a recovery code, spend it and tell the owner", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		token := storeMFAChallenge(t, services.MFAChallenge{AuthID: identityUser.ID})
+		codes := getRecoveryCodes(t, identityUser.ID, "abcde-fghij", "klmno-pqrst")
+
+		RecoveryCodeRepository.On("MarkUsed", codes[1].ID).Return(true, nil).Once()
+		Redis.On("GetValue", mock.Anything).Return("", errors.ErrUnsupported).Once()
+		Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		Redis.On("AddToSet", services.NewSessionsKey(identityUser.ID), mock.Anything, mock.Anything).Return(nil)
+		ProfileRepository.On("GetAttachProfiles", identityUser.ID).Return([]domain.Profile{*domain.NewProfile(identityUser.ID, domain.Main)}, nil)
+
+		// Act
+		response, err := mfaOTP.Handle(MFAOTPInput{
+			MFAToken:     token,
+			RecoveryCode: "KLMNOPQRST",
+			Device:       services.DeviceInfo{IP: "203.0.113.7"},
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+		Redis.AssertCalled(t, "GetAndDelValue", services.NewMFAChallengeKey(token))
+		RabbitMq.AssertCalled(t, "Publish", mock.MatchedBy(func(event *events.RecoveryCodeUsedEvent) bool {
+			return event.AuthID == identityUser.ID && event.Remaining == 1 && event.IP == "203.0.113.7"
+		}))
+	})
+
+	t.Run("Should not regenerate the codes without a valid code", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		Redis.On("GetValue", services.NewTOTPAttemptsKey(identityUser.ID)).Return("", nil).Once()
+		Redis.On("GetValue", services.NewTOTPCounterKey(identityUser.ID)).Return("", nil).Once()
+
+		// Act
+		_, err := regenerate.Handle(TOTPCodeInput{AuthId: identityUser.ID, OTP: "000000"})
+
+		// Assert
+		evaluateError(t, fails.INVALID_OTP, err)
+		RecoveryCodeRepository.AssertNotCalled(t, "ReplaceForAuthId", identityUser.ID, mock.Anything)
+		Redis.AssertCalled(t, "Increment", services.NewTOTPAttemptsKey(identityUser.ID), services.TOTPAttemptsWindow)
+	})
+
+	t.Run("Should not regenerate the codes once there were too many wrong ones", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		Redis.On("GetValue", services.NewTOTPAttemptsKey(identityUser.ID)).Return(strconv.Itoa(services.MaxTOTPAttempts), nil).Once()
+
+		// Act
+		_, err := regenerate.Handle(TOTPCodeInput{AuthId: identityUser.ID, OTP: currentTOTPCode(t)})
+
+		// Assert
+		evaluateError(t, fails.OTP_ATTEMPTS_EXCEEDED, err)
+		RecoveryCodeRepository.AssertNotCalled(t, "ReplaceForAuthId", identityUser.ID, mock.Anything)
+	})
+
+	t.Run("Should replace every recovery code with a valid code", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+
+		Redis.On("GetValue", services.NewTOTPAttemptsKey(identityUser.ID)).Return("", nil).Once()
+		Redis.On("GetValue", services.NewTOTPCounterKey(identityUser.ID)).Return("", nil).Once()
+		RecoveryCodeRepository.On("ReplaceForAuthId", identityUser.ID, mock.MatchedBy(func(codes []*domain.RecoveryCode) bool {
+			return len(codes) == RecoveryCodeCount
+		})).Return(nil).Once()
+
+		// Act
+		response, err := regenerate.Handle(TOTPCodeInput{AuthId: identityUser.ID, OTP: currentTOTPCode(t)})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Len(t, response.RecoveryCodes, RecoveryCodeCount)
+		RecoveryCodeRepository.AssertCalled(t, "ReplaceForAuthId", identityUser.ID, mock.Anything)
+	})
+}
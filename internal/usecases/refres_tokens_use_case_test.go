@@ -6,8 +6,10 @@ import (
 
 	"github.com/BeatEcoprove/identityService/internal/domain"
 	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func getRefreshTokenInput(includeProfile bool) *RefreshTokensInput {
@@ -19,12 +21,20 @@ func getRefreshTokenInput(includeProfile bool) *RefreshTokensInput {
 
 	return &RefreshTokensInput{
 		AuthId:    uuid.New().String(),
+		SessionId: uuid.New().String(),
+		Token:     uuid.New().String(),
 		ProfileId: profileId,
 	}
 }
 
+// storeRefreshToken makes input.Token the current refresh token of its
+// session.
+func storeRefreshToken(input *RefreshTokensInput) {
+	Redis.On("GetAndDelValue", services.NewRefreshTokenKey(input.AuthId, input.SessionId)).Return(input.Token, nil).Once()
+}
+
 func getTestProfiles(authId string, length int) []domain.Profile {
-	var attachedProfiles []domain.Profile = make([]domain.Profile, length)
+	var attachedProfiles []domain.Profile = make([]domain.Profile, 0, length+1)
 
 	attachedProfiles = append(attachedProfiles, *domain.NewProfile(authId, domain.Main))
 	for i := 0; i < length; i++ {
@@ -35,15 +45,21 @@ func getTestProfiles(authId string, length int) []domain.Profile {
 }
 
 func Test_Refresh_Token_UseCase(t *testing.T) {
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	t.Setenv("JWT_REFRESH_EXPIRED", "1")
 	InitTest()
-	SetupRedis()
 
 	var sut *RefreshTokensUseCase = NewRefreshTokensUseCase(
 		AuthRepository,
 		ProfileRepository,
 		TokenService,
+		RabbitMq,
 	)
 
+	Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	Redis.On("GetValue", mock.Anything).Return("", errors.ErrUnsupported)
+	Redis.On("AddToSet", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
 	t.Run("Should generate tokens for a single main profile", func(t *testing.T) {
 		t.Run("Should not refresh tokens if the user does not exists", func(t *testing.T) {
 			var input *RefreshTokensInput = getRefreshTokenInput(true)
@@ -67,7 +83,7 @@ func Test_Refresh_Token_UseCase(t *testing.T) {
 			identityUser, err := getIdentityUser(
 				data.Email,
 				data.Password,
-				int(domain.AuthClient),
+				0,
 			)
 
 			assert.Equal(t, err, nil)
@@ -92,7 +108,7 @@ func Test_Refresh_Token_UseCase(t *testing.T) {
 			identityUser, err := getIdentityUser(
 				data.Email,
 				data.Password,
-				int(domain.AuthClient),
+				0,
 			)
 
 			assert.Equal(t, err, nil)
@@ -118,7 +134,7 @@ func Test_Refresh_Token_UseCase(t *testing.T) {
 			identityUser, err := getIdentityUser(
 				data.Email,
 				data.Password,
-				int(domain.AuthClient),
+				0,
 			)
 
 			assert.Equal(t, err, nil)
@@ -129,19 +145,18 @@ func Test_Refresh_Token_UseCase(t *testing.T) {
 			AuthRepository.On("Get", input.AuthId).Return(identityUser, nil)
 			ProfileRepository.On("IsProfileFromUserId", input.AuthId, input.ProfileId).Return(true)
 			ProfileRepository.On("Get", input.ProfileId).Return(testOneProfile, nil)
+			storeRefreshToken(input)
 
 			response, err := sut.Handle(*input)
 
 			// Assert
-			role, _ := domain.GetRole(identityUser.Role)
-
 			assert.Nil(t, err)
+			assert.Equal(t, "Bearer", response.TokenType)
 			assert.NotEmpty(t, response.AccessToken)
-			assert.Greater(t, response.AccessTokenExpiration, 0)
+			assert.Greater(t, response.ExpiresIn, int64(0))
 			assert.NotEmpty(t, response.RefreshToken)
-			assert.Greater(t, response.RefreshTokenExpiration, 0)
-			assert.Equal(t, response.Details.Email, identityUser.Email)
-			assert.Equal(t, response.Details.Role, role)
+			assert.NotEqual(t, input.Token, response.RefreshToken)
+			assert.Equal(t, domain.GetPermissions(*identityUser), response.Scope)
 		})
 
 		t.Run("Should generate a token pair for authorization, by multiple profiles", func(t *testing.T) {
@@ -154,7 +169,7 @@ func Test_Refresh_Token_UseCase(t *testing.T) {
 			identityUser, err := getIdentityUser(
 				data.Email,
 				data.Password,
-				int(domain.AuthClient),
+				0,
 			)
 
 			assert.Equal(t, err, nil)
@@ -163,21 +178,17 @@ func Test_Refresh_Token_UseCase(t *testing.T) {
 
 			// Act
 			AuthRepository.On("Get", input.AuthId).Return(identityUser, nil)
-			ProfileRepository.On("IsProfileFromUserId", input.AuthId, input.ProfileId).Return(true)
 			ProfileRepository.On("GetAttachProfiles", input.AuthId).Return(testProfiles, nil)
+			storeRefreshToken(input)
 
 			response, err := sut.Handle(*input)
 
 			// Assert
-			role, _ := domain.GetRole(identityUser.Role)
-
 			assert.Nil(t, err)
 			assert.NotEmpty(t, response.AccessToken)
-			assert.Greater(t, response.AccessTokenExpiration, 0)
+			assert.Greater(t, response.ExpiresIn, int64(0))
 			assert.NotEmpty(t, response.RefreshToken)
-			assert.Greater(t, response.RefreshTokenExpiration, 0)
-			assert.Equal(t, response.Details.Email, identityUser.Email)
-			assert.Equal(t, response.Details.Role, role)
+			assert.Equal(t, domain.GetPermissions(*identityUser), response.Scope)
 		})
 	})
 }
@@ -0,0 +1,74 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Refresh_Token_Rotation_UseCase(t *testing.T) {
+	InitTest()
+	SetupRabbitmq()
+
+	var sut *RefreshTokensUseCase = NewRefreshTokensUseCase(
+		AuthRepository,
+		ProfileRepository,
+		TokenService,
+		RabbitMq,
+	)
+
+	getRotationInput := func() RefreshTokensInput {
+		var data LoginInputFaker = LoginInputFaker{}
+		generateFakeData(&data)
+
+		identityUser, err := getIdentityUser(data.Email, DefaultPassword, 0)
+		assert.Nil(t, err)
+
+		input := RefreshTokensInput{
+			AuthId:    uuid.New().String(),
+			SessionId: uuid.New().String(),
+			Token:     uuid.New().String(),
+			ProfileId: uuid.New().String(),
+		}
+
+		AuthRepository.On("Get", input.AuthId).Return(identityUser, nil)
+		ProfileRepository.On("IsProfileFromUserId", input.AuthId, input.ProfileId).Return(true)
+		ProfileRepository.On("Get", input.ProfileId).Return(domain.NewProfile(input.AuthId, domain.Main), nil)
+
+		return input
+	}
+
+	t.Run("Should not refresh when the session has no refresh token", func(t *testing.T) {
+		input := getRotationInput()
+
+		// Act
+		Redis.On("GetAndDelValue", services.NewRefreshTokenKey(input.AuthId, input.SessionId)).Return("", nil)
+
+		_, err := sut.Handle(input)
+
+		// Assert
+		evaluateError(t, fails.INVALID_REFRESH_TOKEN, err)
+	})
+
+	t.Run("Should revoke the session and publish an event when a rotated token is reused", func(t *testing.T) {
+		input := getRotationInput()
+
+		// Act
+		Redis.On("GetAndDelValue", services.NewRefreshTokenKey(input.AuthId, input.SessionId)).Return("newer-refresh-token", nil)
+		Redis.On("DelValue", mock.Anything).Return(nil)
+		Redis.On("RemoveFromSet", services.NewSessionsKey(input.AuthId), mock.Anything).Return(nil)
+		RabbitMq.On("Publish", mock.Anything).Return(nil)
+
+		_, err := sut.Handle(input)
+
+		// Assert
+		evaluateError(t, fails.INVALID_REFRESH_TOKEN, err)
+		Redis.AssertCalled(t, "RemoveFromSet", services.NewSessionsKey(input.AuthId), []string{input.SessionId})
+		RabbitMq.AssertCalled(t, "Publish", mock.Anything)
+	})
+}
@@ -1,8 +1,12 @@
 package usecases
 
 import (
+	"log"
+
 	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/domain/events"
 	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
 	"github.com/BeatEcoprove/identityService/pkg/contracts"
 	fails "github.com/BeatEcoprove/identityService/pkg/errors"
 	"github.com/BeatEcoprove/identityService/pkg/mappers"
@@ -13,13 +17,17 @@ type (
 	// input
 	RefreshTokensInput struct {
 		AuthId    string
+		SessionId string
+		Token     string
 		ProfileId string
+		Device    services.DeviceInfo
 	}
 
 	RefreshTokensUseCase struct {
 		authRepo     repositories.IAuthRepository
 		profileRepo  repositories.IProfileRepository
 		tokenService services.ITokenService
+		broker       adapters.Broker
 	}
 )
 
@@ -27,11 +35,13 @@ func NewRefreshTokensUseCase(
 	authRepo repositories.IAuthRepository,
 	profileRepo repositories.IProfileRepository,
 	tokenService services.ITokenService,
+	broker adapters.Broker,
 ) *RefreshTokensUseCase {
 	return &RefreshTokensUseCase{
 		authRepo:     authRepo,
 		profileRepo:  profileRepo,
 		tokenService: tokenService,
+		broker:       broker,
 	}
 }
 
@@ -48,13 +58,24 @@ func (rtu *RefreshTokensUseCase) Handle(request RefreshTokensInput) (*contracts.
 		return nil, err
 	}
 
-	accessToken, refreshToken, err := rtu.tokenService.CreateAuthenticationTokens(services.TokenPayload{
-		UserID:     identityUser.ID,
-		Email:      identityUser.Email,
-		ProfileID:  mainProfile.ID,
-		ProfileIds: mappers.MapProfileIdsToString(subProfiles),
-		Scope:      domain.GetPermissions(*identityUser),
-		Role:       string(identityUser.GetRole()),
+	if err := rtu.tokenService.ConsumeRefreshToken(request.AuthId, request.SessionId, request.Token); err != nil {
+		if err == services.ErrRefreshTokenReused {
+			rtu.publishReuse(request)
+		}
+
+		return nil, fails.INVALID_REFRESH_TOKEN
+	}
+
+	tokens, err := rtu.tokenService.CreateAuthenticationTokens(services.TokenPayload{
+		UserID:        identityUser.ID,
+		SessionID:     request.SessionId,
+		Email:         identityUser.Email,
+		EmailVerified: identityUser.EmailVerified,
+		ProfileID:     mainProfile.ID,
+		ProfileIds:    mappers.MapProfileIdsToString(subProfiles),
+		Scope:         domain.GetPermissions(*identityUser),
+		Role:          string(identityUser.GetRole()),
+		Device:        request.Device,
 	})
 
 	if err != nil {
@@ -63,11 +84,21 @@ func (rtu *RefreshTokensUseCase) Handle(request RefreshTokensInput) (*contracts.
 
 	return mappers.ToAuthResponse(
 		identityUser,
-		accessToken,
-		refreshToken,
+		tokens,
 	), nil
 }
 
+func (rtu *RefreshTokensUseCase) publishReuse(request RefreshTokensInput) {
+	if err := rtu.broker.Publish(&events.RefreshTokenReusedEvent{
+		AuthID:    request.AuthId,
+		SessionID: request.SessionId,
+		IP:        request.Device.IP,
+		UserAgent: request.Device.UserAgent,
+	}, adapters.AuthEventTopic); err != nil {
+		log.Printf("failed to send kafka event %s", err.Error())
+	}
+}
+
 func (rtu *RefreshTokensUseCase) getProfiles(authId, profileId string) (*domain.Profile, []domain.Profile, error) {
 	if profileId != "" {
 		if ok := rtu.profileRepo.IsProfileFromUserId(authId, profileId); !ok {
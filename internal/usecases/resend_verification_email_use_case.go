@@ -0,0 +1,64 @@
+package usecases
+
+import (
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	ResendVerificationEmailInput struct {
+		AuthId string
+	}
+
+	ResendVerificationEmailUseCase struct {
+		authRepo            repositories.IAuthRepository
+		verificationService services.IEmailVerificationService
+		emailService        services.IEmailService
+	}
+)
+
+func NewResendVerificationEmailUseCase(
+	authRepo repositories.IAuthRepository,
+	verificationService services.IEmailVerificationService,
+	emailService services.IEmailService,
+) *ResendVerificationEmailUseCase {
+	return &ResendVerificationEmailUseCase{
+		authRepo:            authRepo,
+		verificationService: verificationService,
+		emailService:        emailService,
+	}
+}
+
+// Handle sends a new verification email, the token of the previous one stops
+// working.
+func (rvu *ResendVerificationEmailUseCase) Handle(request ResendVerificationEmailInput) (*contracts.GenericResponse, error) {
+	identityUser, err := rvu.authRepo.Get(request.AuthId)
+
+	if err != nil {
+		return nil, fails.USER_NOT_FOUND
+	}
+
+	if identityUser.EmailVerified {
+		return nil, fails.EMAIL_ALREADY_VERIFIED
+	}
+
+	token, err := rvu.verificationService.CreateToken(identityUser.ID)
+
+	if err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	if err := rvu.emailService.Send(services.EmailInput{
+		To:       identityUser.Email,
+		Template: services.NewConfirmEmailTemplate(token),
+	}); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return &contracts.GenericResponse{
+		Message: "It was sent an email with the link to verify your email",
+	}, nil
+}
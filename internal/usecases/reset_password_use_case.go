@@ -1,6 +1,9 @@
 package usecases
 
 import (
+	"log"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
 	"github.com/BeatEcoprove/identityService/internal/repositories"
 	"github.com/BeatEcoprove/identityService/pkg/contracts"
 	fails "github.com/BeatEcoprove/identityService/pkg/errors"
@@ -19,6 +22,8 @@ type (
 		authRepo     repositories.IAuthRepository
 		pgService    services.IPGService
 		emailService services.IEmailService
+		breaches     services.IBreachedPasswordService
+		historyRepo  repositories.IPasswordHistoryRepository
 	}
 )
 
@@ -26,14 +31,95 @@ func NewResetPasswdUseCase(
 	authRepo repositories.IAuthRepository,
 	pgService services.IPGService,
 	emailService services.IEmailService,
+	breaches services.IBreachedPasswordService,
+	historyRepo repositories.IPasswordHistoryRepository,
 ) *ResetPasswdUseCase {
 	return &ResetPasswdUseCase{
 		authRepo:     authRepo,
 		pgService:    pgService,
 		emailService: emailService,
+		breaches:     breaches,
+		historyRepo:  historyRepo,
+	}
+}
+
+// validateNewPassword applies the password policy and refuses passwords
+// known from breaches. The breach check is skipped when the corpus can't be
+// reached, it mustn't keep users from setting a password.
+func validateNewPassword(breaches services.IBreachedPasswordService, password, email string) error {
+	if err := services.ValidatePassword(password, email); err != nil {
+		return err
+	}
+
+	breached, err := breaches.IsBreached(password)
+
+	if err != nil {
+		log.Printf("failed to check the password against the breach corpus: %s", err.Error())
+		return nil
+	}
+
+	if breached {
+		return fails.PASSWORD_BREACHED
+	}
+
+	return nil
+}
+
+// checkPasswordReuse refuses the current password and the previous ones the
+// history keeps, PasswordHistorySize of them in all.
+func checkPasswordReuse(historyRepo repositories.IPasswordHistoryRepository, identityUser *domain.IdentityUser, password string) error {
+	size := services.PasswordHistorySize()
+
+	if size == 0 {
+		return nil
+	}
+
+	if identityUser.HasPassword() && services.CheckPasswordHash(password, identityUser.Salt, identityUser.Password) {
+		return fails.PASSWORD_REUSED
+	}
+
+	if size == 1 {
+		return nil
+	}
+
+	history, err := historyRepo.GetRecentByAuthId(identityUser.ID, size-1)
+
+	if err != nil {
+		return fails.InternalServerError()
+	}
+
+	for _, previous := range history {
+		if previous.Matches(password) {
+			return fails.PASSWORD_REUSED
+		}
+	}
+
+	return nil
+}
+
+// rememberPassword stores the password that was just replaced and drops the
+// ones the history no longer needs. The password is changed already, so
+// failures are only logged.
+func rememberPassword(historyRepo repositories.IPasswordHistoryRepository, previous *domain.PasswordHistory) {
+	keep := services.PasswordHistorySize() - 1
+
+	if previous.Password == "" || keep <= 0 {
+		return
+	}
+
+	if err := historyRepo.Create(previous); err != nil {
+		log.Printf("failed to store the previous password of %s: %s", previous.AuthID, err.Error())
+		return
+	}
+
+	if err := historyRepo.Prune(previous.AuthID, keep); err != nil {
+		log.Printf("failed to prune the password history of %s: %s", previous.AuthID, err.Error())
 	}
 }
 
+// Handle only spends the code once the new password was accepted, a refused
+// password can be retried with the same code. The code is checked first, the
+// history can't be probed without one.
 func (rpu *ResetPasswdUseCase) Handle(request ResetPasswdInput) (*contracts.GenericResponse, error) {
 	identityUser, err := rpu.authRepo.GetUserByEmail(request.Email)
 
@@ -41,14 +127,24 @@ func (rpu *ResetPasswdUseCase) Handle(request ResetPasswdInput) (*contracts.Gene
 		return nil, fails.USER_NOT_FOUND
 	}
 
-	if err := rpu.pgService.ValidateCode(identityUser.ID, request.Code); err != nil {
+	if err := rpu.pgService.CheckCode(identityUser.ID, request.Code); err != nil {
 		return nil, fails.CODE_NOT_VALID
 	}
 
-	if err := services.ValidatePassword(request.Password); err != nil {
+	if err := validateNewPassword(rpu.breaches, request.Password, identityUser.Email); err != nil {
+		return nil, err
+	}
+
+	if err := checkPasswordReuse(rpu.historyRepo, identityUser, request.Password); err != nil {
 		return nil, err
 	}
 
+	if err := rpu.pgService.ValidateCode(identityUser.ID, request.Code); err != nil {
+		return nil, fails.CODE_NOT_VALID
+	}
+
+	previous := domain.NewPasswordHistory(identityUser)
+
 	if err := identityUser.SetPassword(request.Password); err != nil {
 		return nil, fails.InternalServerError()
 	}
@@ -57,6 +153,8 @@ func (rpu *ResetPasswdUseCase) Handle(request ResetPasswdInput) (*contracts.Gene
 		return nil, fails.InternalServerError()
 	}
 
+	rememberPassword(rpu.historyRepo, previous)
+
 	return &contracts.GenericResponse{
 		Message: "The password was changed with success.",
 	}, nil
@@ -1,14 +1,20 @@
 package usecases
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
 	fails "github.com/BeatEcoprove/identityService/pkg/errors"
 	"github.com/BeatEcoprove/identityService/pkg/services"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 type (
@@ -39,6 +45,24 @@ func generateFakeCode() (string, error) {
 	return shuffling(code), nil
 }
 
+// storeForgotCode keeps a code of userID the way forgot-password does.
+func storeForgotCode(t *testing.T, userID string) string {
+	code, err := services.GenerateCode()
+	assert.Nil(t, err)
+
+	key, err := services.GeneratePassword(services.MinPassWordGen, services.MinPassWordGen)
+	assert.Nil(t, err)
+
+	cipherCode, err := services.AesEncrypt([]byte(code), []byte(key))
+	assert.Nil(t, err)
+
+	payload := fmt.Sprintf("%s%s%s", cipherCode, services.Delimiter, base64.StdEncoding.EncodeToString([]byte(key)))
+	Redis.On("GetValue", services.NewForgotCodeKey(userID)).Return(payload, nil)
+	Redis.On("GetAndDelValue", services.NewForgotCodeKey(userID)).Return(payload, nil)
+
+	return code
+}
+
 func Test_Reset_Password_UseCase(t *testing.T) {
 	InitTest()
 
@@ -46,6 +70,8 @@ func Test_Reset_Password_UseCase(t *testing.T) {
 		AuthRepository,
 		PGService,
 		EmailService,
+		BreachedPasswordService,
+		PasswordHistoryRepository,
 	)
 
 	t.Run("Should not reset password if the user can't be found", func(t *testing.T) {
@@ -86,4 +112,95 @@ func Test_Reset_Password_UseCase(t *testing.T) {
 		evaluateError(t, fails.USER_NOT_FOUND, err)
 	})
 
+	AuthRepository.On("Update", mock.Anything).Return(nil)
+	PasswordHistoryRepository.On("Create").Return(nil)
+	PasswordHistoryRepository.On("Prune", mock.Anything, mock.Anything).Return(nil)
+	Redis.On("DelValue", mock.Anything).Return(nil)
+
+	// getResetUser signs up a user that asked for a code through
+	// forgot-password.
+	getResetUser := func(t *testing.T) (*domain.IdentityUser, string) {
+		identityUser := getUnverifiedUser(t)
+		AuthRepository.On("GetUserByEmail", identityUser.Email).Return(identityUser, nil)
+		PasswordHistoryRepository.On("GetRecentByAuthId", identityUser.ID, 4).Return([]domain.PasswordHistory{}, nil)
+
+		return identityUser, storeForgotCode(t, identityUser.ID)
+	}
+
+	t.Run("Should delete a wrong code without spending it on the password", func(t *testing.T) {
+		identityUser, code := getResetUser(t)
+
+		// Act
+		_, err := sut.Handle(ResetPasswdInput{
+			Email:    identityUser.Email,
+			Code:     shuffling(code),
+			Password: "NewPassword1",
+		})
+
+		// Assert
+		evaluateError(t, fails.CODE_NOT_VALID, err)
+		Redis.AssertCalled(t, "DelValue", []adapters.RedisKey{services.NewForgotCodeKey(identityUser.ID)})
+		AuthRepository.AssertNotCalled(t, "Update", identityUser)
+	})
+
+	t.Run("Should keep the code when the new password is breached", func(t *testing.T) {
+		identityUser, code := getResetUser(t)
+		prefix, line := breachedRange("Tr0ub4dor&3x", 3)
+
+		corpus := t.TempDir()
+		assert.Nil(t, os.WriteFile(filepath.Join(corpus, prefix+".txt"), []byte(line+"\r\n"), 0o600))
+
+		sut := NewResetPasswdUseCase(AuthRepository, PGService, EmailService,
+			services.NewBreachedPasswordService(services.NewDirRangeProvider(corpus)), PasswordHistoryRepository)
+
+		// Act
+		_, err := sut.Handle(ResetPasswdInput{
+			Email:    identityUser.Email,
+			Code:     code,
+			Password: "Tr0ub4dor&3x",
+		})
+
+		// Assert
+		evaluateError(t, fails.PASSWORD_BREACHED, err)
+		Redis.AssertNotCalled(t, "GetAndDelValue", services.NewForgotCodeKey(identityUser.ID))
+
+		// Act
+		_, err = sut.Handle(ResetPasswdInput{
+			Email:    identityUser.Email,
+			Code:     code,
+			Password: "NewPassword1",
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		Redis.AssertCalled(t, "GetAndDelValue", services.NewForgotCodeKey(identityUser.ID))
+		AuthRepository.AssertCalled(t, "Update", identityUser)
+	})
+
+	t.Run("Should keep the code when the new password was used before", func(t *testing.T) {
+		identityUser, code := getResetUser(t)
+		assert.Nil(t, identityUser.SetPassword("Tr0ub4dor&3x"))
+
+		// Act
+		_, err := sut.Handle(ResetPasswdInput{
+			Email:    identityUser.Email,
+			Code:     code,
+			Password: "Tr0ub4dor&3x",
+		})
+
+		// Assert
+		evaluateError(t, fails.PASSWORD_REUSED, err)
+		Redis.AssertNotCalled(t, "GetAndDelValue", services.NewForgotCodeKey(identityUser.ID))
+
+		// Act
+		_, err = sut.Handle(ResetPasswdInput{
+			Email:    identityUser.Email,
+			Code:     code,
+			Password: "NewPassword1",
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		Redis.AssertCalled(t, "GetAndDelValue", services.NewForgotCodeKey(identityUser.ID))
+	})
 }
@@ -0,0 +1,44 @@
+package usecases
+
+import (
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	RevokeAllSessionsInput struct {
+		AuthId      string
+		SessionId   string
+		KeepCurrent bool
+	}
+
+	RevokeAllSessionsUseCase struct {
+		tokenService services.ITokenService
+	}
+)
+
+func NewRevokeAllSessionsUseCase(
+	tokenService services.ITokenService,
+) *RevokeAllSessionsUseCase {
+	return &RevokeAllSessionsUseCase{
+		tokenService: tokenService,
+	}
+}
+
+func (rau *RevokeAllSessionsUseCase) Handle(request RevokeAllSessionsInput) (*contracts.GenericResponse, error) {
+	var except []string
+
+	if request.KeepCurrent {
+		except = append(except, request.SessionId)
+	}
+
+	if err := rau.tokenService.RevokeSessions(request.AuthId, except...); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return &contracts.GenericResponse{
+		Message: "The sessions were revoked with success.",
+	}, nil
+}
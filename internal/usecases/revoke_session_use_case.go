@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	RevokeSessionInput struct {
+		AuthId    string
+		SessionId string
+	}
+
+	RevokeSessionUseCase struct {
+		tokenService services.ITokenService
+	}
+)
+
+func NewRevokeSessionUseCase(
+	tokenService services.ITokenService,
+) *RevokeSessionUseCase {
+	return &RevokeSessionUseCase{
+		tokenService: tokenService,
+	}
+}
+
+func (rsu *RevokeSessionUseCase) Handle(request RevokeSessionInput) (*contracts.GenericResponse, error) {
+	if err := rsu.tokenService.RevokeSession(request.AuthId, request.SessionId); err != nil {
+		if err == services.ErrSessionNotFound {
+			return nil, fails.SESSION_NOT_FOUND
+		}
+
+		return nil, fails.InternalServerError()
+	}
+
+	return &contracts.GenericResponse{
+		Message: "The session was revoked with success.",
+	}, nil
+}
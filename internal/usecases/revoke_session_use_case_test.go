@@ -0,0 +1,70 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getRevokeSessionInput() *RevokeSessionInput {
+	return &RevokeSessionInput{
+		AuthId:    uuid.New().String(),
+		SessionId: uuid.New().String(),
+	}
+}
+
+func Test_Revoke_Session_UseCase(t *testing.T) {
+	InitTest()
+
+	var sut *RevokeSessionUseCase = NewRevokeSessionUseCase(
+		TokenService,
+	)
+
+	t.Run("Should fail when the session does not exist", func(t *testing.T) {
+		var input *RevokeSessionInput = getRevokeSessionInput()
+
+		// Act
+		Redis.On("GetValue", services.NewSessionKey(input.AuthId, input.SessionId)).Return("", errors.ErrUnsupported)
+
+		_, err := sut.Handle(*input)
+
+		// Assert
+		evaluateError(t, fails.SESSION_NOT_FOUND, err)
+	})
+
+	t.Run("Should revoke the session tokens", func(t *testing.T) {
+		var input *RevokeSessionInput = getRevokeSessionInput()
+
+		session, err := json.Marshal(services.Session{
+			ID:         input.SessionId,
+			CreatedAt:  time.Now(),
+			LastUsedAt: time.Now(),
+		})
+
+		assert.Nil(t, err)
+
+		// Act
+		Redis.On("GetValue", services.NewSessionKey(input.AuthId, input.SessionId)).Return(string(session), nil)
+		Redis.On("DelValue", []adapters.RedisKey{
+			services.NewAccessTokenKey(input.AuthId, input.SessionId),
+			services.NewRefreshTokenKey(input.AuthId, input.SessionId),
+			services.NewSessionKey(input.AuthId, input.SessionId),
+		}).Return(nil)
+		Redis.On("RemoveFromSet", services.NewSessionsKey(input.AuthId), mock.Anything).Return(nil)
+
+		response, err := sut.Handle(*input)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.Message)
+		Redis.AssertCalled(t, "RemoveFromSet", services.NewSessionsKey(input.AuthId), []string{input.SessionId})
+	})
+}
@@ -0,0 +1,70 @@
+package usecases
+
+import (
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	RevokeTokenInput struct {
+		Token         string
+		TokenTypeHint string
+	}
+
+	RevokeTokenUseCase struct {
+		tokenService services.ITokenService
+	}
+)
+
+const (
+	AccessTokenHint  = "access_token"
+	RefreshTokenHint = "refresh_token"
+)
+
+func NewRevokeTokenUseCase(
+	tokenService services.ITokenService,
+) *RevokeTokenUseCase {
+	return &RevokeTokenUseCase{
+		tokenService: tokenService,
+	}
+}
+
+// Handle follows RFC 7009: tokens that are invalid, expired or already
+// revoked are answered the same way as a successful revocation.
+func (rtu *RevokeTokenUseCase) Handle(request RevokeTokenInput) (*contracts.GenericResponse, error) {
+	response := &contracts.GenericResponse{
+		Message: "The token was revoked with success.",
+	}
+
+	claims, _, ok := parseAnyToken(request.Token, request.TokenTypeHint)
+
+	if !ok || claims.SessionID == "" {
+		return response, nil
+	}
+
+	if err := rtu.tokenService.RevokeSession(claims.Subject, claims.SessionID); err != nil && err != services.ErrSessionNotFound {
+		return nil, fails.InternalServerError()
+	}
+
+	return response, nil
+}
+
+func parseAnyToken(token, hint string) (*services.AuthClaims, services.TokenType, bool) {
+	tokenTypes := []services.TokenType{services.Access, services.Refresh}
+
+	if hint == RefreshTokenHint {
+		tokenTypes = []services.TokenType{services.Refresh, services.Access}
+	}
+
+	for _, tokenType := range tokenTypes {
+		var claims services.AuthClaims
+
+		if err := services.GetClaims(token, &claims, tokenType); err == nil {
+			return &claims, tokenType, true
+		}
+	}
+
+	return nil, "", false
+}
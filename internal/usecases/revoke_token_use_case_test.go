@@ -0,0 +1,66 @@
+package usecases
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Revoke_Token_UseCase(t *testing.T) {
+	InitTest()
+
+	var sut *RevokeTokenUseCase = NewRevokeTokenUseCase(
+		TokenService,
+	)
+
+	t.Run("Should answer with success when the token is not valid", func(t *testing.T) {
+		// Act
+		response, err := sut.Handle(RevokeTokenInput{
+			Token:         "not-a-token",
+			TokenTypeHint: RefreshTokenHint,
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.Message)
+		Redis.AssertNotCalled(t, "DelValue", mock.Anything)
+	})
+
+	t.Run("Should revoke the session of the given token", func(t *testing.T) {
+		authId := uuid.New().String()
+		sessionId := uuid.New().String()
+
+		refreshToken, err := services.CreateJwtToken(services.TokenPayload{
+			UserID:    authId,
+			SessionID: sessionId,
+			Duration:  time.Minute,
+			Type:      services.Refresh,
+		})
+
+		assert.Nil(t, err)
+
+		session, err := json.Marshal(services.Session{ID: sessionId})
+		assert.Nil(t, err)
+
+		// Act
+		Redis.On("GetValue", services.NewSessionKey(authId, sessionId)).Return(string(session), nil)
+		Redis.On("DelValue", mock.Anything).Return(nil)
+		Redis.On("RemoveFromSet", services.NewSessionsKey(authId), mock.Anything).Return(nil)
+
+		// the hint is only an optimization, the token type is still detected
+		response, err := sut.Handle(RevokeTokenInput{
+			Token:         refreshToken.Token,
+			TokenTypeHint: AccessTokenHint,
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.Message)
+		Redis.AssertCalled(t, "RemoveFromSet", services.NewSessionsKey(authId), []string{sessionId})
+	})
+}
@@ -18,6 +18,7 @@ type (
 		Email    string
 		Password string
 		Role     string
+		Device   services.DeviceInfo
 	}
 
 	SignUpUseCase struct {
@@ -26,7 +27,9 @@ type (
 		tokenService services.ITokenService
 
 		emailService        services.IEmailService
+		verificationService services.IEmailVerificationService
 		createProfileHelper helpers.IProfileCreateService
+		breaches            services.IBreachedPasswordService
 	}
 )
 
@@ -35,14 +38,18 @@ func NewSignUpUseCase(
 	profileRepo repositories.IProfileRepository,
 	tokenService services.ITokenService,
 	emailService services.IEmailService,
+	verificationService services.IEmailVerificationService,
 	createProfileHelper helpers.IProfileCreateService,
+	breaches services.IBreachedPasswordService,
 ) *SignUpUseCase {
 	return &SignUpUseCase{
 		authRepo:            authRepo,
 		profileRepo:         profileRepo,
 		tokenService:        tokenService,
 		emailService:        emailService,
+		verificationService: verificationService,
 		createProfileHelper: createProfileHelper,
+		breaches:            breaches,
 	}
 }
 
@@ -51,7 +58,7 @@ func (as *SignUpUseCase) Handle(input SignUpInput) (*contracts.AuthResponse, err
 		return nil, fails.USER_ALREADY_EXISTS
 	}
 
-	if err := services.ValidatePassword(input.Password); err != nil {
+	if err := validateNewPassword(as.breaches, input.Password, input.Email); err != nil {
 		return nil, err
 	}
 
@@ -87,13 +94,15 @@ func (as *SignUpUseCase) Handle(input SignUpInput) (*contracts.AuthResponse, err
 		return nil, fails.InternalServerError()
 	}
 
-	accessToken, refreshToken, err := as.tokenService.CreateAuthenticationTokens(services.TokenPayload{
-		UserID:     identityUser.ID,
-		Email:      identityUser.Email,
-		ProfileID:  profile.ID,
-		Scope:      domain.GetPermissions(*identityUser),
-		ProfileIds: make([]string, 0),
-		Role:       string(identityUser.GetRole()),
+	tokens, err := as.tokenService.CreateAuthenticationTokens(services.TokenPayload{
+		UserID:        identityUser.ID,
+		Email:         identityUser.Email,
+		EmailVerified: identityUser.EmailVerified,
+		ProfileID:     profile.ID,
+		Scope:         domain.GetPermissions(*identityUser),
+		ProfileIds:    make([]string, 0),
+		Role:          string(identityUser.GetRole()),
+		Device:        input.Device,
 	})
 
 	if err != nil {
@@ -104,16 +113,28 @@ func (as *SignUpUseCase) Handle(input SignUpInput) (*contracts.AuthResponse, err
 		return nil, fails.InternalServerError()
 	}
 
+	as.sendConfirmationEmail(identityUser)
+
+	return mappers.ToAuthResponse(
+		identityUser,
+		tokens,
+	), nil
+}
+
+// sendConfirmationEmail doesn't fail the sign up, the user can ask for
+// another email once signed in.
+func (as *SignUpUseCase) sendConfirmationEmail(identityUser *domain.IdentityUser) {
+	token, err := as.verificationService.CreateToken(identityUser.ID)
+
+	if err != nil {
+		log.Println("Failed to create the email verification token")
+		return
+	}
+
 	if err := as.emailService.Send(services.EmailInput{
 		To:       identityUser.Email,
-		Template: services.NewConfirmEmailTemplate(),
+		Template: services.NewConfirmEmailTemplate(token),
 	}); err != nil {
 		log.Println("Failed to send email of account confirmation")
 	}
-
-	return mappers.ToAuthResponse(
-		identityUser,
-		accessToken,
-		refreshToken,
-	), nil
 }
@@ -1,9 +1,11 @@
 package usecases
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/usecases/helpers"
 	"github.com/BeatEcoprove/identityService/internal/usecases/utils"
 	fails "github.com/BeatEcoprove/identityService/pkg/errors"
 
@@ -15,11 +17,21 @@ type (
 	SignUpInputFaker struct {
 		Email    string `faker:"email"`
 		Password string
-		Role     int `faker:"oneof: 0, 1"`
+		Role     string `faker:"oneof: client, organization"`
 	}
 )
 
+func toSignUpInput(input SignUpInputFaker) SignUpInput {
+	return SignUpInput{
+		Email:    input.Email,
+		Password: input.Password,
+		Role:     input.Role,
+	}
+}
+
 func Test_SignUp_UseCase(t *testing.T) {
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	t.Setenv("JWT_REFRESH_EXPIRED", "1")
 	InitTest()
 	SetupRedis()
 
@@ -28,19 +40,25 @@ func Test_SignUp_UseCase(t *testing.T) {
 		ProfileRepository,
 		TokenService,
 		EmailService,
+		EmailVerificationService,
+		helpers.NewProfileCreateService(ProfileRepository, RabbitMq, Redis),
+		BreachedPasswordService,
 	)
 
+	RabbitMq.On("Publish", mock.Anything).Return(nil)
+	Redis.On("GetValue", mock.Anything).Return("", errors.ErrUnsupported)
+	Redis.On("AddToSet", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
 	t.Run("Should not create an account if the email is already in use", func(t *testing.T) {
 		// Arrange
 		var input SignUpInputFaker = SignUpInputFaker{}
 		generateFakeData(&input)
-		input.Password = DefaultPassword
+		input.Password = "Sign-Up-Password-42"
 
 		// Act
 		AuthRepository.On("ExistsUserWithEmail", input.Email).Return(true)
 
-		sut.Handle(SignUpInput(input))
-		_, err := sut.Handle(SignUpInput(input))
+		_, err := sut.Handle(toSignUpInput(input))
 
 		// Assert
 		evaluateError(t, fails.USER_ALREADY_EXISTS, err)
@@ -52,25 +70,24 @@ func Test_SignUp_UseCase(t *testing.T) {
 
 		var input SignUpInputFaker = SignUpInputFaker{}
 		generateFakeData(&input)
-		input.Password = DefaultPassword
+		input.Password = "Sign-Up-Password-42"
 
 		// Act
 		AuthRepository.On("ExistsUserWithEmail", input.Email).Return(false)
-		AuthRepository.On("BeginTransaction").Return(transRepo, nil)
-		transRepo.MockRepositoryBase.On("Create", mock.Anything).Return(nil)
-		transRepo.On("Commit", mock.Anything).Return(nil)
+		AuthRepository.On("BeginTransaction").Return(transRepo, nil).Once()
+		transRepo.MockRepositoryBase.On("Create").Return(nil)
+		transRepo.On("Commit").Return(nil)
+		ProfileRepository.On("GetMainProfileByAuthId", mock.Anything).Return((*domain.Profile)(nil), errors.ErrUnsupported).Once()
 
-		response, err := sut.Handle(SignUpInput(input))
+		response, err := sut.Handle(toSignUpInput(input))
 
 		// Assert
-		role, _ := domain.GetRole(domain.AuthRole(input.Role))
-
 		assert.Nil(t, err)
+		assert.Equal(t, "Bearer", response.TokenType)
 		assert.NotEmpty(t, response.AccessToken)
-		assert.Greater(t, response.AccessTokenExpiration, 0)
+		assert.Greater(t, response.ExpiresIn, int64(0))
 		assert.NotEmpty(t, response.RefreshToken)
-		assert.Greater(t, response.RefreshTokenExpiration, 0)
-		assert.Equal(t, response.Details.Email, input.Email)
-		assert.Equal(t, response.Details.Role, role)
+		assert.Equal(t, domain.GetPermissions(domain.IdentityUser{Role: domain.AuthRole(input.Role)}), response.Scope)
+		transRepo.AssertCalled(t, "Commit")
 	})
 }
@@ -0,0 +1,207 @@
+package usecases
+
+import (
+	"log"
+
+	"github.com/BeatEcoprove/identityService/config"
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	EnrollTOTPInput struct {
+		AuthId string
+	}
+
+	// input
+	TOTPCodeInput struct {
+		AuthId string
+		OTP    string
+	}
+
+	EnrollTOTPUseCase struct {
+		authRepo repositories.IAuthRepository
+	}
+
+	ConfirmTOTPUseCase struct {
+		authRepo      repositories.IAuthRepository
+		recoveryCodes repositories.IRecoveryCodeRepository
+		totp          services.ITOTPService
+	}
+
+	DisableTOTPUseCase struct {
+		authRepo      repositories.IAuthRepository
+		recoveryCodes repositories.IRecoveryCodeRepository
+		totp          services.ITOTPService
+	}
+)
+
+const defaultTOTPIssuer = "Beat"
+
+func NewEnrollTOTPUseCase(
+	authRepo repositories.IAuthRepository,
+) *EnrollTOTPUseCase {
+	return &EnrollTOTPUseCase{
+		authRepo: authRepo,
+	}
+}
+
+func NewConfirmTOTPUseCase(
+	authRepo repositories.IAuthRepository,
+	recoveryCodes repositories.IRecoveryCodeRepository,
+	totp services.ITOTPService,
+) *ConfirmTOTPUseCase {
+	return &ConfirmTOTPUseCase{
+		authRepo:      authRepo,
+		recoveryCodes: recoveryCodes,
+		totp:          totp,
+	}
+}
+
+func NewDisableTOTPUseCase(
+	authRepo repositories.IAuthRepository,
+	recoveryCodes repositories.IRecoveryCodeRepository,
+	totp services.ITOTPService,
+) *DisableTOTPUseCase {
+	return &DisableTOTPUseCase{
+		authRepo:      authRepo,
+		recoveryCodes: recoveryCodes,
+		totp:          totp,
+	}
+}
+
+// totpIssuer names the account in authenticator apps.
+func totpIssuer() string {
+	if issuer := config.GetConfig().JWT_ISSUER; issuer != "" {
+		return issuer
+	}
+
+	return defaultTOTPIssuer
+}
+
+// verifyTOTPCode checks a code a signed in user sends to change the second
+// factor, wrong codes are counted per user and once there were
+// MaxTOTPAttempts of them no code is checked until the window passed.
+func verifyTOTPCode(totp services.ITOTPService, identityUser *domain.IdentityUser, otp string) error {
+	if totp.AttemptsExceeded(identityUser.ID) {
+		return fails.OTP_ATTEMPTS_EXCEEDED
+	}
+
+	if !checkTOTPCode(totp, identityUser, otp) {
+		if err := totp.RegisterFailure(identityUser.ID); err != nil {
+			log.Printf("failed to register totp attempt %s", err.Error())
+		}
+
+		return fails.INVALID_OTP
+	}
+
+	if err := totp.ResetAttempts(identityUser.ID); err != nil {
+		log.Printf("failed to reset totp attempts %s", err.Error())
+	}
+
+	return nil
+}
+
+// Handle starts over any unconfirmed enrollment, the secret is only shown
+// this once.
+func (etu *EnrollTOTPUseCase) Handle(request EnrollTOTPInput) (*contracts.TOTPEnrollmentResponse, error) {
+	identityUser, err := etu.authRepo.Get(request.AuthId)
+
+	if err != nil {
+		return nil, fails.USER_NOT_FOUND
+	}
+
+	if identityUser.HasMFA() {
+		return nil, fails.MFA_ALREADY_ENABLED
+	}
+
+	secret, err := services.GenerateTOTPSecret()
+
+	if err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	encryptedSecret, err := services.EncryptTOTPSecret(secret)
+
+	if err != nil {
+		log.Printf("failed to encrypt totp secret %s", err.Error())
+		return nil, fails.InternalServerError()
+	}
+
+	identityUser.EnrollTOTP(encryptedSecret)
+
+	if err := etu.authRepo.Update(identityUser); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return &contracts.TOTPEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningURI: services.TOTPProvisioningURI(totpIssuer(), identityUser.Email, secret),
+	}, nil
+}
+
+// Handle turns the second factor on once the user proved the authenticator
+// app has the secret, and hands out the first recovery codes.
+func (ctu *ConfirmTOTPUseCase) Handle(request TOTPCodeInput) (*contracts.RecoveryCodesResponse, error) {
+	identityUser, err := ctu.authRepo.Get(request.AuthId)
+
+	if err != nil {
+		return nil, fails.USER_NOT_FOUND
+	}
+
+	if identityUser.HasMFA() {
+		return nil, fails.MFA_ALREADY_ENABLED
+	}
+
+	if identityUser.TotpSecret == "" {
+		return nil, fails.MFA_NOT_ENROLLED
+	}
+
+	if err := verifyTOTPCode(ctu.totp, identityUser, request.OTP); err != nil {
+		return nil, err
+	}
+
+	identityUser.EnableTOTP()
+
+	if err := ctu.authRepo.Update(identityUser); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return replaceRecoveryCodes(ctu.recoveryCodes, identityUser.ID)
+}
+
+// Handle asks for a current code, a stolen access token alone can't turn
+// the second factor off.
+func (dtu *DisableTOTPUseCase) Handle(request TOTPCodeInput) (*contracts.GenericResponse, error) {
+	identityUser, err := dtu.authRepo.Get(request.AuthId)
+
+	if err != nil {
+		return nil, fails.USER_NOT_FOUND
+	}
+
+	if !identityUser.HasMFA() {
+		return nil, fails.MFA_NOT_ENROLLED
+	}
+
+	if err := verifyTOTPCode(dtu.totp, identityUser, request.OTP); err != nil {
+		return nil, err
+	}
+
+	identityUser.DisableTOTP()
+
+	if err := dtu.authRepo.Update(identityUser); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	if err := dtu.recoveryCodes.DeleteByAuthId(identityUser.ID); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return &contracts.GenericResponse{
+		Message: "Two-factor authentication was disabled with success.",
+	}, nil
+}
@@ -0,0 +1,138 @@
+package usecases
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_TOTP_UseCase(t *testing.T) {
+	t.Setenv("MFA_ENCRYPTION_KEY", "test-mfa-key")
+	t.Setenv("JWT_ISSUER", "Beat")
+	InitTest()
+
+	enroll := NewEnrollTOTPUseCase(AuthRepository)
+	confirm := NewConfirmTOTPUseCase(AuthRepository, RecoveryCodeRepository, TOTPService)
+	disable := NewDisableTOTPUseCase(AuthRepository, RecoveryCodeRepository, TOTPService)
+
+	// exhausted users already sent MaxTOTPAttempts wrong codes
+	exhausted := map[string]bool{}
+
+	AuthRepository.On("Update", mock.Anything).Return(nil)
+	Redis.On("GetValue", mock.MatchedBy(func(key adapters.RedisKey) bool {
+		return exhausted[key.Key]
+	})).Return(strconv.Itoa(services.MaxTOTPAttempts), nil)
+	Redis.On("GetValue", mock.Anything).Return("", nil)
+	Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	Redis.On("Increment", mock.Anything, mock.Anything).Return(int64(1), nil)
+	Redis.On("DelValue", mock.Anything).Return(nil)
+
+	t.Run("Should enroll with an encrypted secret and a provisioning uri", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+
+		// Act
+		response, err := enroll.Handle(EnrollTOTPInput{AuthId: identityUser.ID})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.False(t, identityUser.HasMFA())
+		assert.NotEqual(t, response.Secret, identityUser.TotpSecret)
+
+		secret, err := services.DecryptTOTPSecret(identityUser.TotpSecret)
+		assert.Nil(t, err)
+		assert.Equal(t, response.Secret, secret)
+
+		uri, err := url.Parse(response.ProvisioningURI)
+		assert.Nil(t, err)
+		assert.Equal(t, "otpauth", uri.Scheme)
+		assert.Equal(t, "totp", uri.Host)
+		assert.Equal(t, response.Secret, uri.Query().Get("secret"))
+		assert.Equal(t, "Beat", uri.Query().Get("issuer"))
+	})
+
+	t.Run("Should not enable the second factor with a wrong code", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+
+		_, err := enroll.Handle(EnrollTOTPInput{AuthId: identityUser.ID})
+		assert.Nil(t, err)
+
+		// Act
+		_, err = confirm.Handle(TOTPCodeInput{AuthId: identityUser.ID, OTP: "12345"})
+
+		// Assert
+		evaluateError(t, fails.INVALID_OTP, err)
+		assert.False(t, identityUser.HasMFA())
+		Redis.AssertCalled(t, "Increment", services.NewTOTPAttemptsKey(identityUser.ID), services.TOTPAttemptsWindow)
+	})
+
+	t.Run("Should not check codes once there were too many wrong ones", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		identityUser.EnrollTOTP(identityUser.TotpSecret)
+		exhausted[services.NewTOTPAttemptsKey(identityUser.ID).Key] = true
+
+		// Act
+		_, err := confirm.Handle(TOTPCodeInput{AuthId: identityUser.ID, OTP: currentTOTPCode(t)})
+
+		// Assert
+		evaluateError(t, fails.OTP_ATTEMPTS_EXCEEDED, err)
+		assert.False(t, identityUser.HasMFA())
+	})
+
+	t.Run("Should not disable the second factor once there were too many wrong codes", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		exhausted[services.NewTOTPAttemptsKey(identityUser.ID).Key] = true
+
+		// Act
+		_, err := disable.Handle(TOTPCodeInput{AuthId: identityUser.ID, OTP: currentTOTPCode(t)})
+
+		// Assert
+		evaluateError(t, fails.OTP_ATTEMPTS_EXCEEDED, err)
+		assert.True(t, identityUser.HasMFA())
+	})
+
+	t.Run("Should enable the second factor with a code of the enrolled secret", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		identityUser.EnrollTOTP(identityUser.TotpSecret)
+
+		RecoveryCodeRepository.On("ReplaceForAuthId", identityUser.ID, mock.Anything).Return(nil).Once()
+
+		// Act
+		response, err := confirm.Handle(TOTPCodeInput{AuthId: identityUser.ID, OTP: currentTOTPCode(t)})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.True(t, identityUser.HasMFA())
+		assert.Len(t, response.RecoveryCodes, RecoveryCodeCount)
+		Redis.AssertCalled(t, "DelValue", []adapters.RedisKey{services.NewTOTPAttemptsKey(identityUser.ID)})
+	})
+
+	t.Run("Should not enroll again once enabled", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+
+		// Act
+		_, err := enroll.Handle(EnrollTOTPInput{AuthId: identityUser.ID})
+
+		// Assert
+		evaluateError(t, fails.MFA_ALREADY_ENABLED, err)
+	})
+
+	t.Run("Should disable the second factor with a valid code", func(t *testing.T) {
+		identityUser := getMFAUser(t)
+		RecoveryCodeRepository.On("DeleteByAuthId", identityUser.ID).Return(nil).Once()
+
+		// Act
+		_, err := disable.Handle(TOTPCodeInput{AuthId: identityUser.ID, OTP: currentTOTPCode(t)})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.False(t, identityUser.HasMFA())
+		assert.Empty(t, identityUser.TotpSecret)
+		RecoveryCodeRepository.AssertCalled(t, "DeleteByAuthId", identityUser.ID)
+	})
+}
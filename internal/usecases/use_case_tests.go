@@ -1,9 +1,12 @@
 package usecases
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/BeatEcoprove/identityService/internal/usecases/utils"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
 	"github.com/BeatEcoprove/identityService/pkg/services"
 	"github.com/BeatEcoprove/identityService/pkg/shared"
 	"github.com/go-faker/faker/v4"
@@ -23,18 +26,32 @@ func InitTest() {
 	utils.TestSetup()
 
 	Redis = new(utils.MockRedis)
+	lockedOut = make(map[string]bool)
 	RabbitMq = new(utils.MockRabbitMq)
 
 	AuthRepository = new(utils.MockAuthRepository)
 	ProfileRepository = new(utils.MockProfileRepository)
+	OAuthClientRepository = new(utils.MockOAuthClientRepository)
+	RecoveryCodeRepository = new(utils.MockRecoveryCodeRepository)
+	WebAuthnCredentialRepository = new(utils.MockWebAuthnCredentialRepository)
+	ExternalIdentityRepository = new(utils.MockExternalIdentityRepository)
+	PasswordHistoryRepository = new(utils.MockPasswordHistoryRepository)
 
 	TokenService = services.NewTokenService(Redis)
 	EmailService = services.NewEmailService(RabbitMq)
 	PGService = services.NewPGService(Redis)
+	LoginAttemptService = services.NewLoginAttemptService(Redis)
+	EmailVerificationService = services.NewEmailVerificationService(Redis)
+	MFAChallengeService = services.NewMFAChallengeService(Redis)
+	TOTPService = services.NewTOTPService(Redis)
+	WebAuthnService = services.NewWebAuthnService(Redis)
+	LoginCodeService = services.NewLoginCodeService(Redis)
+	FederationService = services.NewFederationService()
+	BreachedPasswordService = services.NewBreachedPasswordService(nil)
 }
 
 func SetupRabbitmq() {
-	RabbitMq.On("PublishMessage", mock.Anything).Return(nil)
+	RabbitMq.On("Publish", mock.Anything).Return(nil)
 	RabbitMq.On("Close").Return(nil)
 }
 
@@ -43,18 +60,51 @@ func SetupRedis() {
 	Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 }
 
+// SetupLoginAttempts leaves every account and ip unlocked and under the
+// attempt limits, except the accounts passed to lockOut.
+func SetupLoginAttempts() {
+	Redis.On("TimeToLive", mock.MatchedBy(func(key adapters.RedisKey) bool {
+		return !lockedOut[key.Key]
+	})).Return(time.Duration(-2), nil)
+	Redis.On("TimeToLive", mock.Anything).Return(time.Minute, nil)
+	Redis.On("Increment", mock.Anything, mock.Anything).Return(int64(1), nil)
+	Redis.On("DelValue", mock.Anything).Return(nil)
+}
+
+// lockOut locks the account of email out for a minute.
+func lockOut(email string) {
+	lockedOut[services.NewLoginLockKey(services.LoginAccountScope, strings.ToLower(email)).Key] = true
+}
+
 const DefaultPassword = "Password1"
 
 var (
 	Redis    *utils.MockRedis
 	RabbitMq *utils.MockRabbitMq
 
-	AuthRepository    *utils.MockAuthRepository
-	ProfileRepository *utils.MockProfileRepository
+	lockedOut map[string]bool
+
+	AuthRepository         *utils.MockAuthRepository
+	ProfileRepository      *utils.MockProfileRepository
+	OAuthClientRepository  *utils.MockOAuthClientRepository
+	RecoveryCodeRepository *utils.MockRecoveryCodeRepository
+
+	WebAuthnCredentialRepository *utils.MockWebAuthnCredentialRepository
+	ExternalIdentityRepository   *utils.MockExternalIdentityRepository
+	PasswordHistoryRepository    *utils.MockPasswordHistoryRepository
 
 	TokenService services.ITokenService
 	EmailService services.IEmailService
 	PGService    services.IPGService
+
+	LoginAttemptService      services.ILoginAttemptService
+	EmailVerificationService services.IEmailVerificationService
+	MFAChallengeService      services.IMFAChallengeService
+	TOTPService              services.ITOTPService
+	WebAuthnService          services.IWebAuthnService
+	LoginCodeService         services.ILoginCodeService
+	FederationService        services.IFederationService
+	BreachedPasswordService  services.IBreachedPasswordService
 )
 
 func generateFakeData(input any) {
@@ -71,3 +121,20 @@ func evaluateError(t *testing.T, shouldBe *shared.Error, err error) {
 		assert.Equal(t, shouldBe.Id, fail.Id)
 	}
 }
+
+// evaluateValidationError checks that err failed exactly the given fields.
+func evaluateValidationError(t *testing.T, id string, err error, fields ...string) {
+	var fail *shared.ValidationError
+	assert.ErrorAs(t, err, &fail)
+
+	if fail != nil {
+		assert.Equal(t, id, fail.Id)
+
+		failed := make([]string, 0, len(fail.Details))
+		for field := range fail.Details {
+			failed = append(failed, field)
+		}
+
+		assert.ElementsMatch(t, fields, failed)
+	}
+}
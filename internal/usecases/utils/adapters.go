@@ -32,6 +32,36 @@ func (r *MockRedis) GetAndDelValue(key adapters.RedisKey) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (r *MockRedis) DelValue(keys ...adapters.RedisKey) error {
+	args := r.Called(keys)
+	return args.Error(0)
+}
+
+func (r *MockRedis) Increment(key adapters.RedisKey, expiration time.Duration) (int64, error) {
+	args := r.Called(key, expiration)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *MockRedis) TimeToLive(key adapters.RedisKey) (time.Duration, error) {
+	args := r.Called(key)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (r *MockRedis) AddToSet(key adapters.RedisKey, expiration time.Duration, members ...string) error {
+	args := r.Called(key, expiration, members)
+	return args.Error(0)
+}
+
+func (r *MockRedis) GetSetMembers(key adapters.RedisKey) ([]string, error) {
+	args := r.Called(key)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (r *MockRedis) RemoveFromSet(key adapters.RedisKey, members ...string) error {
+	args := r.Called(key, members)
+	return args.Error(0)
+}
+
 func (r *MockRedis) Close() error {
 	args := r.Called()
 	return args.Error(0)
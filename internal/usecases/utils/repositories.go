@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"time"
+
 	interfaces "github.com/BeatEcoprove/identityService/pkg/domain"
 	"gorm.io/gorm"
 
@@ -26,6 +28,26 @@ type (
 	MockProfileRepository struct {
 		MockRepositoryBase[*domain.Profile]
 	}
+
+	MockOAuthClientRepository struct {
+		MockRepositoryBase[*domain.OAuthClient]
+	}
+
+	MockRecoveryCodeRepository struct {
+		MockRepositoryBase[*domain.RecoveryCode]
+	}
+
+	MockWebAuthnCredentialRepository struct {
+		MockRepositoryBase[*domain.WebAuthnCredential]
+	}
+
+	MockExternalIdentityRepository struct {
+		MockRepositoryBase[*domain.ExternalIdentity]
+	}
+
+	MockPasswordHistoryRepository struct {
+		MockRepositoryBase[*domain.PasswordHistory]
+	}
 )
 
 func (tran *MockTransaction) Rollback() error {
@@ -82,6 +104,16 @@ func (repo *MockAuthRepository) GetUserByEmail(email string) (*domain.IdentityUs
 	return args.Get(0).(*domain.IdentityUser), args.Error(1)
 }
 
+func (repo *MockAuthRepository) GetDeletionsRequestedBefore(before time.Time) ([]domain.IdentityUser, error) {
+	args := repo.Called(before)
+	return args.Get(0).([]domain.IdentityUser), args.Error(1)
+}
+
+func (repo *MockAuthRepository) Purge(identityUser *domain.IdentityUser) error {
+	args := repo.Called(identityUser)
+	return args.Error(0)
+}
+
 func (repo *MockProfileRepository) IsProfileFromUserId(authId, profileId string) bool {
 	args := repo.Called(authId, profileId)
 	return args.Bool(0)
@@ -96,3 +128,73 @@ func (repo *MockProfileRepository) GetAttachProfiles(authId string) ([]domain.Pr
 	args := repo.Called(authId)
 	return args.Get(0).([]domain.Profile), args.Error(1)
 }
+
+func (repo *MockOAuthClientRepository) ExistsClientWithClientId(clientID string) bool {
+	args := repo.Called(clientID)
+	return args.Bool(0)
+}
+
+func (repo *MockOAuthClientRepository) GetByClientId(clientID string) (*domain.OAuthClient, error) {
+	args := repo.Called(clientID)
+	return args.Get(0).(*domain.OAuthClient), args.Error(1)
+}
+
+func (repo *MockRecoveryCodeRepository) GetUnusedByAuthId(authID string) ([]domain.RecoveryCode, error) {
+	args := repo.Called(authID)
+	return args.Get(0).([]domain.RecoveryCode), args.Error(1)
+}
+
+func (repo *MockRecoveryCodeRepository) MarkUsed(id string) (bool, error) {
+	args := repo.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (repo *MockRecoveryCodeRepository) ReplaceForAuthId(authID string, codes []*domain.RecoveryCode) error {
+	args := repo.Called(authID, codes)
+	return args.Error(0)
+}
+
+func (repo *MockRecoveryCodeRepository) DeleteByAuthId(authID string) error {
+	args := repo.Called(authID)
+	return args.Error(0)
+}
+
+func (repo *MockWebAuthnCredentialRepository) GetByAuthId(authID string) ([]domain.WebAuthnCredential, error) {
+	args := repo.Called(authID)
+	return args.Get(0).([]domain.WebAuthnCredential), args.Error(1)
+}
+
+func (repo *MockWebAuthnCredentialRepository) ExistsCredentialWithId(credentialID []byte) bool {
+	args := repo.Called(credentialID)
+	return args.Bool(0)
+}
+
+func (repo *MockExternalIdentityRepository) GetByProviderSubject(provider, subject string) (*domain.ExternalIdentity, error) {
+	args := repo.Called(provider, subject)
+	return args.Get(0).(*domain.ExternalIdentity), args.Error(1)
+}
+
+func (repo *MockExternalIdentityRepository) GetByAuthId(authID string) ([]domain.ExternalIdentity, error) {
+	args := repo.Called(authID)
+	return args.Get(0).([]domain.ExternalIdentity), args.Error(1)
+}
+
+func (repo *MockExternalIdentityRepository) Unlink(identity *domain.ExternalIdentity) error {
+	args := repo.Called(identity)
+	return args.Error(0)
+}
+
+func (repo *MockPasswordHistoryRepository) GetRecentByAuthId(authID string, limit int) ([]domain.PasswordHistory, error) {
+	args := repo.Called(authID, limit)
+	return args.Get(0).([]domain.PasswordHistory), args.Error(1)
+}
+
+func (repo *MockPasswordHistoryRepository) Prune(authID string, keep int) error {
+	args := repo.Called(authID, keep)
+	return args.Error(0)
+}
+
+func (repo *MockPasswordHistoryRepository) DeleteByAuthId(authID string) error {
+	args := repo.Called(authID)
+	return args.Error(0)
+}
@@ -0,0 +1,58 @@
+package usecases
+
+import (
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+)
+
+type (
+	// input
+	VerifyEmailInput struct {
+		Token string
+	}
+
+	VerifyEmailUseCase struct {
+		authRepo            repositories.IAuthRepository
+		verificationService services.IEmailVerificationService
+	}
+)
+
+func NewVerifyEmailUseCase(
+	authRepo repositories.IAuthRepository,
+	verificationService services.IEmailVerificationService,
+) *VerifyEmailUseCase {
+	return &VerifyEmailUseCase{
+		authRepo:            authRepo,
+		verificationService: verificationService,
+	}
+}
+
+// Handle confirms the email the token was sent to, the email_verified claim
+// follows on the next tokens issued to the account.
+func (veu *VerifyEmailUseCase) Handle(request VerifyEmailInput) (*contracts.GenericResponse, error) {
+	authID, err := veu.verificationService.ConsumeToken(request.Token)
+
+	if err != nil {
+		return nil, fails.INVALID_VERIFICATION_TOKEN
+	}
+
+	identityUser, err := veu.authRepo.Get(authID)
+
+	if err != nil {
+		return nil, fails.USER_NOT_FOUND
+	}
+
+	if !identityUser.EmailVerified {
+		identityUser.VerifyEmail()
+
+		if err := veu.authRepo.Update(identityUser); err != nil {
+			return nil, fails.InternalServerError()
+		}
+	}
+
+	return &contracts.GenericResponse{
+		Message: "The email was verified with success.",
+	}, nil
+}
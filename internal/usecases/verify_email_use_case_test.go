@@ -0,0 +1,113 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getUnverifiedUser(t *testing.T) *domain.IdentityUser {
+	var input InputFaker
+	generateFakeData(&input)
+
+	identityUser, err := getIdentityUser(input.Email, DefaultPassword, input.Role)
+	assert.Nil(t, err)
+
+	identityUser.ID = uuid.NewString()
+	AuthRepository.On("Get", identityUser.ID).Return(identityUser, nil)
+
+	return identityUser
+}
+
+func Test_Verify_Email_UseCase(t *testing.T) {
+	InitTest()
+
+	var sut *VerifyEmailUseCase = NewVerifyEmailUseCase(
+		AuthRepository,
+		EmailVerificationService,
+	)
+
+	t.Run("Should refuse an unknown or already used token", func(t *testing.T) {
+		token := uuid.NewString()
+		Redis.On("GetAndDelValue", services.NewEmailVerificationKey(token)).Return("", nil)
+
+		// Act
+		_, err := sut.Handle(VerifyEmailInput{Token: token})
+
+		// Assert
+		evaluateError(t, fails.INVALID_VERIFICATION_TOKEN, err)
+	})
+
+	t.Run("Should verify the email the token was sent to", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		token := uuid.NewString()
+
+		Redis.On("GetAndDelValue", services.NewEmailVerificationKey(token)).Return(identityUser.ID, nil)
+		Redis.On("DelValue", []interfaces.RedisKey{services.NewUserEmailVerificationKey(identityUser.ID)}).Return(nil)
+		AuthRepository.On("Update", identityUser).Return(nil)
+
+		// Act
+		response, err := sut.Handle(VerifyEmailInput{Token: token})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotNil(t, response)
+		assert.True(t, identityUser.EmailVerified)
+		AuthRepository.AssertCalled(t, "Update", identityUser)
+	})
+}
+
+func Test_Resend_Verification_Email_UseCase(t *testing.T) {
+	InitTest()
+
+	var sut *ResendVerificationEmailUseCase = NewResendVerificationEmailUseCase(
+		AuthRepository,
+		EmailVerificationService,
+		EmailService,
+	)
+
+	t.Run("Should not resend the email of a verified account", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		identityUser.VerifyEmail()
+
+		// Act
+		_, err := sut.Handle(ResendVerificationEmailInput{AuthId: identityUser.ID})
+
+		// Assert
+		evaluateError(t, fails.EMAIL_ALREADY_VERIFIED, err)
+	})
+
+	t.Run("Should send a new token and invalidate the previous one", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		previousHash := "previous-token-hash"
+
+		Redis.On("GetAndDelValue", services.NewUserEmailVerificationKey(identityUser.ID)).Return(previousHash, nil)
+		Redis.On("DelValue", mock.Anything).Return(nil)
+		Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		RabbitMq.On("Publish", mock.Anything).Return(nil)
+
+		// Act
+		response, err := sut.Handle(ResendVerificationEmailInput{AuthId: identityUser.ID})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotNil(t, response)
+
+		email, err := EmailService.Last()
+		assert.Nil(t, err)
+		assert.Equal(t, identityUser.Email, email.To)
+		assert.Equal(t, "confirm-account", email.Template.ID)
+
+		token := email.Template.Paramters["token"]
+		assert.NotEmpty(t, token)
+
+		Redis.AssertCalled(t, "DelValue", []interfaces.RedisKey{interfaces.NewRedisKey("email_verification", previousHash)})
+		Redis.AssertCalled(t, "SetValue", services.NewEmailVerificationKey(token), identityUser.ID, services.EmailVerificationLifetime)
+	})
+}
@@ -0,0 +1,240 @@
+package usecases
+
+import (
+	"bytes"
+	"errors"
+	"log"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/internal/repositories"
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+type (
+	// input
+	BeginWebAuthnRegistrationInput struct {
+		AuthId string
+	}
+
+	// input
+	FinishWebAuthnRegistrationInput struct {
+		AuthId     string
+		Name       string
+		Credential []byte
+	}
+
+	// input
+	WebAuthnInput struct {
+		Credential []byte
+		Nonce      string
+		Device     services.DeviceInfo
+	}
+
+	BeginWebAuthnRegistrationUseCase struct {
+		authRepo       repositories.IAuthRepository
+		credentialRepo repositories.IWebAuthnCredentialRepository
+		webAuthn       services.IWebAuthnService
+	}
+
+	FinishWebAuthnRegistrationUseCase struct {
+		authRepo       repositories.IAuthRepository
+		credentialRepo repositories.IWebAuthnCredentialRepository
+		webAuthn       services.IWebAuthnService
+	}
+
+	BeginWebAuthnLoginUseCase struct {
+		webAuthn services.IWebAuthnService
+	}
+
+	WebAuthnUseCase struct {
+		authRepo       repositories.IAuthRepository
+		profileRepo    repositories.IProfileRepository
+		credentialRepo repositories.IWebAuthnCredentialRepository
+		tokenService   services.ITokenService
+		webAuthn       services.IWebAuthnService
+	}
+)
+
+var errWebAuthnUserNotFound = errors.New("webauthn user not found")
+
+func NewBeginWebAuthnRegistrationUseCase(
+	authRepo repositories.IAuthRepository,
+	credentialRepo repositories.IWebAuthnCredentialRepository,
+	webAuthn services.IWebAuthnService,
+) *BeginWebAuthnRegistrationUseCase {
+	return &BeginWebAuthnRegistrationUseCase{
+		authRepo:       authRepo,
+		credentialRepo: credentialRepo,
+		webAuthn:       webAuthn,
+	}
+}
+
+func NewFinishWebAuthnRegistrationUseCase(
+	authRepo repositories.IAuthRepository,
+	credentialRepo repositories.IWebAuthnCredentialRepository,
+	webAuthn services.IWebAuthnService,
+) *FinishWebAuthnRegistrationUseCase {
+	return &FinishWebAuthnRegistrationUseCase{
+		authRepo:       authRepo,
+		credentialRepo: credentialRepo,
+		webAuthn:       webAuthn,
+	}
+}
+
+func NewBeginWebAuthnLoginUseCase(
+	webAuthn services.IWebAuthnService,
+) *BeginWebAuthnLoginUseCase {
+	return &BeginWebAuthnLoginUseCase{
+		webAuthn: webAuthn,
+	}
+}
+
+func NewWebAuthnUseCase(
+	authRepo repositories.IAuthRepository,
+	profileRepo repositories.IProfileRepository,
+	credentialRepo repositories.IWebAuthnCredentialRepository,
+	tokenService services.ITokenService,
+	webAuthn services.IWebAuthnService,
+) *WebAuthnUseCase {
+	return &WebAuthnUseCase{
+		authRepo:       authRepo,
+		profileRepo:    profileRepo,
+		credentialRepo: credentialRepo,
+		tokenService:   tokenService,
+		webAuthn:       webAuthn,
+	}
+}
+
+// getWebAuthnUser loads the account together with its passkeys, the
+// ceremonies check credentials against them.
+func getWebAuthnUser(
+	authRepo repositories.IAuthRepository,
+	credentialRepo repositories.IWebAuthnCredentialRepository,
+	authID string,
+) (*domain.WebAuthnUser, []domain.WebAuthnCredential, error) {
+	identityUser, err := authRepo.Get(authID)
+
+	if err != nil {
+		return nil, nil, fails.USER_NOT_FOUND
+	}
+
+	credentials, err := credentialRepo.GetByAuthId(identityUser.ID)
+
+	if err != nil {
+		return nil, nil, fails.InternalServerError()
+	}
+
+	return domain.NewWebAuthnUser(identityUser, credentials), credentials, nil
+}
+
+// Handle returns the options for navigator.credentials.create, the passkeys
+// already registered are excluded.
+func (bru *BeginWebAuthnRegistrationUseCase) Handle(request BeginWebAuthnRegistrationInput) (*protocol.CredentialCreation, error) {
+	user, _, err := getWebAuthnUser(bru.authRepo, bru.credentialRepo, request.AuthId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	creation, err := bru.webAuthn.BeginRegistration(user)
+
+	if err != nil {
+		log.Printf("failed to begin webauthn registration %s", err.Error())
+		return nil, fails.InternalServerError()
+	}
+
+	return creation, nil
+}
+
+func (fru *FinishWebAuthnRegistrationUseCase) Handle(request FinishWebAuthnRegistrationInput) (*contracts.WebAuthnCredentialResponse, error) {
+	user, _, err := getWebAuthnUser(fru.authRepo, fru.credentialRepo, request.AuthId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := fru.webAuthn.FinishRegistration(user, request.Credential)
+
+	if err != nil {
+		if err == services.ErrMissingWebAuthnConfig {
+			return nil, fails.InternalServerError()
+		}
+
+		return nil, fails.INVALID_WEBAUTHN_REGISTRATION
+	}
+
+	if fru.credentialRepo.ExistsCredentialWithId(credential.ID) {
+		return nil, fails.WEBAUTHN_CREDENTIAL_EXISTS
+	}
+
+	webAuthnCredential := domain.NewWebAuthnCredential(request.AuthId, request.Name, credential)
+
+	if err := fru.credentialRepo.Create(webAuthnCredential); err != nil {
+		return nil, fails.InternalServerError()
+	}
+
+	return &contracts.WebAuthnCredentialResponse{
+		ID:        webAuthnCredential.ID,
+		Name:      webAuthnCredential.Name,
+		CreatedAt: webAuthnCredential.CreatedAt,
+	}, nil
+}
+
+// Handle returns the options for navigator.credentials.get, no username is
+// needed as passkeys carry the user handle.
+func (blu *BeginWebAuthnLoginUseCase) Handle() (*protocol.CredentialAssertion, error) {
+	assertion, err := blu.webAuthn.BeginLogin()
+
+	if err != nil {
+		log.Printf("failed to begin webauthn login %s", err.Error())
+		return nil, fails.InternalServerError()
+	}
+
+	return assertion, nil
+}
+
+// Handle verifies a passkey assertion and issues the same tokens as a
+// password login. The passkey verified the user on the device, so no second
+// factor is asked for.
+func (wu *WebAuthnUseCase) Handle(input WebAuthnInput) (*contracts.AuthResponse, error) {
+	var user *domain.WebAuthnUser
+	var credentials []domain.WebAuthnCredential
+
+	credential, err := wu.webAuthn.FinishLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		found, stored, err := getWebAuthnUser(wu.authRepo, wu.credentialRepo, string(userHandle))
+
+		if err != nil {
+			return nil, errWebAuthnUserNotFound
+		}
+
+		user, credentials = found, stored
+		return user, nil
+	}, input.Credential)
+
+	if err != nil {
+		return nil, fails.WEBAUTHN_AUTH_FAILED
+	}
+
+	if credential.Authenticator.CloneWarning {
+		log.Printf("webauthn signature counter went backwards for %s", user.User().ID)
+		return nil, fails.WEBAUTHN_AUTH_FAILED
+	}
+
+	for i := range credentials {
+		if !bytes.Equal(credentials[i].CredentialID, credential.ID) {
+			continue
+		}
+
+		credentials[i].MarkUsed(credential.Authenticator, credential.Flags)
+
+		if err := wu.credentialRepo.Update(&credentials[i]); err != nil {
+			return nil, fails.InternalServerError()
+		}
+	}
+
+	return issueTokens(wu.profileRepo, wu.tokenService, user.User(), input.Device, "", input.Nonce)
+}
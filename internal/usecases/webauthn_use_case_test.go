@@ -0,0 +1,305 @@
+package usecases
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/BeatEcoprove/identityService/internal/domain"
+	"github.com/BeatEcoprove/identityService/pkg/adapters"
+	fails "github.com/BeatEcoprove/identityService/pkg/errors"
+	"github.com/BeatEcoprove/identityService/pkg/services"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testRPID     = "beat.pt"
+	testRPOrigin = "https://beat.pt"
+
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+// softAuthenticator plays the part of a platform authenticator holding one
+// passkey.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	counter      uint32
+}
+
+func newSoftAuthenticator(t *testing.T, userHandle string) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	assert.Nil(t, err)
+
+	return &softAuthenticator{
+		key:          key,
+		credentialID: credentialID,
+		userHandle:   []byte(userHandle),
+	}
+}
+
+func (a *softAuthenticator) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.counter)
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremony string, challenge protocol.URLEncodedBase64) []byte {
+	clientData, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge.String(),
+		"origin":    testRPOrigin,
+	})
+	assert.Nil(t, err)
+
+	return clientData
+}
+
+func (a *softAuthenticator) publicKey() webauthncose.EC2PublicKeyData {
+	return webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1,
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	}
+}
+
+func (a *softAuthenticator) create(t *testing.T, creation *protocol.CredentialCreation) []byte {
+	publicKey, err := webauthncbor.Marshal(a.publicKey())
+	assert.Nil(t, err)
+
+	authData := a.authData(flagUserPresent | flagUserVerified | flagAttestedData)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, publicKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+	assert.Nil(t, err)
+
+	return a.credential(t, map[string]string{
+		"clientDataJSON":    encodeWebAuthn(a.clientData(t, "webauthn.create", creation.Response.Challenge)),
+		"attestationObject": encodeWebAuthn(attestationObject),
+	})
+}
+
+func (a *softAuthenticator) get(t *testing.T, assertion *protocol.CredentialAssertion) []byte {
+	a.counter++
+
+	clientData := a.clientData(t, "webauthn.get", assertion.Response.Challenge)
+	authData := a.authData(flagUserPresent | flagUserVerified)
+	clientDataHash := sha256.Sum256(clientData)
+	signedData := sha256.Sum256(append(authData, clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, signedData[:])
+	assert.Nil(t, err)
+
+	return a.credential(t, map[string]string{
+		"clientDataJSON":    encodeWebAuthn(clientData),
+		"authenticatorData": encodeWebAuthn(authData),
+		"signature":         encodeWebAuthn(signature),
+		"userHandle":        encodeWebAuthn(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) credential(t *testing.T, response map[string]string) []byte {
+	credential, err := json.Marshal(map[string]interface{}{
+		"id":       encodeWebAuthn(a.credentialID),
+		"rawId":    encodeWebAuthn(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	assert.Nil(t, err)
+
+	return credential
+}
+
+func encodeWebAuthn(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+// stored is the passkey as the server keeps it after the registration.
+func (a *softAuthenticator) stored(t *testing.T, authID string) domain.WebAuthnCredential {
+	publicKey, err := webauthncbor.Marshal(a.publicKey())
+	assert.Nil(t, err)
+
+	return *domain.NewWebAuthnCredential(authID, "Phone", &webauthn.Credential{
+		ID:              a.credentialID,
+		PublicKey:       publicKey,
+		AttestationType: "none",
+	})
+}
+
+// replayWebAuthnSession hands the session stored by the begin step to the
+// finish step.
+func replayWebAuthnSession(key adapters.RedisKey) {
+	for _, call := range Redis.Calls {
+		if call.Method == "SetValue" && call.Arguments.Get(0).(adapters.RedisKey).Key == key.Key {
+			Redis.On("GetAndDelValue", key).Return(call.Arguments.String(1), nil).Once()
+		}
+	}
+}
+
+func Test_WebAuthn_UseCase(t *testing.T) {
+	t.Setenv("WEBAUTHN_RP_ID", testRPID)
+	t.Setenv("WEBAUTHN_RP_ORIGINS", testRPOrigin)
+	t.Setenv("JWT_ACCESS_EXPIRED", "10")
+	t.Setenv("JWT_REFRESH_EXPIRED", "1")
+	InitTest()
+
+	beginRegistration := NewBeginWebAuthnRegistrationUseCase(AuthRepository, WebAuthnCredentialRepository, WebAuthnService)
+	finishRegistration := NewFinishWebAuthnRegistrationUseCase(AuthRepository, WebAuthnCredentialRepository, WebAuthnService)
+	beginLogin := NewBeginWebAuthnLoginUseCase(WebAuthnService)
+
+	var sut *WebAuthnUseCase = NewWebAuthnUseCase(
+		AuthRepository,
+		ProfileRepository,
+		WebAuthnCredentialRepository,
+		TokenService,
+		WebAuthnService,
+	)
+
+	Redis.On("SetValue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	WebAuthnCredentialRepository.On("Create").Return(nil)
+	WebAuthnCredentialRepository.On("Update", mock.Anything).Return(nil)
+
+	t.Run("Should register a passkey created for the registration challenge", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		authenticator := newSoftAuthenticator(t, identityUser.ID)
+
+		WebAuthnCredentialRepository.On("GetByAuthId", identityUser.ID).Return([]domain.WebAuthnCredential{}, nil)
+		WebAuthnCredentialRepository.On("ExistsCredentialWithId", authenticator.credentialID).Return(false).Once()
+
+		creation, err := beginRegistration.Handle(BeginWebAuthnRegistrationInput{AuthId: identityUser.ID})
+		assert.Nil(t, err)
+		assert.Equal(t, testRPID, creation.Response.RelyingParty.ID)
+		assert.Equal(t, protocol.VerificationRequired, creation.Response.AuthenticatorSelection.UserVerification)
+
+		replayWebAuthnSession(services.NewWebAuthnRegistrationKey(identityUser.ID))
+
+		// Act
+		response, err := finishRegistration.Handle(FinishWebAuthnRegistrationInput{
+			AuthId:     identityUser.ID,
+			Name:       "Phone",
+			Credential: authenticator.create(t, creation),
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.ID)
+		assert.Equal(t, "Phone", response.Name)
+		WebAuthnCredentialRepository.AssertCalled(t, "Create")
+	})
+
+	t.Run("Should refuse a registration without a pending challenge", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		authenticator := newSoftAuthenticator(t, identityUser.ID)
+
+		WebAuthnCredentialRepository.On("GetByAuthId", identityUser.ID).Return([]domain.WebAuthnCredential{}, nil)
+		Redis.On("GetAndDelValue", services.NewWebAuthnRegistrationKey(identityUser.ID)).Return("", nil).Once()
+
+		// Act
+		_, err := finishRegistration.Handle(FinishWebAuthnRegistrationInput{
+			AuthId: identityUser.ID,
+			Credential: authenticator.create(t, &protocol.CredentialCreation{
+				Response: protocol.PublicKeyCredentialCreationOptions{Challenge: []byte("not-issued")},
+			}),
+		})
+
+		// Assert
+		evaluateError(t, fails.INVALID_WEBAUTHN_REGISTRATION, err)
+	})
+
+	t.Run("Should issue tokens for a passkey assertion and keep the signature counter", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		authenticator := newSoftAuthenticator(t, identityUser.ID)
+
+		WebAuthnCredentialRepository.On("GetByAuthId", identityUser.ID).Return([]domain.WebAuthnCredential{authenticator.stored(t, identityUser.ID)}, nil)
+		Redis.On("GetValue", mock.Anything).Return("", errors.ErrUnsupported).Once()
+		Redis.On("AddToSet", services.NewSessionsKey(identityUser.ID), mock.Anything, mock.Anything).Return(nil)
+		ProfileRepository.On("GetAttachProfiles", identityUser.ID).Return([]domain.Profile{*domain.NewProfile(identityUser.ID, domain.Main)}, nil)
+
+		assertion, err := beginLogin.Handle()
+		assert.Nil(t, err)
+
+		replayWebAuthnSession(services.NewWebAuthnLoginKey(assertion.Response.Challenge.String()))
+
+		// Act
+		response, err := sut.Handle(WebAuthnInput{
+			Credential: authenticator.get(t, assertion),
+			Nonce:      "n-0S6_WzA2Mj",
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+		assert.Equal(t, "n-0S6_WzA2Mj", getIDTokenClaims(t, response.IDToken).Nonce)
+		WebAuthnCredentialRepository.AssertCalled(t, "Update", mock.MatchedBy(func(credential *domain.WebAuthnCredential) bool {
+			return credential.AuthID == identityUser.ID && credential.SignCount == 1 && credential.LastUsedAt != nil
+		}))
+	})
+
+	t.Run("Should refuse an assertion signed by another key", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		authenticator := newSoftAuthenticator(t, identityUser.ID)
+		impostor := newSoftAuthenticator(t, identityUser.ID)
+		impostor.credentialID = authenticator.credentialID
+
+		WebAuthnCredentialRepository.On("GetByAuthId", identityUser.ID).Return([]domain.WebAuthnCredential{authenticator.stored(t, identityUser.ID)}, nil)
+
+		assertion, err := beginLogin.Handle()
+		assert.Nil(t, err)
+
+		replayWebAuthnSession(services.NewWebAuthnLoginKey(assertion.Response.Challenge.String()))
+
+		// Act
+		_, err = sut.Handle(WebAuthnInput{Credential: impostor.get(t, assertion)})
+
+		// Assert
+		evaluateError(t, fails.WEBAUTHN_AUTH_FAILED, err)
+	})
+
+	t.Run("Should refuse an assertion for a challenge that was not issued", func(t *testing.T) {
+		identityUser := getUnverifiedUser(t)
+		authenticator := newSoftAuthenticator(t, identityUser.ID)
+		challenge := protocol.URLEncodedBase64("not-issued")
+
+		Redis.On("GetAndDelValue", services.NewWebAuthnLoginKey(challenge.String())).Return("", nil).Once()
+
+		// Act
+		_, err := sut.Handle(WebAuthnInput{
+			Credential: authenticator.get(t, &protocol.CredentialAssertion{
+				Response: protocol.PublicKeyCredentialRequestOptions{Challenge: challenge},
+			}),
+		})
+
+		// Assert
+		evaluateError(t, fails.WEBAUTHN_AUTH_FAILED, err)
+	})
+}
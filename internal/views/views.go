@@ -0,0 +1,26 @@
+package views
+
+import (
+	"embed"
+	"html/template"
+
+	"github.com/BeatEcoprove/identityService/pkg/contracts"
+)
+
+type (
+	AuthorizeView struct {
+		Action  string
+		Request contracts.AuthorizeRequest
+		Email   string
+		Error   string
+
+		// MFAToken is set once the password passed and the account asks
+		// for a second factor
+		MFAToken string
+	}
+)
+
+//go:embed *.html
+var files embed.FS
+
+var Authorize = template.Must(template.ParseFS(files, "authorize.html"))
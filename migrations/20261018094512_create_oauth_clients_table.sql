@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+create table oauth_clients(
+    id uuid not null,
+    client_id varchar(100) not null,
+    secret text not null,
+    scopes text default '',
+    redirect_uris text default '',
+    created_at timestamp default now(),
+    updated_at timestamp default now(),
+    deleted_at timestamp default null,
+    primary key (id),
+    CONSTRAINT uq_client_id UNIQUE (client_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table oauth_clients;
+-- +goose StatementEnd
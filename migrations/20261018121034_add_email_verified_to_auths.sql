@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table auths add column email_verified boolean default false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table auths drop column email_verified;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table auths add column totp_secret text not null default '';
+alter table auths add column totp_enabled boolean not null default false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table auths drop column totp_enabled;
+alter table auths drop column totp_secret;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+create table recovery_codes(
+    id uuid not null,
+    auth_id uuid not null,
+    code text not null,
+    used_at timestamp default null,
+    created_at timestamp default now(),
+    updated_at timestamp default now(),
+    deleted_at timestamp default null,
+    primary key (id),
+    CONSTRAINT recovery_codes_auth_id_fk
+    FOREIGN KEY (auth_id)
+    REFERENCES auths (id)
+);
+
+create index idx_recovery_codes_auth_id on recovery_codes (auth_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table recovery_codes;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+create table webauthn_credentials(
+    id uuid not null,
+    auth_id uuid not null,
+    credential_id bytea not null,
+    public_key bytea not null,
+    attestation_type text not null default '',
+    transports text not null default '',
+    aaguid bytea,
+    sign_count bigint not null default 0,
+    backup_eligible boolean not null default false,
+    backup_state boolean not null default false,
+    name text not null default '',
+    last_used_at timestamp default null,
+    created_at timestamp default now(),
+    updated_at timestamp default now(),
+    deleted_at timestamp default null,
+    primary key (id),
+    CONSTRAINT webauthn_credentials_auth_id_fk
+    FOREIGN KEY (auth_id)
+    REFERENCES auths (id)
+);
+
+create unique index idx_webauthn_credentials_credential_id on webauthn_credentials (credential_id);
+create index idx_webauthn_credentials_auth_id on webauthn_credentials (auth_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table webauthn_credentials;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+create table external_identities(
+    id uuid not null,
+    auth_id uuid not null,
+    provider text not null,
+    subject text not null,
+    email text not null default '',
+    created_at timestamp default now(),
+    updated_at timestamp default now(),
+    deleted_at timestamp default null,
+    primary key (id),
+    CONSTRAINT external_identities_auth_id_fk
+    FOREIGN KEY (auth_id)
+    REFERENCES auths (id)
+);
+
+create unique index idx_external_identities_provider_subject on external_identities (provider, subject);
+create index idx_external_identities_auth_id on external_identities (auth_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table external_identities;
+-- +goose StatementEnd
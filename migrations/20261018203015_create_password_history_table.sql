@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+create table password_history(
+    id uuid not null,
+    auth_id uuid not null,
+    password text not null,
+    salt text not null,
+    created_at timestamp default now(),
+    updated_at timestamp default now(),
+    deleted_at timestamp default null,
+    primary key (id),
+    CONSTRAINT password_history_auth_id_fk
+    FOREIGN KEY (auth_id)
+    REFERENCES auths (id)
+    ON DELETE CASCADE
+);
+
+create index idx_password_history_auth_id on password_history (auth_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table password_history;
+-- +goose StatementEnd
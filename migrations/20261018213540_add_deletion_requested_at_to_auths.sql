@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table auths add column deletion_requested_at timestamp default null;
+
+create index idx_auths_deletion_requested_at on auths (deletion_requested_at) where deletion_requested_at is not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index idx_auths_deletion_requested_at;
+alter table auths drop column deletion_requested_at;
+-- +goose StatementEnd
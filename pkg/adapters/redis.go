@@ -20,6 +20,12 @@ type (
 		GetValue(key RedisKey) (string, error)
 		SetValue(key RedisKey, value interface{}, expiration time.Duration) error
 		GetAndDelValue(key RedisKey) (string, error)
+		DelValue(keys ...RedisKey) error
+		Increment(key RedisKey, expiration time.Duration) (int64, error)
+		TimeToLive(key RedisKey) (time.Duration, error)
+		AddToSet(key RedisKey, expiration time.Duration, members ...string) error
+		GetSetMembers(key RedisKey) ([]string, error)
+		RemoveFromSet(key RedisKey, members ...string) error
 		Close() error
 	}
 
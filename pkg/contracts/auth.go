@@ -1,5 +1,10 @@
 package contracts
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type (
 	CheckEmailFieldRequest struct {
 		Email string `validate:"required,email"`
@@ -9,10 +14,14 @@ type (
 		Email string `json:"email" validate:"required,email"`
 	}
 
+	VerifyEmailRequest struct {
+		Token string `json:"token" validate:"required"`
+	}
+
 	ResetPasswordRequest struct {
 		Email    string `json:"email" validate:"required,email"`
 		Code     string `json:"code"`
-		Password string `json:"password" validate:"required,min=8"`
+		Password string `json:"password" validate:"required"`
 	}
 
 	ProfileResponse struct {
@@ -36,7 +45,8 @@ type (
 	LoginRequest struct {
 		TokenRequest
 		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"required,min=8"`
+		Password string `json:"password" validate:"required"`
+		Nonce    string `json:"nonce" form:"nonce"`
 	}
 
 	RefreshTokenRequest struct {
@@ -45,18 +55,192 @@ type (
 		ProfileID string `json:"profile_id" form:"profile_id" validate:"omitempty,uuid"`
 	}
 
+	AuthorizationCodeRequest struct {
+		TokenRequest
+		Code         string `json:"code" form:"code" validate:"required"`
+		RedirectURI  string `json:"redirect_uri" form:"redirect_uri" validate:"required"`
+		ClientID     string `json:"client_id" form:"client_id" validate:"required"`
+		CodeVerifier string `json:"code_verifier" form:"code_verifier" validate:"required,min=43,max=128"`
+	}
+
+	ClientCredentialsRequest struct {
+		TokenRequest
+		ClientID     string `json:"client_id" form:"client_id"`
+		ClientSecret string `json:"client_secret" form:"client_secret"`
+		Scope        string `json:"scope" form:"scope"`
+	}
+
+	MFAOTPRequest struct {
+		TokenRequest
+		MFAToken     string `json:"mfa_token" form:"mfa_token" validate:"required"`
+		OTP          string `json:"otp" form:"otp" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+		RecoveryCode string `json:"recovery_code" form:"recovery_code" validate:"required_without=OTP"`
+	}
+
+	ChangePasswordRequest struct {
+		CurrentPassword string `json:"current_password" validate:"required"`
+		NewPassword     string `json:"new_password" validate:"required"`
+	}
+
+	// DeleteAccountRequest is optional, a session that signed in a moment
+	// ago doesn't need to send the password again
+	DeleteAccountRequest struct {
+		Password string `json:"password"`
+	}
+
+	// RestoreAccountRequest takes the credentials first, accounts with a
+	// second factor send the mfa_token they got back with a code
+	RestoreAccountRequest struct {
+		Email        string `json:"email" validate:"required_without=MFAToken,omitempty,email"`
+		Password     string `json:"password" validate:"required_without=MFAToken"`
+		MFAToken     string `json:"mfa_token"`
+		OTP          string `json:"otp" validate:"omitempty,len=6,numeric"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	SendLoginCodeRequest struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	LoginCodeRequest struct {
+		TokenRequest
+		Email string `json:"email" form:"email" validate:"required,email"`
+		Code  string `json:"code" form:"code" validate:"required"`
+		Nonce string `json:"nonce" form:"nonce"`
+	}
+
+	// FederatedLoginRequest carries the id token an external provider issued
+	// to the client, provider_nonce is the nonce the client sent it.
+	FederatedLoginRequest struct {
+		TokenRequest
+		Provider      string `json:"provider" form:"provider" validate:"required"`
+		IDToken       string `json:"id_token" form:"id_token" validate:"required"`
+		ProviderNonce string `json:"provider_nonce" form:"provider_nonce"`
+		Role          string `json:"role" form:"role"`
+		Nonce         string `json:"nonce" form:"nonce"`
+	}
+
+	// LinkIdentityRequest links the provider of the id token to the signed
+	// in account, password re-authenticates sessions that aren't recent.
+	LinkIdentityRequest struct {
+		Provider      string `json:"provider" validate:"required"`
+		IDToken       string `json:"id_token" validate:"required"`
+		ProviderNonce string `json:"provider_nonce"`
+		Password      string `json:"password"`
+	}
+
+	ExternalIdentityResponse struct {
+		ID        string    `json:"id"`
+		Provider  string    `json:"provider"`
+		Email     string    `json:"email"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	ExternalIdentitiesResponse struct {
+		Identities []ExternalIdentityResponse `json:"identities"`
+	}
+
+	// WebAuthnRequest carries the PublicKeyCredential of a passkey login as
+	// the browser returned it from navigator.credentials.get.
+	WebAuthnRequest struct {
+		TokenRequest
+		Credential json.RawMessage `json:"credential" validate:"required"`
+		Nonce      string          `json:"nonce"`
+	}
+
+	// WebAuthnRegistrationRequest carries the PublicKeyCredential returned by
+	// navigator.credentials.create.
+	WebAuthnRegistrationRequest struct {
+		Name       string          `json:"name" validate:"max=64"`
+		Credential json.RawMessage `json:"credential" validate:"required"`
+	}
+
+	WebAuthnCredentialResponse struct {
+		ID        string    `json:"id"`
+		Name      string    `json:"name"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	OTPRequest struct {
+		OTP string `json:"otp" validate:"required,len=6,numeric"`
+	}
+
+	// RecoveryCodesResponse is the only time the codes are shown, each one
+	// can replace a totp code once.
+	RecoveryCodesResponse struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	TOTPEnrollmentResponse struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}
+
+	AuthorizeRequest struct {
+		ResponseType        string `json:"response_type" form:"response_type" query:"response_type" validate:"required"`
+		ClientID            string `json:"client_id" form:"client_id" query:"client_id" validate:"required"`
+		RedirectURI         string `json:"redirect_uri" form:"redirect_uri" query:"redirect_uri" validate:"required,url"`
+		Scope               string `json:"scope" form:"scope" query:"scope"`
+		State               string `json:"state" form:"state" query:"state"`
+		Nonce               string `json:"nonce" form:"nonce" query:"nonce"`
+		CodeChallenge       string `json:"code_challenge" form:"code_challenge" query:"code_challenge" validate:"required"`
+		CodeChallengeMethod string `json:"code_challenge_method" form:"code_challenge_method" query:"code_challenge_method"`
+	}
+
+	AuthorizeLoginRequest struct {
+		AuthorizeRequest
+		Email        string `json:"email" form:"email" validate:"required,email"`
+		Password     string `json:"password" form:"password" validate:"required"`
+		MFAToken     string `json:"mfa_token" form:"mfa_token"`
+		OTP          string `json:"otp" form:"otp"`
+		RecoveryCode string `json:"recovery_code" form:"recovery_code"`
+	}
+
+	AuthorizationResponse struct {
+		RedirectTo string `json:"redirect_to"`
+	}
+
+	RevokeTokenRequest struct {
+		Token         string `json:"token" form:"token" validate:"required"`
+		TokenTypeHint string `json:"token_type_hint" form:"token_type_hint" validate:"omitempty,oneof=access_token refresh_token"`
+	}
+
+	IntrospectTokenRequest struct {
+		Token         string `json:"token" form:"token" validate:"required"`
+		TokenTypeHint string `json:"token_type_hint" form:"token_type_hint" validate:"omitempty,oneof=access_token refresh_token"`
+	}
+
+	IntrospectionResponse struct {
+		Active        bool     `json:"active"`
+		Scope         string   `json:"scope,omitempty"`
+		TokenType     string   `json:"token_type,omitempty"`
+		Exp           int64    `json:"exp,omitempty"`
+		Iat           int64    `json:"iat,omitempty"`
+		Sub           string   `json:"sub,omitempty"`
+		Aud           []string `json:"aud,omitempty"`
+		Iss           string   `json:"iss,omitempty"`
+		Jti           string   `json:"jti,omitempty"`
+		SessionID     string   `json:"sid,omitempty"`
+		Email         string   `json:"email,omitempty"`
+		EmailVerified bool     `json:"email_verified,omitempty"`
+		ProfileID     string   `json:"profile_id,omitempty"`
+		ProfileIds    []string `json:"profile_ids,omitempty"`
+		Role          string   `json:"role,omitempty"`
+	}
+
 	SignUpRequest struct {
 		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"required,min=8"`
+		Password string `json:"password" validate:"required"`
 		Role     string `json:"role"`
 	}
 
 	AccountResponse struct {
-		UserID     string   `json:"user_id"`
-		Email      string   `json:"email"`
-		ProfileID  string   `json:"profile_id"`
-		ProfileIds []string `json:"profile_ids"`
-		Role       string   `json:"role"`
+		UserID        string   `json:"user_id"`
+		Email         string   `json:"email"`
+		EmailVerified bool     `json:"email_verified"`
+		ProfileID     string   `json:"profile_id"`
+		ProfileIds    []string `json:"profile_ids"`
+		Role          string   `json:"role"`
 	}
 
 	AuthResponse struct {
@@ -64,6 +248,7 @@ type (
 		AccessToken  string   `json:"access_token"`
 		ExpiresIn    int64    `json:"expires_in"`
 		RefreshToken string   `json:"refresh_token"`
+		IDToken      string   `json:"id_token,omitempty"`
 		Scope        []string `json:"scope,omitempty"`
 		// Custom extensions
 		// 	User      *AccountResponse `json:"user,omitempty"`
@@ -71,7 +256,35 @@ type (
 		// 	ExpiresAt int64            `json:"expires_at"` // Unix timestamp
 	}
 
+	// MachineTokenResponse has no refresh token, a client authenticates again
+	// once the token expires (RFC 6749 section 4.4.3).
+	MachineTokenResponse struct {
+		TokenType   string `json:"token_type"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+		Scope       string `json:"scope,omitempty"`
+	}
+
+	SessionResponse struct {
+		ID         string    `json:"id"`
+		Device     string    `json:"device"`
+		IP         string    `json:"ip"`
+		UserAgent  string    `json:"user_agent"`
+		CreatedAt  time.Time `json:"created_at"`
+		LastUsedAt time.Time `json:"last_used_at"`
+		Current    bool      `json:"current"`
+	}
+
+	SessionsResponse struct {
+		Sessions []SessionResponse `json:"sessions"`
+	}
+
 	GenericResponse struct {
 		Message string `json:"message"`
 	}
+
+	AccountDeletionResponse struct {
+		Message   string    `json:"message"`
+		DeletesAt time.Time `json:"deletes_at"`
+	}
 )
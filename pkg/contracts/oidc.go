@@ -0,0 +1,24 @@
+package contracts
+
+type (
+	// OpenIDConfigurationResponse is the OpenID Connect Discovery 1.0
+	// provider metadata.
+	OpenIDConfigurationResponse struct {
+		Issuer                                    string   `json:"issuer"`
+		AuthorizationEndpoint                     string   `json:"authorization_endpoint,omitempty"`
+		TokenEndpoint                             string   `json:"token_endpoint"`
+		UserinfoEndpoint                          string   `json:"userinfo_endpoint,omitempty"`
+		JwksURI                                   string   `json:"jwks_uri"`
+		RevocationEndpoint                        string   `json:"revocation_endpoint,omitempty"`
+		IntrospectionEndpoint                     string   `json:"introspection_endpoint,omitempty"`
+		ScopesSupported                           []string `json:"scopes_supported,omitempty"`
+		ResponseTypesSupported                    []string `json:"response_types_supported"`
+		GrantTypesSupported                       []string `json:"grant_types_supported,omitempty"`
+		SubjectTypesSupported                     []string `json:"subject_types_supported"`
+		IDTokenSigningAlgValuesSupported          []string `json:"id_token_signing_alg_values_supported"`
+		TokenEndpointAuthMethodsSupported         []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+		IntrospectionEndpointAuthMethodsSupported []string `json:"introspection_endpoint_auth_methods_supported,omitempty"`
+		ClaimsSupported                           []string `json:"claims_supported,omitempty"`
+		CodeChallengeMethodsSupported             []string `json:"code_challenge_methods_supported,omitempty"`
+	}
+)
@@ -22,6 +22,24 @@ var (
 		"Auth.Http.InvalidRefreshToken.Title",
 		"Auth.Http.InvalidRefreshToken.Description",
 	)
+
+	INVALID_CLIENT_CREDENTIALS = shared.NewUnauthorizedError(
+		"invalid-client-credentials",
+		"Auth.Http.InvalidClientCredentials.Title",
+		"Auth.Http.InvalidClientCredentials.Description",
+	)
+
+	INSUFFICIENT_SCOPE = shared.NewForbiddenError(
+		"insufficient-scope",
+		"Auth.Http.InsufficientScope.Title",
+		"Auth.Http.InsufficientScope.Description",
+	)
+
+	TOO_MANY_REQUESTS = shared.NewTooManyRequestsError(
+		"too-many-requests",
+		"Auth.Http.TooManyRequests.Title",
+		"Auth.Http.TooManyRequests.Description",
+	)
 )
 
 func InternalServerError() *shared.Error {
@@ -5,34 +5,16 @@ import (
 )
 
 var (
-	PASSWORD_PROVIDE = shared.NewConflitError(
-		"password-pv",
-		"Auth.Validation.ProvidePassword.Title",
-		"Auth.Validation.ProvidePassword.Description",
+	PASSWORD_BREACHED = shared.NewBadRequest(
+		"password-breached",
+		"Auth.Validation.PasswordBreached.Title",
+		"Auth.Validation.PasswordBreached.Description",
 	)
 
-	PASSWORD_BTW_6_16 = shared.NewConflitError(
-		"password-btw-6-16",
-		"Auth.Validation.PasswordBetween6And16.Title",
-		"Auth.Validation.PasswordBetween6And16.Description",
-	)
-
-	PASSWORD_MUST_CONTAIN_ONE_NUMBER = shared.NewConflitError(
-		"password-mst-number",
-		"Auth.Validation.PasswordMustContainOneNumber.Title",
-		"Auth.Validation.PasswordMustContainOneNumber.Description",
-	)
-
-	PASSWORD_MUST_CONTAIN_ONE_CAPITAL = shared.NewConflitError(
-		"password-mst-capital",
-		"Auth.Validation.PasswordMustContainOneCapital.Title",
-		"Auth.Validation.PasswordMustContainOneCapital.Description",
-	)
-
-	PASSWORD_MUST_CONTAIN_NON_CAPITAL = shared.NewConflitError(
-		"password-mst-n-capital",
-		"Auth.Validation.PasswordMustContainNonCapital.Title",
-		"Auth.Validation.PasswordMustContainNonCapital.Description",
+	PASSWORD_REUSED = shared.NewBadRequest(
+		"password-reused",
+		"Auth.Validation.PasswordReused.Title",
+		"Auth.Validation.PasswordReused.Description",
 	)
 
 	BAD_UUID = shared.NewConflitError(
@@ -47,3 +29,11 @@ var (
 		"Auth.Validation.InvalidEmail.Description",
 	)
 )
+
+func WeakPassword(violations map[string]string) *shared.ValidationError {
+	return shared.NewBadRequestError(
+		"weak-password",
+		"Auth.Validation.WeakPassword.Title",
+		violations,
+	)
+}
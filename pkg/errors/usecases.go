@@ -15,6 +15,12 @@ var (
 		"Auth.User.AuthFailed.Description",
 	)
 
+	USER_LOCKED = shared.NewLockedError(
+		"user-locked",
+		"Auth.User.Locked.Title",
+		"Auth.User.Locked.Description",
+	)
+
 	USER_NOT_FOUND = shared.NewNotFoundError(
 		"user-not-found",
 		"Auth.User.NotFound.Title",
@@ -68,4 +74,190 @@ var (
 		"Auth.Member.NotFound.Title",
 		"Auth.Member.NotFound.Description",
 	)
+
+	SESSION_NOT_FOUND = shared.NewNotFoundError(
+		"session-not-found",
+		"Auth.Session.NotFound.Title",
+		"Auth.Session.NotFound.Description",
+	)
+
+	INVALID_REDIRECT_URI = shared.NewBadRequest(
+		"invalid-redirect-uri",
+		"Auth.Authorize.InvalidRedirectUri.Title",
+		"Auth.Authorize.InvalidRedirectUri.Description",
+	)
+
+	UNKNOWN_CLIENT = shared.NewBadRequest(
+		"unknown-client",
+		"Auth.Authorize.UnknownClient.Title",
+		"Auth.Authorize.UnknownClient.Description",
+	)
+
+	INVALID_AUTHORIZE_REQUEST = shared.NewBadRequest(
+		"invalid-authorize-request",
+		"Auth.Authorize.InvalidRequest.Title",
+		"Auth.Authorize.InvalidRequest.Description",
+	)
+
+	INVALID_AUTHORIZATION_CODE = shared.NewBadRequest(
+		"invalid-authorization-code",
+		"Auth.Authorize.InvalidCode.Title",
+		"Auth.Authorize.InvalidCode.Description",
+	)
+
+	INVALID_SCOPE = shared.NewBadRequest(
+		"invalid-scope",
+		"Auth.Client.InvalidScope.Title",
+		"Auth.Client.InvalidScope.Description",
+	)
+
+	INVALID_VERIFICATION_TOKEN = shared.NewBadRequest(
+		"invalid-verification-token",
+		"Auth.Email.InvalidVerificationToken.Title",
+		"Auth.Email.InvalidVerificationToken.Description",
+	)
+
+	EMAIL_ALREADY_VERIFIED = shared.NewConflitError(
+		"email-already-verified",
+		"Auth.Email.AlreadyVerified.Title",
+		"Auth.Email.AlreadyVerified.Description",
+	)
+
+	MFA_REQUIRED = shared.NewForbiddenError(
+		"mfa-required",
+		"Auth.Mfa.Required.Title",
+		"Auth.Mfa.Required.Description",
+	)
+
+	INVALID_MFA_TOKEN = shared.NewBadRequest(
+		"invalid-mfa-token",
+		"Auth.Mfa.InvalidToken.Title",
+		"Auth.Mfa.InvalidToken.Description",
+	)
+
+	INVALID_OTP = shared.NewUnauthorizedError(
+		"invalid-otp",
+		"Auth.Mfa.InvalidOtp.Title",
+		"Auth.Mfa.InvalidOtp.Description",
+	)
+
+	OTP_ATTEMPTS_EXCEEDED = shared.NewLockedError(
+		"otp-attempts-exceeded",
+		"Auth.Mfa.OtpAttemptsExceeded.Title",
+		"Auth.Mfa.OtpAttemptsExceeded.Description",
+	)
+
+	MFA_ALREADY_ENABLED = shared.NewConflitError(
+		"mfa-already-enabled",
+		"Auth.Mfa.AlreadyEnabled.Title",
+		"Auth.Mfa.AlreadyEnabled.Description",
+	)
+
+	MFA_NOT_ENROLLED = shared.NewConflitError(
+		"mfa-not-enrolled",
+		"Auth.Mfa.NotEnrolled.Title",
+		"Auth.Mfa.NotEnrolled.Description",
+	)
+
+	INVALID_RECOVERY_CODE = shared.NewUnauthorizedError(
+		"invalid-recovery-code",
+		"Auth.Mfa.InvalidRecoveryCode.Title",
+		"Auth.Mfa.InvalidRecoveryCode.Description",
+	)
+
+	INVALID_WEBAUTHN_REGISTRATION = shared.NewBadRequest(
+		"invalid-webauthn-registration",
+		"Auth.WebAuthn.InvalidRegistration.Title",
+		"Auth.WebAuthn.InvalidRegistration.Description",
+	)
+
+	WEBAUTHN_CREDENTIAL_EXISTS = shared.NewConflitError(
+		"webauthn-credential-exists",
+		"Auth.WebAuthn.CredentialExists.Title",
+		"Auth.WebAuthn.CredentialExists.Description",
+	)
+
+	WEBAUTHN_AUTH_FAILED = shared.NewUnauthorizedError(
+		"webauthn-auth-failed",
+		"Auth.WebAuthn.AuthFailed.Title",
+		"Auth.WebAuthn.AuthFailed.Description",
+	)
+
+	INVALID_LOGIN_CODE = shared.NewUnauthorizedError(
+		"invalid-login-code",
+		"Auth.LoginCode.Invalid.Title",
+		"Auth.LoginCode.Invalid.Description",
+	)
+
+	UNKNOWN_IDENTITY_PROVIDER = shared.NewBadRequest(
+		"unknown-identity-provider",
+		"Auth.Federation.UnknownProvider.Title",
+		"Auth.Federation.UnknownProvider.Description",
+	)
+
+	INVALID_ID_TOKEN = shared.NewUnauthorizedError(
+		"invalid-id-token",
+		"Auth.Federation.InvalidIdToken.Title",
+		"Auth.Federation.InvalidIdToken.Description",
+	)
+
+	FEDERATED_EMAIL_NOT_VERIFIED = shared.NewForbiddenError(
+		"federated-email-not-verified",
+		"Auth.Federation.EmailNotVerified.Title",
+		"Auth.Federation.EmailNotVerified.Description",
+	)
+
+	ACCOUNT_EMAIL_NOT_VERIFIED = shared.NewConflitError(
+		"account-email-not-verified",
+		"Auth.Federation.AccountEmailNotVerified.Title",
+		"Auth.Federation.AccountEmailNotVerified.Description",
+	)
+
+	REAUTHENTICATION_REQUIRED = shared.NewUnauthorizedError(
+		"reauthentication-required",
+		"Auth.Identity.ReauthenticationRequired.Title",
+		"Auth.Identity.ReauthenticationRequired.Description",
+	)
+
+	IDENTITY_ALREADY_LINKED = shared.NewConflitError(
+		"identity-already-linked",
+		"Auth.Identity.AlreadyLinked.Title",
+		"Auth.Identity.AlreadyLinked.Description",
+	)
+
+	IDENTITY_NOT_FOUND = shared.NewNotFoundError(
+		"identity-not-found",
+		"Auth.Identity.NotFound.Title",
+		"Auth.Identity.NotFound.Description",
+	)
+
+	LAST_LOGIN_METHOD = shared.NewConflitError(
+		"last-login-method",
+		"Auth.Identity.LastLoginMethod.Title",
+		"Auth.Identity.LastLoginMethod.Description",
+	)
+
+	PASSWORD_NOT_SET = shared.NewConflitError(
+		"password-not-set",
+		"Auth.Password.NotSet.Title",
+		"Auth.Password.NotSet.Description",
+	)
+
+	INVALID_CURRENT_PASSWORD = shared.NewBadRequest(
+		"invalid-current-password",
+		"Auth.Password.InvalidCurrent.Title",
+		"Auth.Password.InvalidCurrent.Description",
+	)
+
+	ACCOUNT_PENDING_DELETION = shared.NewForbiddenError(
+		"account-pending-deletion",
+		"Auth.Account.PendingDeletion.Title",
+		"Auth.Account.PendingDeletion.Description",
+	)
+
+	ACCOUNT_NOT_PENDING_DELETION = shared.NewConflitError(
+		"account-not-pending-deletion",
+		"Auth.Account.NotPendingDeletion.Title",
+		"Auth.Account.NotPendingDeletion.Description",
+	)
 )
@@ -16,16 +16,62 @@ func MapProfileIdsToString(profiles []domain.Profile) []string {
 	return ids
 }
 
+func ToSessionsResponse(sessions []services.Session, currentSessionId string) *contracts.SessionsResponse {
+	response := make([]contracts.SessionResponse, 0, len(sessions))
+
+	for _, session := range sessions {
+		response = append(response, contracts.SessionResponse{
+			ID:         session.ID,
+			Device:     session.Device,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.ID == currentSessionId,
+		})
+	}
+
+	return &contracts.SessionsResponse{
+		Sessions: response,
+	}
+}
+
+func ToExternalIdentityResponse(identity *domain.ExternalIdentity) *contracts.ExternalIdentityResponse {
+	return &contracts.ExternalIdentityResponse{
+		ID:        identity.ID,
+		Provider:  identity.Provider,
+		Email:     identity.Email,
+		CreatedAt: identity.CreatedAt,
+	}
+}
+
+func ToExternalIdentitiesResponse(identities []domain.ExternalIdentity) *contracts.ExternalIdentitiesResponse {
+	response := make([]contracts.ExternalIdentityResponse, 0, len(identities))
+
+	for i := range identities {
+		response = append(response, *ToExternalIdentityResponse(&identities[i]))
+	}
+
+	return &contracts.ExternalIdentitiesResponse{
+		Identities: response,
+	}
+}
+
 func ToAuthResponse(
 	identityUser *domain.IdentityUser,
-	accessToken,
-	refreshToken *services.JwtToken,
+	tokens *services.AuthenticationTokens,
 ) *contracts.AuthResponse {
-	return &contracts.AuthResponse{
+	response := &contracts.AuthResponse{
 		TokenType:    "Bearer",
-		AccessToken:  accessToken.Token,
-		ExpiresIn:    accessToken.ExpireAt,
-		RefreshToken: refreshToken.Token,
+		AccessToken:  tokens.Access.Token,
+		ExpiresIn:    tokens.Access.ExpireAt,
+		RefreshToken: tokens.Refresh.Token,
 		Scope:        domain.GetPermissions(*identityUser),
 	}
+
+	if tokens.ID != nil {
+		response.IDToken = tokens.ID.Token
+	}
+
+	return response
 }
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+)
+
+type (
+	// AuthorizationGrant is what an authorization code stands for until it is
+	// exchanged at the token endpoint.
+	AuthorizationGrant struct {
+		AuthID              string `json:"auth_id"`
+		ClientID            string `json:"client_id"`
+		RedirectURI         string `json:"redirect_uri"`
+		CodeChallenge       string `json:"code_challenge"`
+		CodeChallengeMethod string `json:"code_challenge_method"`
+		Nonce               string `json:"nonce,omitempty"`
+	}
+
+	IAuthorizationCodeService interface {
+		CreateCode(grant AuthorizationGrant) (string, error)
+		ConsumeCode(code string) (*AuthorizationGrant, error)
+	}
+
+	AuthorizationCodeService struct {
+		redis interfaces.Redis
+	}
+)
+
+const (
+	CodeChallengeS256 = "S256"
+
+	// RFC 6749 section 4.1.2 recommends at most 10 minutes
+	AuthorizationCodeLifetime = time.Minute
+
+	authorizationCodeKey   = "authorization_code"
+	authorizationCodeBytes = 32
+)
+
+var (
+	ErrInvalidAuthorizationCode = errors.New("invalid authorization code")
+)
+
+func NewAuthorizationCodeService(redis interfaces.Redis) *AuthorizationCodeService {
+	return &AuthorizationCodeService{
+		redis: redis,
+	}
+}
+
+// NewAuthorizationCodeKey only keeps a hash of the code, a dump of redis
+// can't be replayed at the token endpoint.
+func NewAuthorizationCodeKey(code string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(authorizationCodeKey, fmt.Sprintf("%x", sha256.Sum256([]byte(code))))
+}
+
+func (acs *AuthorizationCodeService) CreateCode(grant AuthorizationGrant) (string, error) {
+	rawCode := make([]byte, authorizationCodeBytes)
+
+	if _, err := rand.Read(rawCode); err != nil {
+		return "", err
+	}
+
+	code := base64.RawURLEncoding.EncodeToString(rawCode)
+
+	rawGrant, err := json.Marshal(grant)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := acs.redis.SetValue(NewAuthorizationCodeKey(code), string(rawGrant), AuthorizationCodeLifetime); err != nil {
+		return "", ErrCreatingToken
+	}
+
+	return code, nil
+}
+
+// ConsumeCode returns the grant behind a code and deletes it, a code can only
+// be exchanged once.
+func (acs *AuthorizationCodeService) ConsumeCode(code string) (*AuthorizationGrant, error) {
+	rawGrant, err := acs.redis.GetAndDelValue(NewAuthorizationCodeKey(code))
+
+	if err != nil || rawGrant == "" {
+		return nil, ErrInvalidAuthorizationCode
+	}
+
+	var grant AuthorizationGrant
+
+	if err := json.Unmarshal([]byte(rawGrant), &grant); err != nil {
+		return nil, ErrInvalidAuthorizationCode
+	}
+
+	return &grant, nil
+}
+
+// VerifyCodeChallenge checks a PKCE code_verifier against the challenge sent
+// to the authorize endpoint (RFC 7636 section 4.6), only S256 is accepted.
+func VerifyCodeChallenge(verifier, challenge, method string) bool {
+	if method != CodeChallengeS256 || verifier == "" {
+		return false
+	}
+
+	hash := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(hash[:])
+
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/config"
+)
+
+type (
+	// IPasswordRangeProvider answers k-anonymity range queries, the suffixes
+	// of the breached SHA-1 hashes starting with a 5 character prefix and how
+	// often each was seen. The full hash never leaves the service.
+	IPasswordRangeProvider interface {
+		Range(prefix string) (map[string]int, error)
+	}
+
+	IBreachedPasswordService interface {
+		IsBreached(password string) (bool, error)
+	}
+
+	// DirRangeProvider reads a local copy of the corpus, a directory of
+	// <PREFIX>.txt range files as the Have I Been Pwned downloader writes
+	// them, only the range asked for is read.
+	DirRangeProvider struct {
+		dir string
+	}
+
+	// HTTPRangeProvider queries a range API such as
+	// https://api.pwnedpasswords.com/range/, padding hides the size of the
+	// answer from anyone watching.
+	HTTPRangeProvider struct {
+		baseURL string
+		client  *http.Client
+	}
+
+	BreachedPasswordService struct {
+		provider IPasswordRangeProvider
+	}
+)
+
+const (
+	rangePrefixLength       = 5
+	rangeRequestTimeout     = 5 * time.Second
+	maxRangeResponseSize    = 1 << 20
+	defaultBreachedRangeAPI = "https://api.pwnedpasswords.com/range/"
+)
+
+var ErrInvalidRangeLine = errors.New("invalid range line")
+
+func NewDirRangeProvider(dir string) *DirRangeProvider {
+	return &DirRangeProvider{dir: dir}
+}
+
+func NewHTTPRangeProvider(baseURL string) *HTTPRangeProvider {
+	if baseURL == "" {
+		baseURL = defaultBreachedRangeAPI
+	}
+
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	return &HTTPRangeProvider{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: rangeRequestTimeout},
+	}
+}
+
+// NewPasswordRangeProvider picks the provider from the config, the local
+// corpus wins over the API, without either the check is off.
+func NewPasswordRangeProvider() IPasswordRangeProvider {
+	cfg := config.GetConfig()
+
+	if cfg.PASSWORD_BREACH_RANGE_DIR != "" {
+		return NewDirRangeProvider(cfg.PASSWORD_BREACH_RANGE_DIR)
+	}
+
+	if cfg.PASSWORD_BREACH_RANGE_URL != "" {
+		return NewHTTPRangeProvider(cfg.PASSWORD_BREACH_RANGE_URL)
+	}
+
+	return nil
+}
+
+// NewBreachedPasswordService screens passwords with provider, a nil provider
+// lets every password through.
+func NewBreachedPasswordService(provider IPasswordRangeProvider) *BreachedPasswordService {
+	return &BreachedPasswordService{
+		provider: provider,
+	}
+}
+
+// parseRangeLine reads a HASH:COUNT line, a line without a count was seen
+// once.
+func parseRangeLine(line string) (string, int, error) {
+	hash, rawCount, found := strings.Cut(strings.TrimSpace(line), ":")
+	hash = strings.ToUpper(hash)
+
+	if hash == "" {
+		return "", 0, ErrInvalidRangeLine
+	}
+
+	if !found {
+		return hash, 1, nil
+	}
+
+	count, err := strconv.Atoi(rawCount)
+
+	if err != nil {
+		return "", 0, ErrInvalidRangeLine
+	}
+
+	return hash, count, nil
+}
+
+func readRange(reader io.Reader) (map[string]int, error) {
+	suffixes := make(map[string]int)
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		suffix, count, err := parseRangeLine(scanner.Text())
+
+		if err != nil {
+			return nil, err
+		}
+
+		suffixes[suffix] = count
+	}
+
+	return suffixes, scanner.Err()
+}
+
+func (dp *DirRangeProvider) Range(prefix string) (map[string]int, error) {
+	file, err := os.Open(filepath.Join(dp.dir, prefix+".txt"))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return readRange(file)
+}
+
+func (hp *HTTPRangeProvider) Range(prefix string) (map[string]int, error) {
+	request, err := http.NewRequest(http.MethodGet, hp.baseURL+prefix, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Add-Padding", "true")
+	response, err := hp.client.Do(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %d", hp.baseURL, response.StatusCode)
+	}
+
+	return readRange(io.LimitReader(response.Body, maxRangeResponseSize))
+}
+
+// IsBreached tells whether password shows up in the corpus, only the first
+// 5 characters of its SHA-1 are handed to the provider. Padding entries
+// have a count of 0 and never match.
+func (bs *BreachedPasswordService) IsBreached(password string) (bool, error) {
+	if bs.provider == nil {
+		return false, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := bs.provider.Range(hash[:rangePrefixLength])
+
+	if err != nil {
+		return false, err
+	}
+
+	return suffixes[hash[rangePrefixLength:]] > 0, nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -20,6 +21,7 @@ type (
 
 	IPGService interface {
 		CreateAndStoreCode(userId string) (*Code, error)
+		CheckCode(userId, code string) error
 		ValidateCode(userId, code string) error
 	}
 
@@ -52,6 +54,30 @@ func NewForgotCodeKey(userId string) interfaces.RedisKey {
 	return interfaces.NewRedisKey(userId, ForgotCodeKey.Value)
 }
 
+// CheckCode verifies a code without spending it, so a request that fails
+// for another reason can be retried. A wrong code is deleted right away, it
+// can't be guessed any more than through ValidateCode.
+func (pgs *PGService) CheckCode(userId, code string) error {
+	var forgotKey = NewForgotCodeKey(userId)
+
+	storedCode, err := pgs.redis.GetValue(forgotKey)
+
+	if err != nil {
+		return err
+	}
+
+	if err := matchCode(storedCode, code); err != nil {
+		if err := pgs.redis.DelValue(forgotKey); err != nil {
+			log.Printf("failed to delete forgot code of %s: %s", userId, err.Error())
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// ValidateCode spends the code, only one request gets through with it.
 func (pgs *PGService) ValidateCode(userId, code string) error {
 	var forgotKey = NewForgotCodeKey(userId)
 
@@ -61,9 +87,13 @@ func (pgs *PGService) ValidateCode(userId, code string) error {
 		return err
 	}
 
+	return matchCode(storedCode, code)
+}
+
+func matchCode(storedCode, code string) error {
 	base64Content := strings.Split(storedCode, Delimiter)
 
-	if len(base64Content) > 2 {
+	if len(base64Content) != 2 {
 		return ErrCodeNotValid
 	}
 
@@ -4,4 +4,15 @@ type Services struct {
 	Token ITokenService
 	PG    IPGService
 	Email IEmailService
+
+	AuthorizationCode IAuthorizationCodeService
+	LoginAttempt      ILoginAttemptService
+	RateLimiter       IRateLimiter
+	EmailVerification IEmailVerificationService
+	MFAChallenge      IMFAChallengeService
+	TOTP              ITOTPService
+	WebAuthn          IWebAuthnService
+	LoginCode         ILoginCodeService
+	Federation        IFederationService
+	BreachedPassword  IBreachedPasswordService
 }
@@ -2,8 +2,11 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
 	"time"
 
+	"github.com/BeatEcoprove/identityService/config"
 	"github.com/BeatEcoprove/identityService/internal/domain/events"
 	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
 	"github.com/BeatEcoprove/identityService/pkg/shared"
@@ -37,11 +40,21 @@ var (
 	ErrEmptyEmailList = errors.New("there isn't any email sent yet")
 )
 
-func NewConfirmEmailTemplate() *EmailTemplate {
+// NewConfirmEmailTemplate carries the verification token and, when
+// EMAIL_VERIFICATION_URL is set, the link of the page that confirms it.
+func NewConfirmEmailTemplate(token string) *EmailTemplate {
+	paramters := map[string]string{
+		"token": token,
+	}
+
+	if verificationURL := config.GetConfig().EMAIL_VERIFICATION_URL; verificationURL != "" {
+		paramters["link"] = fmt.Sprintf("%s?token=%s", verificationURL, url.QueryEscape(token))
+	}
+
 	return &EmailTemplate{
 		ID:        "confirm-account",
 		Subject:   "Confirm Account",
-		Paramters: make(map[string]string),
+		Paramters: paramters,
 	}
 }
 
@@ -55,6 +68,33 @@ func NewForgotEmailTemplate(code string) *EmailTemplate {
 	}
 }
 
+// NewLoginCodeTemplate carries a one-time code that signs the user in
+// without a password.
+func NewLoginCodeTemplate(code string) *EmailTemplate {
+	return &EmailTemplate{
+		ID:      "login-code",
+		Subject: "Login Code",
+		Paramters: map[string]string{
+			"code":       code,
+			"expires_in": fmt.Sprintf("%d", int(LoginCodeLifetime.Minutes())),
+		},
+	}
+}
+
+// NewPasswordChangedTemplate warns the owner of the account, the device
+// lets them tell whether it was them.
+func NewPasswordChangedTemplate(device DeviceInfo, changedAt time.Time) *EmailTemplate {
+	return &EmailTemplate{
+		ID:      "password-changed",
+		Subject: "Password Changed",
+		Paramters: map[string]string{
+			"ip":         device.IP,
+			"user_agent": device.UserAgent,
+			"changed_at": changedAt.UTC().Format(time.RFC3339),
+		},
+	}
+}
+
 func NewEmailService(rabbitmq interfaces.Broker) *EmailService {
 	return &EmailService{
 		broker: rabbitmq,
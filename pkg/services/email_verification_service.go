@@ -0,0 +1,94 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+)
+
+type (
+	IEmailVerificationService interface {
+		CreateToken(authID string) (string, error)
+		ConsumeToken(token string) (string, error)
+	}
+
+	EmailVerificationService struct {
+		redis interfaces.Redis
+	}
+)
+
+const (
+	EmailVerificationLifetime = 24 * time.Hour
+
+	emailVerificationKey   = "email_verification"
+	emailVerificationBytes = 32
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid email verification token")
+)
+
+func NewEmailVerificationService(redis interfaces.Redis) *EmailVerificationService {
+	return &EmailVerificationService{
+		redis: redis,
+	}
+}
+
+// NewEmailVerificationKey only keeps a hash of the token, like authorization
+// codes.
+func NewEmailVerificationKey(token string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(emailVerificationKey, hashVerificationToken(token))
+}
+
+// NewUserEmailVerificationKey points at the last token sent to a user, so a
+// resend invalidates the previous email.
+func NewUserEmailVerificationKey(authID string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(authID, emailVerificationKey)
+}
+
+func hashVerificationToken(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
+
+func (evs *EmailVerificationService) CreateToken(authID string) (string, error) {
+	rawToken := make([]byte, emailVerificationBytes)
+
+	if _, err := rand.Read(rawToken); err != nil {
+		return "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(rawToken)
+	userKey := NewUserEmailVerificationKey(authID)
+
+	if previous, err := evs.redis.GetAndDelValue(userKey); err == nil && previous != "" {
+		_ = evs.redis.DelValue(interfaces.NewRedisKey(emailVerificationKey, previous))
+	}
+
+	if err := evs.redis.SetValue(NewEmailVerificationKey(token), authID, EmailVerificationLifetime); err != nil {
+		return "", ErrCreatingToken
+	}
+
+	if err := evs.redis.SetValue(userKey, hashVerificationToken(token), EmailVerificationLifetime); err != nil {
+		return "", ErrCreatingToken
+	}
+
+	return token, nil
+}
+
+// ConsumeToken returns the user a token was sent to and deletes it, a token
+// confirms an email once.
+func (evs *EmailVerificationService) ConsumeToken(token string) (string, error) {
+	authID, err := evs.redis.GetAndDelValue(NewEmailVerificationKey(token))
+
+	if err != nil || authID == "" {
+		return "", ErrInvalidVerificationToken
+	}
+
+	_ = evs.redis.DelValue(NewUserEmailVerificationKey(authID))
+	return authID, nil
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type (
+	IFederationService interface {
+		VerifyIDToken(provider, idToken string) (*FederatedIdentity, error)
+	}
+
+	// IdentityProvider is an OpenID Connect issuer users can sign in with, its
+	// id tokens are only accepted when issued to ClientID.
+	IdentityProvider struct {
+		Name     string
+		Issuers  []string
+		ClientID string
+	}
+
+	// FederatedIdentity is the user as the provider's id token describes it.
+	FederatedIdentity struct {
+		Provider      string
+		Subject       string
+		Email         string
+		EmailVerified bool
+		Nonce         string
+	}
+
+	federatedClaims struct {
+		jwt.RegisteredClaims
+		Email string `json:"email"`
+		Nonce string `json:"nonce"`
+
+		// Apple sends it as the string "true"
+		EmailVerified any `json:"email_verified"`
+	}
+
+	providerDiscovery struct {
+		Issuer  string `json:"issuer"`
+		JwksURI string `json:"jwks_uri"`
+	}
+
+	providerKeys struct {
+		keys      map[string]crypto.PublicKey
+		fetchedAt time.Time
+	}
+
+	FederationService struct {
+		client *http.Client
+
+		mu   sync.Mutex
+		keys map[string]*providerKeys
+	}
+)
+
+const (
+	GoogleProvider      = "google"
+	AppleProvider       = "apple"
+	DefaultOIDCProvider = "oidc"
+
+	// provider keys are refetched after this long, or sooner when a token
+	// names a kid that isn't known yet
+	FederatedKeysLifetime = time.Hour
+
+	federatedKeysRefreshInterval = time.Minute
+	federationRequestTimeout     = 10 * time.Second
+	maxFederationResponseSize    = 1 << 20
+)
+
+var (
+	ErrUnknownIdentityProvider = errors.New("unknown identity provider")
+	ErrInvalidIDToken          = errors.New("invalid id token")
+	ErrFetchingProviderKeys    = errors.New("failed to fetch the identity provider keys")
+)
+
+func NewFederationService() *FederationService {
+	return &FederationService{
+		client: &http.Client{Timeout: federationRequestTimeout},
+		keys:   make(map[string]*providerKeys),
+	}
+}
+
+// IdentityProviders lists the providers with a client id configured, Google
+// and Apple have fixed issuers, the generic one is set with
+// OIDC_PROVIDER_ISSUER.
+func IdentityProviders() []IdentityProvider {
+	cfg := config.GetConfig()
+
+	var providers []IdentityProvider
+
+	if cfg.OIDC_GOOGLE_CLIENT_ID != "" {
+		providers = append(providers, IdentityProvider{
+			Name:     GoogleProvider,
+			Issuers:  []string{"https://accounts.google.com", "accounts.google.com"},
+			ClientID: cfg.OIDC_GOOGLE_CLIENT_ID,
+		})
+	}
+
+	if cfg.OIDC_APPLE_CLIENT_ID != "" {
+		providers = append(providers, IdentityProvider{
+			Name:     AppleProvider,
+			Issuers:  []string{"https://appleid.apple.com"},
+			ClientID: cfg.OIDC_APPLE_CLIENT_ID,
+		})
+	}
+
+	if cfg.OIDC_PROVIDER_ISSUER != "" && cfg.OIDC_PROVIDER_CLIENT_ID != "" {
+		name := cfg.OIDC_PROVIDER_NAME
+
+		if name == "" {
+			name = DefaultOIDCProvider
+		}
+
+		providers = append(providers, IdentityProvider{
+			Name:     name,
+			Issuers:  []string{strings.TrimSuffix(cfg.OIDC_PROVIDER_ISSUER, "/")},
+			ClientID: cfg.OIDC_PROVIDER_CLIENT_ID,
+		})
+	}
+
+	return providers
+}
+
+func GetIdentityProvider(name string) (IdentityProvider, bool) {
+	for _, provider := range IdentityProviders() {
+		if provider.Name == name {
+			return provider, true
+		}
+	}
+
+	return IdentityProvider{}, false
+}
+
+func (fs *FederationService) getJSON(url string, target any) error {
+	response, err := fs.client.Get(url)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %d", url, response.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(response.Body, maxFederationResponseSize)).Decode(target)
+}
+
+// fetchKeys finds the JWKS through the provider's discovery document, keys
+// of an unsupported type are skipped.
+func (fs *FederationService) fetchKeys(provider IdentityProvider) (map[string]crypto.PublicKey, error) {
+	var discovery providerDiscovery
+
+	if err := fs.getJSON(provider.Issuers[0]+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+
+	if discovery.JwksURI == "" {
+		return nil, ErrFetchingProviderKeys
+	}
+
+	var jwks JWKS
+
+	if err := fs.getJSON(discovery.JwksURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		if publicKey, err := parseJWK(jwk); err == nil {
+			keys[jwk.Kid] = publicKey
+		}
+	}
+
+	return keys, nil
+}
+
+// publicKey resolves kid from the cached keys of the provider, providers
+// rotate their keys so an unknown kid refreshes them, at most once a minute.
+func (fs *FederationService) publicKey(provider IdentityProvider, kid string) (crypto.PublicKey, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	now := time.Now()
+	cached, ok := fs.keys[provider.Name]
+
+	if ok && now.Sub(cached.fetchedAt) < FederatedKeysLifetime {
+		if publicKey, found := cached.keys[kid]; found {
+			return publicKey, nil
+		}
+
+		if now.Sub(cached.fetchedAt) < federatedKeysRefreshInterval {
+			return nil, ErrUnknownKid
+		}
+	}
+
+	keys, err := fs.fetchKeys(provider)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFetchingProviderKeys, err.Error())
+	}
+
+	fs.keys[provider.Name] = &providerKeys{keys: keys, fetchedAt: now}
+
+	if publicKey, found := keys[kid]; found {
+		return publicKey, nil
+	}
+
+	return nil, ErrUnknownKid
+}
+
+func isTrue(value any) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+
+	return false
+}
+
+// VerifyIDToken checks the signature, issuer, audience and expiry of an id
+// token issued by provider.
+func (fs *FederationService) VerifyIDToken(providerName, idToken string) (*FederatedIdentity, error) {
+	provider, ok := GetIdentityProvider(providerName)
+
+	if !ok {
+		return nil, ErrUnknownIdentityProvider
+	}
+
+	var claims federatedClaims
+
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(t *jwt.Token) (any, error) {
+		kid, ok := t.Header["kid"].(string)
+
+		if !ok || kid == "" {
+			return nil, ErrInvalidKidTokenHeader
+		}
+
+		return fs.publicKey(provider, kid)
+	},
+		jwt.WithValidMethods(SupportedSigningAlgs),
+		jwt.WithAudience(provider.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+
+	if err != nil {
+		if errors.Is(err, ErrFetchingProviderKeys) {
+			return nil, ErrFetchingProviderKeys
+		}
+
+		return nil, ErrInvalidIDToken
+	}
+
+	if !slices.Contains(provider.Issuers, claims.Issuer) || claims.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	return &FederatedIdentity{
+		Provider:      provider.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: isTrue(claims.EmailVerified),
+		Nonce:         claims.Nonce,
+	}, nil
+}
@@ -1,7 +1,6 @@
 package services
 
 import (
-	"crypto/rsa"
 	"errors"
 	"time"
 
@@ -21,27 +20,54 @@ type (
 	TokenPayload struct {
 		Email      string
 		UserID     string
+		SessionID  string
 		ProfileID  string
 		ProfileIds []string
 		Scope      []string
 		Role       string
+		Device     DeviceInfo
 		Duration   time.Duration
 		Type       TokenType
+
+		EmailVerified bool
+
+		// OpenID Connect, only used for the id_token
+		Nonce    string
+		ClientID string
 	}
 
 	AuthClaims struct {
 		jwt.RegisteredClaims
-		Email      string   `json:"email,omitempty"`
-		Role       string   `json:"role,omitempty"`
-		ProfileID  string   `json:"profile_id,omitempty"`
-		ProfileIds []string `json:"profile_ids,omitempty"`
-		Scope      []string `json:"scope,omitempty"`
+		Email         string   `json:"email,omitempty"`
+		EmailVerified bool     `json:"email_verified,omitempty"`
+		SessionID     string   `json:"sid,omitempty"`
+		Role          string   `json:"role,omitempty"`
+		ProfileID     string   `json:"profile_id,omitempty"`
+		ProfileIds    []string `json:"profile_ids,omitempty"`
+		Scope         []string `json:"scope,omitempty"`
+	}
+
+	IDTokenClaims struct {
+		jwt.RegisteredClaims
+		Nonce         string           `json:"nonce,omitempty"`
+		AuthTime      *jwt.NumericDate `json:"auth_time,omitempty"`
+		SessionID     string           `json:"sid,omitempty"`
+		Email         string           `json:"email,omitempty"`
+		EmailVerified bool             `json:"email_verified"`
 	}
 )
 
 const (
 	Access  TokenType = "access"
 	Refresh TokenType = "refresh"
+
+	// ID tokens keep the standard typ OpenID Connect clients expect, they are
+	// told apart from access tokens by their claims and audience.
+	ID TokenType = "JWT"
+
+	// Machine tokens are issued to registered clients, not to users, they have
+	// no session and are only accepted by internal routes.
+	Machine TokenType = "machine"
 )
 
 var (
@@ -51,26 +77,54 @@ var (
 	ErrInvalidTokenType      = errors.New("invalid token type")
 )
 
-var privKey *rsa.PrivateKey
-var PubKey *rsa.PublicKey
+// VerificationKey resolves the public key of the ring entry named by the
+// token's kid, so tokens signed before a rotation keep verifying.
+func VerificationKey(t *jwt.Token) (any, error) {
+	kid, ok := t.Header["kid"].(string)
 
-func LoadKeys(publicKey, privateKey []byte) error {
-	loadPrivKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKey)
+	if !ok || kid == "" {
+		return nil, ErrInvalidKidTokenHeader
+	}
 
-	if err != nil {
-		return err
+	key, ok := keyRing.Lookup(kid)
+
+	if !ok {
+		return nil, ErrUnknownKid
 	}
 
-	loadPubKey, err := jwt.ParseRSAPublicKeyFromPEM(publicKey)
+	if t.Method.Alg() != key.Alg {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	return key.PublicKey(), nil
+}
+
+// TypedVerificationKey only resolves the key of tokens whose typ header is
+// tokenType, a token can't be used in place of another kind.
+func TypedVerificationKey(tokenType TokenType) jwt.Keyfunc {
+	return func(t *jwt.Token) (any, error) {
+		typ, ok := t.Header["typ"].(string)
+
+		if !ok || typ != string(tokenType) {
+			return nil, ErrInvalidTokenType
+		}
+
+		return VerificationKey(t)
+	}
+}
+
+func signClaims(claims jwt.Claims, tokenType TokenType, now time.Time) (string, error) {
+	signingKey, err := keyRing.SigningKey(now)
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	privKey = loadPrivKey
-	PubKey = loadPubKey
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(signingKey.Alg), claims)
+	token.Header["kid"] = signingKey.Kid
+	token.Header["typ"] = string(tokenType)
 
-	return nil
+	return token.SignedString(signingKey.privateKey)
 }
 
 func CreateJwtToken(payload TokenPayload) (*JwtToken, error) {
@@ -79,11 +133,13 @@ func CreateJwtToken(payload TokenPayload) (*JwtToken, error) {
 	expiresAt := currentTime.Add(payload.Duration)
 
 	claims := AuthClaims{
-		Email:      payload.Email,
-		Role:       payload.Role,
-		ProfileID:  payload.ProfileID,
-		ProfileIds: payload.ProfileIds,
-		Scope:      payload.Scope,
+		Email:         payload.Email,
+		EmailVerified: payload.EmailVerified,
+		SessionID:     payload.SessionID,
+		Role:          payload.Role,
+		ProfileID:     payload.ProfileID,
+		ProfileIds:    payload.ProfileIds,
+		Scope:         payload.Scope,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    env.JWT_ISSUER,
 			Audience:  jwt.ClaimStrings{env.JWT_AUDIENCE},
@@ -94,15 +150,7 @@ func CreateJwtToken(payload TokenPayload) (*JwtToken, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token.Header["kid"] = generateKid()
-	token.Header["typ"] = string(payload.Type)
-
-	if token.Header["typ"] == "" {
-		token.Header["typ"] = Access
-	}
-
-	jwtToken, err := token.SignedString(privKey)
+	jwtToken, err := signClaims(claims, payload.Type, currentTime)
 
 	if err != nil {
 		return nil, err
@@ -114,30 +162,50 @@ func CreateJwtToken(payload TokenPayload) (*JwtToken, error) {
 	}, nil
 }
 
-func GetClaims(token string, claims jwt.Claims, tokenType TokenType) error {
-	jwtToken, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
+// CreateIDToken issues the OpenID Connect id_token of a session, authTime is
+// when the user originally authenticated, not when the token was refreshed.
+// The audience is the client the session was authorized for, sessions of a
+// first party login have none and get JWT_AUDIENCE.
+func CreateIDToken(payload TokenPayload, authTime time.Time) (*JwtToken, error) {
+	env := config.GetConfig()
+	currentTime := time.Now()
+	expiresAt := currentTime.Add(payload.Duration)
+	audience := env.JWT_AUDIENCE
 
-		kid, ok := t.Header["kid"].(string)
+	if payload.ClientID != "" {
+		audience = payload.ClientID
+	}
 
-		if !ok {
-			return nil, ErrInvalidKidTokenHeader
-		}
+	claims := IDTokenClaims{
+		Nonce:         payload.Nonce,
+		AuthTime:      jwt.NewNumericDate(authTime),
+		SessionID:     payload.SessionID,
+		Email:         payload.Email,
+		EmailVerified: payload.EmailVerified,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    env.JWT_ISSUER,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  &jwt.NumericDate{Time: currentTime},
+			ExpiresAt: &jwt.NumericDate{Time: expiresAt},
+			Subject:   payload.UserID,
+			ID:        uuid.New().String(),
+		},
+	}
 
-		if storedJwks.Keys[0].Kid != kid {
-			return nil, ErrInvalidToken
-		}
+	jwtToken, err := signClaims(claims, ID, currentTime)
 
-		typ, ok := t.Header["typ"].(string)
+	if err != nil {
+		return nil, err
+	}
 
-		if !ok || typ != string(tokenType) {
-			return nil, ErrInvalidTokenType
-		}
+	return &JwtToken{
+		Token:    jwtToken,
+		ExpireAt: int64(expiresAt.Unix()),
+	}, nil
+}
 
-		return PubKey, nil
-	})
+func GetClaims(token string, claims jwt.Claims, tokenType TokenType) error {
+	jwtToken, err := jwt.ParseWithClaims(token, claims, TypedVerificationKey(tokenType))
 
 	if err != nil {
 		return err
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/config"
+)
+
+const (
+	defaultKeyPublishLead    = time.Hour
+	keyRotationCheckInterval = time.Minute
+)
+
+// KeyRotator periodically reloads the key ring, so keys rotated by another
+// instance or by hand are picked up, rotates it once the newest key is older
+// than JWT_KEY_ROTATION_DAYS and prunes keys that can no longer verify any
+// token.
+type KeyRotator struct {
+	period      time.Duration
+	publishLead time.Duration
+	done        chan struct{}
+}
+
+func NewKeyRotator() *KeyRotator {
+	return &KeyRotator{
+		period:      KeyRotationPeriod(),
+		publishLead: KeyPublishLead(),
+		done:        make(chan struct{}),
+	}
+}
+
+// KeyRotationPeriod is configured in days, zero disables scheduled rotation.
+func KeyRotationPeriod() time.Duration {
+	return time.Duration(config.GetConfig().JWT_KEY_ROTATION_DAYS) * time.Hour * 24
+}
+
+// KeyPublishLead is configured in minutes and should be longer than the time
+// relying parties cache the JWKS.
+func KeyPublishLead() time.Duration {
+	lead := time.Duration(config.GetConfig().JWT_KEY_PUBLISH_LEAD) * time.Minute
+
+	if lead <= 0 {
+		return defaultKeyPublishLead
+	}
+
+	return lead
+}
+
+func (kr *KeyRotator) Check() error {
+	if err := LoadKeyRing(); err != nil {
+		return err
+	}
+
+	if RotationDue(kr.period) {
+		key, err := RotateKeys(kr.publishLead)
+
+		if err != nil {
+			return err
+		}
+
+		log.Printf("🔑 signing key %s published, active from %s", key.Kid, key.ActivatesAt.Format(time.RFC3339))
+	}
+
+	return PruneKeys()
+}
+
+func (kr *KeyRotator) Start() {
+	ticker := time.NewTicker(keyRotationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-kr.done:
+			return
+		case <-ticker.C:
+			if err := kr.Check(); err != nil {
+				log.Printf("failed to rotate signing keys: %s", err.Error())
+			}
+		}
+	}
+}
+
+func (kr *KeyRotator) Close() error {
+	close(kr.done)
+	return nil
+}
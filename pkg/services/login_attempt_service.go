@@ -0,0 +1,177 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/config"
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+)
+
+type (
+	// Lockout is returned when a failed attempt locks an account.
+	Lockout struct {
+		Attempts    int64
+		LockedUntil time.Time
+	}
+
+	ILoginAttemptService interface {
+		LockedFor(email, ip string) (time.Duration, error)
+		RegisterFailure(email, ip string) (*Lockout, error)
+		Reset(email string) error
+	}
+
+	LoginAttemptService struct {
+		redis interfaces.Redis
+	}
+)
+
+const (
+	loginAttemptsKey = "login_attempts"
+	loginLockKey     = "login_lock"
+
+	LoginAccountScope = "account"
+	LoginIPScope      = "ip"
+
+	defaultMaxLoginAttempts   = 5
+	defaultMaxIPLoginAttempts = 50
+	defaultLoginWindow        = time.Hour
+	defaultLockout            = time.Minute
+	defaultMaxLockout         = 30 * time.Minute
+)
+
+func NewLoginAttemptService(redis interfaces.Redis) *LoginAttemptService {
+	return &LoginAttemptService{
+		redis: redis,
+	}
+}
+
+func NewLoginAttemptsKey(scope, value string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(loginAttemptsKey, scope, value)
+}
+
+func NewLoginLockKey(scope, value string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(loginLockKey, scope, value)
+}
+
+// accountID counts attempts on the same account however the email is typed.
+func accountID(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func configuredMinutes(minutes int, fallback time.Duration) time.Duration {
+	if minutes <= 0 {
+		return fallback
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+func maxLoginAttempts() int64 {
+	if attempts := config.GetConfig().LOGIN_MAX_ATTEMPTS; attempts > 0 {
+		return int64(attempts)
+	}
+
+	return defaultMaxLoginAttempts
+}
+
+func maxIPLoginAttempts() int64 {
+	if attempts := config.GetConfig().LOGIN_MAX_IP_ATTEMPTS; attempts > 0 {
+		return int64(attempts)
+	}
+
+	return defaultMaxIPLoginAttempts
+}
+
+// loginWindow is configured in minutes, failures are forgotten once none
+// happened for that long. It should outlast LOGIN_MAX_LOCKOUT, otherwise the
+// backoff starts over after the longest lockout.
+func loginWindow() time.Duration {
+	return configuredMinutes(config.GetConfig().LOGIN_ATTEMPTS_WINDOW, defaultLoginWindow)
+}
+
+// lockoutFor doubles the lockout, configured in minutes, with every failure
+// past the limit, up to LOGIN_MAX_LOCKOUT.
+func lockoutFor(attempts, limit int64) time.Duration {
+	env := config.GetConfig()
+	lockout := configuredMinutes(env.LOGIN_LOCKOUT, defaultLockout)
+	maxLockout := configuredMinutes(env.LOGIN_MAX_LOCKOUT, defaultMaxLockout)
+
+	for i := limit; i < attempts && lockout < maxLockout; i++ {
+		lockout *= 2
+	}
+
+	return min(lockout, maxLockout)
+}
+
+// LockedFor is how long the account or the ip is still locked out, zero when
+// neither is.
+func (las *LoginAttemptService) LockedFor(email, ip string) (time.Duration, error) {
+	lockedFor, err := las.redis.TimeToLive(NewLoginLockKey(LoginAccountScope, accountID(email)))
+
+	if err != nil {
+		return 0, err
+	}
+
+	if ip != "" {
+		ipLockedFor, err := las.redis.TimeToLive(NewLoginLockKey(LoginIPScope, ip))
+
+		if err != nil {
+			return 0, err
+		}
+
+		lockedFor = max(lockedFor, ipLockedFor)
+	}
+
+	return max(lockedFor, 0), nil
+}
+
+// RegisterFailure counts a failed attempt against the account and the ip and
+// locks whichever went over its limit. Only an account lockout is returned,
+// an ip lockout concerns no account owner.
+func (las *LoginAttemptService) RegisterFailure(email, ip string) (*Lockout, error) {
+	window := loginWindow()
+
+	if ip != "" {
+		ipAttempts, err := las.redis.Increment(NewLoginAttemptsKey(LoginIPScope, ip), window)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if limit := maxIPLoginAttempts(); ipAttempts >= limit {
+			if err := las.redis.SetValue(NewLoginLockKey(LoginIPScope, ip), ipAttempts, lockoutFor(ipAttempts, limit)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	attempts, err := las.redis.Increment(NewLoginAttemptsKey(LoginAccountScope, accountID(email)), window)
+
+	if err != nil {
+		return nil, err
+	}
+
+	limit := maxLoginAttempts()
+
+	if attempts < limit {
+		return nil, nil
+	}
+
+	lockout := lockoutFor(attempts, limit)
+
+	if err := las.redis.SetValue(NewLoginLockKey(LoginAccountScope, accountID(email)), attempts, lockout); err != nil {
+		return nil, err
+	}
+
+	return &Lockout{
+		Attempts:    attempts,
+		LockedUntil: time.Now().Add(lockout),
+	}, nil
+}
+
+// Reset forgets the failures of an account after a successful login, the ip
+// counter is kept so one valid account can't be used to keep guessing others.
+func (las *LoginAttemptService) Reset(email string) error {
+	return las.redis.DelValue(NewLoginAttemptsKey(LoginAccountScope, accountID(email)))
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+)
+
+type (
+	ILoginCodeService interface {
+		CreateCode(authID string) (string, error)
+		ConsumeCode(authID, code string) error
+	}
+
+	LoginCodeService struct {
+		redis interfaces.Redis
+	}
+)
+
+const (
+	LoginCodeLifetime = 10 * time.Minute
+
+	// a code is dropped after this many wrong guesses, a new one has to be
+	// requested
+	MaxLoginCodeAttempts = 5
+
+	loginCodeKey         = "login_code"
+	loginCodeAttemptsKey = "attempts"
+)
+
+var (
+	ErrInvalidLoginCode = errors.New("invalid login code")
+)
+
+func NewLoginCodeService(redis interfaces.Redis) *LoginCodeService {
+	return &LoginCodeService{
+		redis: redis,
+	}
+}
+
+// NewLoginCodeKey holds the last code sent to a user, a new one replaces
+// it.
+func NewLoginCodeKey(authID string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(authID, loginCodeKey)
+}
+
+func NewLoginCodeAttemptsKey(authID string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(authID, loginCodeKey, loginCodeAttemptsKey)
+}
+
+// hashLoginCode only keeps a hash of the code, it is accepted however it
+// was typed.
+func hashLoginCode(code string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.ToUpper(strings.TrimSpace(code)))))
+}
+
+func (lcs *LoginCodeService) CreateCode(authID string) (string, error) {
+	code, err := GenerateCode()
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := lcs.redis.SetValue(NewLoginCodeKey(authID), hashLoginCode(code), LoginCodeLifetime); err != nil {
+		return "", ErrCreatingToken
+	}
+
+	_ = lcs.redis.DelValue(NewLoginCodeAttemptsKey(authID))
+	return code, nil
+}
+
+// ConsumeCode accepts a code once. Wrong codes are counted and the code is
+// dropped once it had MaxLoginCodeAttempts of them.
+func (lcs *LoginCodeService) ConsumeCode(authID, code string) error {
+	storedCode, err := lcs.redis.GetValue(NewLoginCodeKey(authID))
+
+	if err != nil || storedCode == "" {
+		return ErrInvalidLoginCode
+	}
+
+	if subtle.ConstantTimeCompare([]byte(storedCode), []byte(hashLoginCode(code))) != 1 {
+		attempts, err := lcs.redis.Increment(NewLoginCodeAttemptsKey(authID), LoginCodeLifetime)
+
+		if err == nil && attempts >= MaxLoginCodeAttempts {
+			_ = lcs.redis.DelValue(NewLoginCodeKey(authID), NewLoginCodeAttemptsKey(authID))
+		}
+
+		return ErrInvalidLoginCode
+	}
+
+	// only the first of two concurrent exchanges gets the code
+	if consumed, err := lcs.redis.GetAndDelValue(NewLoginCodeKey(authID)); err != nil || consumed != storedCode {
+		return ErrInvalidLoginCode
+	}
+
+	_ = lcs.redis.DelValue(NewLoginCodeAttemptsKey(authID))
+	return nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+)
+
+type (
+	// MFAChallenge is what a mfa token stands for, a login that passed the
+	// first factor and waits for the second.
+	MFAChallenge struct {
+		AuthID string `json:"auth_id"`
+		Nonce  string `json:"nonce,omitempty"`
+	}
+
+	IMFAChallengeService interface {
+		CreateChallenge(challenge MFAChallenge) (string, error)
+		GetChallenge(token string) (*MFAChallenge, error)
+		RegisterFailure(token string) error
+		ConsumeChallenge(token string) error
+	}
+
+	MFAChallengeService struct {
+		redis interfaces.Redis
+	}
+)
+
+const (
+	MFAChallengeLifetime = 5 * time.Minute
+
+	// a challenge is dropped after this many wrong codes, the user signs in
+	// again, which goes through the login lockout
+	MaxMFAChallengeAttempts = 5
+
+	mfaChallengeKey         = "mfa_challenge"
+	mfaChallengeAttemptsKey = "attempts"
+	mfaChallengeBytes       = 32
+)
+
+var (
+	ErrInvalidMFAChallenge = errors.New("invalid mfa token")
+)
+
+func NewMFAChallengeService(redis interfaces.Redis) *MFAChallengeService {
+	return &MFAChallengeService{
+		redis: redis,
+	}
+}
+
+// NewMFAChallengeKey only keeps a hash of the token, like authorization
+// codes.
+func NewMFAChallengeKey(token string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(mfaChallengeKey, fmt.Sprintf("%x", sha256.Sum256([]byte(token))))
+}
+
+func NewMFAChallengeAttemptsKey(token string) interfaces.RedisKey {
+	key := NewMFAChallengeKey(token)
+	return interfaces.NewRedisKey(key.Key, mfaChallengeAttemptsKey)
+}
+
+func (mcs *MFAChallengeService) CreateChallenge(challenge MFAChallenge) (string, error) {
+	rawToken := make([]byte, mfaChallengeBytes)
+
+	if _, err := rand.Read(rawToken); err != nil {
+		return "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(rawToken)
+
+	rawChallenge, err := json.Marshal(challenge)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := mcs.redis.SetValue(NewMFAChallengeKey(token), string(rawChallenge), MFAChallengeLifetime); err != nil {
+		return "", ErrCreatingToken
+	}
+
+	return token, nil
+}
+
+func (mcs *MFAChallengeService) GetChallenge(token string) (*MFAChallenge, error) {
+	rawChallenge, err := mcs.redis.GetValue(NewMFAChallengeKey(token))
+
+	if err != nil || rawChallenge == "" {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	var challenge MFAChallenge
+
+	if err := json.Unmarshal([]byte(rawChallenge), &challenge); err != nil {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	return &challenge, nil
+}
+
+// RegisterFailure counts a wrong code, the challenge is dropped once it had
+// MaxMFAChallengeAttempts of them.
+func (mcs *MFAChallengeService) RegisterFailure(token string) error {
+	attempts, err := mcs.redis.Increment(NewMFAChallengeAttemptsKey(token), MFAChallengeLifetime)
+
+	if err != nil {
+		return err
+	}
+
+	if attempts < MaxMFAChallengeAttempts {
+		return nil
+	}
+
+	return mcs.redis.DelValue(NewMFAChallengeKey(token), NewMFAChallengeAttemptsKey(token))
+}
+
+// ConsumeChallenge deletes the challenge once the second factor passed, only
+// the first of two concurrent exchanges gets through.
+func (mcs *MFAChallengeService) ConsumeChallenge(token string) error {
+	rawChallenge, err := mcs.redis.GetAndDelValue(NewMFAChallengeKey(token))
+
+	if err != nil || rawChallenge == "" {
+		return ErrInvalidMFAChallenge
+	}
+
+	_ = mcs.redis.DelValue(NewMFAChallengeAttemptsKey(token))
+	return nil
+}
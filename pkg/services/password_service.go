@@ -2,42 +2,162 @@ package services
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
+	"strings"
 
+	"github.com/BeatEcoprove/identityService/config"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-const SALT_COST = 11
+type (
+	// Argon2Params are the costs an argon2id hash was made with, they are
+	// encoded in the hash so they can be raised without breaking old ones.
+	Argon2Params struct {
+		Memory      uint32
+		Iterations  uint32
+		Parallelism uint8
+	}
+)
+
+const (
+	argon2idPrefix    = "$argon2id$"
+	argon2SaltLength  = 16
+	argon2KeyLength   = 32
+	maxArgon2Parallel = 255
+
+	// the OWASP baseline, 19 MiB and 2 passes on a single lane
+	defaultArgon2Memory      = 19 * 1024
+	defaultArgon2Iterations  = 2
+	defaultArgon2Parallelism = 1
+)
+
+var ErrUnsupportedHash = errors.New("unsupported password hash")
 
-func GenerateSalt(length int) (string, error) {
-	salt := make([]byte, length)
+// CurrentArgon2Params reads the costs new hashes are made with,
+// PASSWORD_HASH_MEMORY is in KiB.
+func CurrentArgon2Params() Argon2Params {
+	cfg := config.GetConfig()
+
+	params := Argon2Params{
+		Memory:      defaultArgon2Memory,
+		Iterations:  defaultArgon2Iterations,
+		Parallelism: defaultArgon2Parallelism,
+	}
+
+	if cfg.PASSWORD_HASH_MEMORY > 0 {
+		params.Memory = uint32(cfg.PASSWORD_HASH_MEMORY)
+	}
+
+	if cfg.PASSWORD_HASH_ITERATIONS > 0 {
+		params.Iterations = uint32(cfg.PASSWORD_HASH_ITERATIONS)
+	}
+
+	if cfg.PASSWORD_HASH_PARALLELISM > 0 {
+		params.Parallelism = uint8(min(cfg.PASSWORD_HASH_PARALLELISM, maxArgon2Parallel))
+	}
+
+	return params
+}
+
+// HashPassword hashes password with argon2id and encodes it the PHC way,
+// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>.
+func HashPassword(password string) (string, error) {
+	params := CurrentArgon2Params()
+	salt := make([]byte, argon2SaltLength)
 
 	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
 		return "", err
 	}
-	return base64.StdEncoding.EncodeToString(salt), nil
+
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, argon2KeyLength)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
 }
 
-func HashPassword(password string, salt string) (string, error) {
-	saltedPassword := password + salt
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(saltedPassword), bcrypt.DefaultCost)
+func decodeArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	var version int
+
+	parts := strings.Split(hash, "$")
+
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
 
 	if err != nil {
-		return "", err
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnsupportedHash
 	}
 
-	return string(hashedPassword), nil
+	return params, salt, key, nil
 }
 
+// CheckPasswordHash compares password with an encoded argon2id hash, the
+// salt is only used by legacy hashes, bcrypt over the password with the
+// salt appended.
 func CheckPasswordHash(password, salt, hash string) bool {
-	saltedPassword := password + salt
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(saltedPassword))
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password+salt)) == nil
+	}
+
+	return CheckArgon2idHash(password, hash)
+}
+
+// CheckArgon2idHash compares value with an encoded argon2id hash, secrets
+// that never had a legacy hash are checked with it directly.
+func CheckArgon2idHash(value, hash string) bool {
+	params, argonSalt, key, err := decodeArgon2id(hash)
+
+	if err != nil {
+		return false
+	}
+
+	candidate := argon2.IDKey([]byte(value), argonSalt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, candidate) == 1
+}
+
+// NeedsRehash reports hashes that aren't argon2id or were made with other
+// costs than the configured ones, they are replaced on the next login.
+func NeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2id(hash)
+
+	if err != nil {
+		return true
+	}
 
-	return err == nil
+	return params != CurrentArgon2Params()
 }
 
 func GeneratePassword(minPasswordLength, maxPasswordLength int64) (string, error) {
@@ -63,6 +183,35 @@ func GeneratePassword(minPasswordLength, maxPasswordLength int64) (string, error
 	return string(password), nil
 }
 
+// GenerateClientSecret returns a random secret for a registered client, it
+// is only shown once, the database keeps a hash.
+func GenerateClientSecret() (string, error) {
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// GenerateRecoveryCode returns a code like "k3m9x-7fq2w", 50 random bits
+// that are easy to copy down on paper.
+func GenerateRecoveryCode() (string, error) {
+	const recoveryCharset = "abcdefghijkmnpqrstuvwxyz23456789"
+	code := make([]byte, 10)
+
+	if _, err := rand.Read(code); err != nil {
+		return "", err
+	}
+
+	for i, b := range code {
+		code[i] = recoveryCharset[b%byte(len(recoveryCharset))]
+	}
+
+	return fmt.Sprintf("%s-%s", code[:5], code[5:]), nil
+}
+
 func GenerateCode() (string, error) {
 	var part2Str string
 	var alphanumericCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
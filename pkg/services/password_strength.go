@@ -0,0 +1,248 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// MaxStrengthScore is the score of a password that is very unlikely to be
+// guessed.
+const MaxStrengthScore = 4
+
+const minPatternLength = 3
+
+var (
+	// commonPasswords are ranked by how often they show up in leaks, the
+	// rank is how many guesses an attacker needs to reach them
+	commonPasswords = []string{
+		"password", "123456", "qwerty", "letmein", "welcome", "admin", "iloveyou",
+		"monkey", "dragon", "football", "baseball", "sunshine", "princess", "master",
+		"shadow", "superman", "trustno", "secret", "abc123", "hello", "freedom",
+		"whatever", "starwars", "login", "passw", "love", "test", "user", "guest",
+		"default", "changeme", "summer", "winter", "spring", "autumn", "soccer",
+		"hockey", "batman", "charlie", "michael", "jordan", "hunter", "ranger",
+		"thomas", "robert", "jennifer", "computer", "internet", "google", "pokemon",
+	}
+
+	keyboardRows = []string{
+		"1234567890",
+		"qwertyuiop",
+		"asdfghjkl",
+		"zxcvbnm",
+	}
+
+	leetSubstitutions = map[rune]rune{
+		'@': 'a', '4': 'a', '3': 'e', '1': 'i', '!': 'i',
+		'0': 'o', '$': 's', '5': 's', '7': 't',
+	}
+)
+
+// cardinality is the size of the alphabet the characters of password come
+// from, what a brute force attack would have to try per character.
+func cardinality(password string) float64 {
+	var size float64
+
+	if containsClass(password, unicode.IsDigit) {
+		size += 10
+	}
+
+	if containsClass(password, unicode.IsLower) {
+		size += 26
+	}
+
+	if containsClass(password, unicode.IsUpper) {
+		size += 26
+	}
+
+	if containsClass(password, isSymbol) {
+		size += 33
+	}
+
+	return max(size, 10)
+}
+
+func unleet(password []rune) []rune {
+	normalized := make([]rune, len(password))
+
+	for i, char := range password {
+		if substitute, ok := leetSubstitutions[char]; ok {
+			char = substitute
+		}
+
+		normalized[i] = char
+	}
+
+	return normalized
+}
+
+func hasPrefix(runes []rune, word string) bool {
+	return strings.HasPrefix(string(runes), word)
+}
+
+// dictionaryMatch finds the longest known word at the start of lowered, the
+// guesses are its rank, doubled when capitals or leet hide it.
+func dictionaryMatch(original, lowered []rune, words []string) (int, float64) {
+	normalized := unleet(lowered)
+	length, guesses := 0, 0.0
+
+	for rank, word := range words {
+		size := len([]rune(word))
+
+		if size < minPatternLength || size <= length {
+			continue
+		}
+
+		variations := 1.0
+
+		switch {
+		case hasPrefix(lowered, word):
+		case hasPrefix(normalized, word):
+			variations *= 2
+		default:
+			continue
+		}
+
+		if containsClass(string(original[:size]), unicode.IsUpper) {
+			variations *= 2
+		}
+
+		length, guesses = size, float64(rank+1)*variations
+	}
+
+	return length, guesses
+}
+
+// repeatMatch matches the same character typed over and over, "aaaa".
+func repeatMatch(lowered []rune) (int, float64) {
+	length := 1
+
+	for length < len(lowered) && lowered[length] == lowered[0] {
+		length++
+	}
+
+	if length < minPatternLength {
+		return 0, 0
+	}
+
+	return length, 10 * float64(length)
+}
+
+// sequenceMatch matches characters that go up or down one at a time, "abcd"
+// or "9876".
+func sequenceMatch(lowered []rune) (int, float64) {
+	if len(lowered) < minPatternLength {
+		return 0, 0
+	}
+
+	step := lowered[1] - lowered[0]
+
+	if step != 1 && step != -1 {
+		return 0, 0
+	}
+
+	length := 2
+
+	for length < len(lowered) && lowered[length]-lowered[length-1] == step {
+		length++
+	}
+
+	if length < minPatternLength {
+		return 0, 0
+	}
+
+	return length, 26 * float64(length)
+}
+
+// keyboardMatch matches runs of neighbour keys on a row, "asdf" or "poiu".
+func keyboardMatch(lowered []rune) (int, float64) {
+	length := 0
+
+	for _, row := range keyboardRows {
+		for _, keys := range []string{row, reverse(row)} {
+			for start := range keys {
+				size := 0
+
+				for size < len(lowered) && start+size < len(keys) && lowered[size] == rune(keys[start+size]) {
+					size++
+				}
+
+				length = max(length, size)
+			}
+		}
+	}
+
+	if length < minPatternLength {
+		return 0, 0
+	}
+
+	return length, 40 * float64(length)
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return string(runes)
+}
+
+// EstimateGuesses estimates, in log10, how many guesses an attacker needs.
+// It walks the password taking the longest pattern it finds, common
+// passwords, userInputs, repeats, sequences or keyboard runs, and charges
+// a full brute force for every character outside of them.
+func EstimateGuesses(password string, userInputs ...string) float64 {
+	original := []rune(password)
+	lowered := make([]rune, len(original))
+
+	for i, char := range original {
+		lowered[i] = unicode.ToLower(char)
+	}
+
+	bruteForce := math.Log10(cardinality(password))
+	inputWords := userInputWords(userInputs)
+
+	var guesses float64
+
+	for i := 0; i < len(lowered); {
+		length, patternGuesses := dictionaryMatch(original[i:], lowered[i:], commonPasswords)
+
+		// user inputs rank as high as the most common password
+		if size, matchGuesses := dictionaryMatch(original[i:], lowered[i:], inputWords); size > length {
+			length, patternGuesses = size, matchGuesses
+		}
+
+		for _, match := range []func([]rune) (int, float64){repeatMatch, sequenceMatch, keyboardMatch} {
+			if size, matchGuesses := match(lowered[i:]); size > length {
+				length, patternGuesses = size, matchGuesses
+			}
+		}
+
+		if length == 0 {
+			guesses += bruteForce
+			i++
+			continue
+		}
+
+		guesses += math.Log10(patternGuesses)
+		i += length
+	}
+
+	return guesses
+}
+
+// StrengthScore rates password from 0, guessed right away, to
+// MaxStrengthScore, out of reach of an offline attack.
+func StrengthScore(password string, userInputs ...string) int {
+	guesses := EstimateGuesses(password, userInputs...)
+
+	for score, threshold := range []float64{3, 6, 8, 10} {
+		if guesses < threshold {
+			return score
+		}
+	}
+
+	return MaxStrengthScore
+}
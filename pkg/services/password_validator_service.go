@@ -1,14 +1,50 @@
 package services
 
 import (
+	"fmt"
+	"strings"
 	"unicode"
 
+	"github.com/BeatEcoprove/identityService/config"
 	fails "github.com/BeatEcoprove/identityService/pkg/errors"
 )
 
-func containsNumber(s string) bool {
+type (
+	// PasswordPolicy holds the rules a new password must follow, every rule
+	// is checked so the user learns all of them at once.
+	PasswordPolicy struct {
+		MinLength     int
+		MaxLength     int
+		RequireDigit  bool
+		RequireUpper  bool
+		RequireLower  bool
+		RequireSymbol bool
+		BannedWords   []string
+
+		// MinStrength is the least StrengthScore accepted, below 0 it is off
+		MinStrength int
+	}
+)
+
+const (
+	PasswordDigitClass  = "digit"
+	PasswordUpperClass  = "upper"
+	PasswordLowerClass  = "lower"
+	PasswordSymbolClass = "symbol"
+
+	defaultPasswordMinLength = 8
+	defaultPasswordMaxLength = 64
+	defaultPasswordClasses   = "digit,upper,lower"
+	defaultPasswordStrength  = 2
+	defaultPasswordHistory   = 5
+
+	// user inputs shorter than this are too common to ban
+	minBannedInputLength = 3
+)
+
+func containsClass(s string, class func(rune) bool) bool {
 	for _, char := range s {
-		if unicode.IsDigit(char) {
+		if class(char) {
 			return true
 		}
 	}
@@ -16,46 +52,160 @@ func containsNumber(s string) bool {
 	return false
 }
 
-func containsCapitalLetter(s string) bool {
-	for _, char := range s {
-		if unicode.IsUpper(char) {
-			return true
+func isSymbol(char rune) bool {
+	return !unicode.IsLetter(char) && !unicode.IsDigit(char) && !unicode.IsSpace(char)
+}
+
+func splitList(value string) []string {
+	var items []string
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
 		}
 	}
 
-	return false
+	return items
 }
 
-func containsNonCapitalLetter(s string) bool {
-	for _, char := range s {
-		if unicode.IsLower(char) {
-			return true
+// LoadPasswordPolicy builds the policy from the PASSWORD_* settings, unset
+// ones keep their defaults.
+func LoadPasswordPolicy() *PasswordPolicy {
+	cfg := config.GetConfig()
+
+	policy := &PasswordPolicy{
+		MinLength:   defaultPasswordMinLength,
+		MaxLength:   defaultPasswordMaxLength,
+		BannedWords: splitList(cfg.PASSWORD_BANNED_WORDS),
+		MinStrength: defaultPasswordStrength,
+	}
+
+	if cfg.PASSWORD_MIN_LENGTH > 0 {
+		policy.MinLength = cfg.PASSWORD_MIN_LENGTH
+	}
+
+	if cfg.PASSWORD_MAX_LENGTH > 0 {
+		policy.MaxLength = cfg.PASSWORD_MAX_LENGTH
+	}
+
+	if cfg.PASSWORD_MIN_STRENGTH != 0 {
+		policy.MinStrength = min(cfg.PASSWORD_MIN_STRENGTH, MaxStrengthScore)
+	}
+
+	classes := cfg.PASSWORD_REQUIRED_CLASSES
+
+	if classes == "" {
+		classes = defaultPasswordClasses
+	}
+
+	for _, class := range splitList(classes) {
+		switch class {
+		case PasswordDigitClass:
+			policy.RequireDigit = true
+		case PasswordUpperClass:
+			policy.RequireUpper = true
+		case PasswordLowerClass:
+			policy.RequireLower = true
+		case PasswordSymbolClass:
+			policy.RequireSymbol = true
 		}
 	}
 
-	return false
+	return policy
 }
 
-func ValidatePassword(password string) error {
-	if password == "" {
-		return fails.PASSWORD_PROVIDE
+// PasswordHistorySize is how many of the last passwords, the current one
+// included, can't be chosen again, 0 when reuse is allowed.
+func PasswordHistorySize() int {
+	size := config.GetConfig().PASSWORD_HISTORY_SIZE
+
+	switch {
+	case size < 0:
+		return 0
+	case size == 0:
+		return defaultPasswordHistory
+	}
+
+	return size
+}
+
+// userInputWords splits user inputs such as the email into the words a
+// password shouldn't contain, "john.doe@beat.pt" gives john, doe and beat.
+func userInputWords(userInputs []string) []string {
+	var words []string
+
+	for _, input := range userInputs {
+		// the top level domain is in too many words
+		if at := strings.LastIndex(input, "@"); at >= 0 {
+			if dot := strings.LastIndex(input, "."); dot > at {
+				input = input[:dot]
+			}
+		}
+
+		for _, word := range strings.FieldsFunc(strings.ToLower(input), func(char rune) bool {
+			return !unicode.IsLetter(char) && !unicode.IsDigit(char)
+		}) {
+			if len(word) >= minBannedInputLength {
+				words = append(words, word)
+			}
+		}
 	}
 
-	if len(password) < 6 || len(password) > 16 {
-		return fails.PASSWORD_BTW_6_16
+	return words
+}
+
+// Validate checks password against every rule, userInputs are things the
+// user is known by, like the email, that the password can't contain.
+func (p *PasswordPolicy) Validate(password string, userInputs ...string) error {
+	violations := make(map[string]string)
+	length := len([]rune(password))
+
+	if length < p.MinLength {
+		violations["min_length"] = fmt.Sprintf("Password must be at least %d characters long.", p.MinLength)
 	}
 
-	if !containsNumber(password) {
-		return fails.PASSWORD_MUST_CONTAIN_ONE_NUMBER
+	if length > p.MaxLength {
+		violations["max_length"] = fmt.Sprintf("Password must be at most %d characters long.", p.MaxLength)
 	}
 
-	if !containsCapitalLetter(password) {
-		return fails.PASSWORD_MUST_CONTAIN_ONE_CAPITAL
+	if p.RequireDigit && !containsClass(password, unicode.IsDigit) {
+		violations[PasswordDigitClass] = "Password must contain a number."
 	}
 
-	if !containsNonCapitalLetter(password) {
-		return fails.PASSWORD_MUST_CONTAIN_NON_CAPITAL
+	if p.RequireUpper && !containsClass(password, unicode.IsUpper) {
+		violations[PasswordUpperClass] = "Password must contain a capital letter."
+	}
+
+	if p.RequireLower && !containsClass(password, unicode.IsLower) {
+		violations[PasswordLowerClass] = "Password must contain a lowercase letter."
+	}
+
+	if p.RequireSymbol && !containsClass(password, isSymbol) {
+		violations[PasswordSymbolClass] = "Password must contain a symbol."
+	}
+
+	lowered := strings.ToLower(password)
+	inputWords := userInputWords(userInputs)
+
+	for _, word := range append(inputWords, p.BannedWords...) {
+		if strings.Contains(lowered, word) {
+			violations["banned"] = "Password can't contain your email or common words."
+			break
+		}
+	}
+
+	if p.MinStrength >= 0 && password != "" && StrengthScore(password, inputWords...) < p.MinStrength {
+		violations["strength"] = "Password is too easy to guess."
+	}
+
+	if len(violations) > 0 {
+		return fails.WeakPassword(violations)
 	}
 
 	return nil
 }
+
+// ValidatePassword checks a new password against the configured policy.
+func ValidatePassword(password string, userInputs ...string) error {
+	return LoadPasswordPolicy().Validate(password, userInputs...)
+}
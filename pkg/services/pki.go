@@ -1,34 +1,39 @@
 package services
 
 import (
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/sha256"
-	"crypto/x509"
-	"encoding/base64"
+	"crypto"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"math/big"
 	"os"
 	"path"
+	"slices"
+	"sort"
+	"sync"
+	"time"
 )
 
 const (
-	PKI_PATH       = "www/keys"
-	WELL_KWOWN     = "www/.well-known"
-	JWKS_FILE_NAME = "jwks.json"
-	KeyBits        = 2048
+	PKI_PATH           = "www/keys"
+	WELL_KWOWN         = "www/.well-known"
+	JWKS_FILE_NAME     = "jwks.json"
+	KEYS_MANIFEST_NAME = "keys.json"
+	KeyBits            = 2048
 )
 
 var (
-	private_key_path = PKI_PATH + "/" + "private.pem"
-	public_key_path  = PKI_PATH + "/" + "public.pem"
+	// keys generated before rotation existed, imported on first start
+	legacy_private_key_path = PKI_PATH + "/" + "private.pem"
+
+	manifest_path = path.Join(PKI_PATH, KEYS_MANIFEST_NAME)
+	jwks_path     = path.Join(WELL_KWOWN, JWKS_FILE_NAME)
 
 	ErrFailedToReadJWKS = errors.New("failed to read JWKS file")
 	ErrUnmarshalJWKS    = errors.New("failed to unmarshal JWKS data")
 	ErrFailedToOpenKey  = errors.New("failed to open file for writing key")
+	ErrNoSigningKey     = errors.New("no active signing key")
+	ErrUnknownKid       = errors.New("unknown 'kid' in token header")
 )
 
 type (
@@ -45,202 +50,512 @@ type (
 		Kty string `json:"kty"`
 		Kid string `json:"kid"`
 		Use string `json:"use"`
-		N   string `json:"n"`
-		E   string `json:"e"`
 		Alg string `json:"alg"`
+		N   string `json:"n,omitempty"`
+		E   string `json:"e,omitempty"`
+		Crv string `json:"crv,omitempty"`
+		X   string `json:"x,omitempty"`
+		Y   string `json:"y,omitempty"`
+	}
+
+	// SigningKey is one entry of the key ring. A key is published in the JWKS
+	// as soon as it is created, but only used for signing from ActivatesAt on,
+	// so relying parties get the chance to refresh their cached JWKS first.
+	SigningKey struct {
+		Kid         string    `json:"kid"`
+		Alg         string    `json:"alg"`
+		CreatedAt   time.Time `json:"created_at"`
+		ActivatesAt time.Time `json:"activates_at"`
+
+		privateKey crypto.Signer
+	}
+
+	keyManifest struct {
+		Keys []*SigningKey `json:"keys"`
+	}
+
+	// KeyRing holds every key that may still verify a token, ordered by
+	// activation time. The newest active key signs, the others only verify
+	// until the tokens they signed have expired.
+	KeyRing struct {
+		mu   sync.RWMutex
+		keys []*SigningKey
 	}
 )
 
-var storedJwks *JWKS
+var keyRing = &KeyRing{}
 
-func MustCreatePKI() bool {
-	return keysDirExists(PKI_PATH)
+func (sk *SigningKey) PublicKey() crypto.PublicKey {
+	return sk.privateKey.Public()
 }
 
-func ReadKeys() ([]byte, []byte, error) {
-	privKey, err := os.ReadFile(private_key_path)
+func (sk *SigningKey) IsActive(now time.Time) bool {
+	return !sk.ActivatesAt.After(now)
+}
 
-	if err != nil {
-		return nil, nil, err
+func (kr *KeyRing) set(keys []*SigningKey) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].ActivatesAt.Before(keys[j].ActivatesAt)
+	})
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	kr.keys = keys
+}
+
+func (kr *KeyRing) snapshot() []*SigningKey {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	return append([]*SigningKey(nil), kr.keys...)
+}
+
+// SigningKey returns the most recently activated key.
+func (kr *KeyRing) SigningKey(now time.Time) (*SigningKey, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	for i := len(kr.keys) - 1; i >= 0; i-- {
+		if kr.keys[i].IsActive(now) {
+			return kr.keys[i], nil
+		}
 	}
 
-	pubKey, err := os.ReadFile(public_key_path)
+	return nil, ErrNoSigningKey
+}
 
-	if err != nil {
-		return nil, nil, err
+func (kr *KeyRing) Lookup(kid string) (*SigningKey, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	for _, key := range kr.keys {
+		if key.Kid == kid {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+// expired drops the keys whose successor has been signing for longer than
+// the lifetime of any token, nothing signed by them can still be valid.
+func expired(keys []*SigningKey, now time.Time) ([]*SigningKey, []*SigningKey) {
+	maxTokenLifetime := max(AccessTokenLifetime(), RefreshTokenLifetime())
+
+	var kept, dropped []*SigningKey
+
+	for i, key := range keys {
+		if i+1 < len(keys) && keys[i+1].IsActive(now) && now.Sub(keys[i+1].ActivatesAt) > maxTokenLifetime {
+			dropped = append(dropped, key)
+			continue
+		}
+
+		kept = append(kept, key)
 	}
 
-	return privKey, pubKey, nil
+	return kept, dropped
 }
 
-func base64URLUInt(i *big.Int) string {
-	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+func keyFilePath(kid string) string {
+	return path.Join(PKI_PATH, kid+".pem")
 }
 
-func loadJWKS(jwksPath string) (*JWKS, error) {
-	if storedJwks != nil {
-		return storedJwks, nil
+// CreateJWKS builds the key set out of every key in the ring, including the
+// ones that are not active yet.
+func CreateJWKS() (*JWKS, error) {
+	keys := keyRing.snapshot()
+
+	if len(keys) == 0 {
+		return nil, ErrNoSigningKey
 	}
 
-	openJwksFile, err := os.ReadFile(jwksPath)
-	if err != nil {
-		return nil, ErrFailedToReadJWKS
+	jwks := &JWKS{
+		Keys: make([]JWK, 0, len(keys)),
 	}
 
-	var jwksData JWKS
+	for _, key := range keys {
+		jwk, err := newJWK(key)
 
-	err = json.Unmarshal(openJwksFile, &jwksData)
+		if err != nil {
+			return nil, err
+		}
 
-	if err != nil {
-		return nil, ErrUnmarshalJWKS
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks, nil
+}
+
+func NewJWKS() (*JWKS, error) {
+	return CreateJWKS()
+}
+
+// SigningAlgorithms lists the algorithms of the keys in the ring.
+func SigningAlgorithms() []string {
+	algs := make([]string, 0)
+
+	for _, key := range keyRing.snapshot() {
+		if !slices.Contains(algs, key.Alg) {
+			algs = append(algs, key.Alg)
+		}
 	}
 
-	storedJwks = &jwksData
-	return &jwksData, nil
+	return algs
 }
 
 func storeJwks(jwks *JWKS, jwksPath string) error {
-	jwksFile, err := os.Create(jwksPath)
+	if !keysDirExists(WELL_KWOWN) {
+		if err := os.MkdirAll(WELL_KWOWN, 0755); err != nil {
+			return err
+		}
+	}
+
+	jwksMarshal, err := json.Marshal(jwks)
 
 	if err != nil {
 		return err
 	}
 
-	defer jwksFile.Close()
+	return writeFileAtomic(jwksPath, jwksMarshal, 0644)
+}
 
-	jwksMarshal, err := json.Marshal(jwks)
+func keysDirExists(path string) bool {
+	info, err := os.Stat(path)
 
 	if err != nil {
+		return false
+	}
+
+	return info.IsDir()
+}
+
+// writeFileAtomic makes sure other instances sharing the volume never read
+// a half written manifest or JWKS.
+func writeFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	tmpPath := filePath + ".tmp"
+
+	if err := os.WriteFile(tmpPath, data, perm); err != nil {
 		return err
 	}
 
-	_, err = jwksFile.Write(jwksMarshal)
+	return os.Rename(tmpPath, filePath)
+}
+
+func saveKey(key *pem.Block, filePath string) error {
+	keyFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 
 	if err != nil {
-		panic(err)
+		return ErrFailedToOpenKey
 	}
 
-	return nil
+	defer keyFile.Close()
+
+	return pem.Encode(keyFile, key)
 }
 
-func generateKid() string {
-	pubKeyBytes := x509.MarshalPKCS1PublicKey(PubKey)
-	return fmt.Sprintf("%x", sha256.Sum256(pubKeyBytes))
+func readPrivateKey(filePath string) (crypto.Signer, error) {
+	rawKey, err := os.ReadFile(filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePrivateKey(rawKey)
 }
 
-func CreateJWKS() (*JWKS, error) {
-	var jwks *JWKS
+// CreatePKI generates a key pair for the configured signing algorithm.
+func CreatePKI() (*KeyPair, error) {
+	alg, err := SigningAlgorithm()
 
-	jwk := JWK{
-		Kty: "RSA",
-		Kid: generateKid(),
-		N:   base64URLUInt(PubKey.N),
-		E:   base64URLUInt(big.NewInt(int64(PubKey.E))),
-		Use: "sig",
-		Alg: "RS256",
+	if err != nil {
+		return nil, err
 	}
 
-	jwks = &JWKS{
-		Keys: []JWK{jwk},
+	privateKey, err := generatePrivateKey(alg)
+
+	if err != nil {
+		return nil, err
 	}
 
-	storedJwks = jwks
-	return jwks, nil
+	privateKeyPEM, err := encodePrivateKey(privateKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	publicKeyPEM, err := encodePublicKey(privateKey.Public())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{
+		PrivateKey: privateKeyPEM,
+		PublicKey:  publicKeyPEM,
+	}, nil
 }
 
-func NewJWKS() (*JWKS, error) {
-	jwksPath := path.Join(WELL_KWOWN, JWKS_FILE_NAME)
+func newSigningKey(privateKey crypto.Signer, alg string, activatesAt time.Time) (*SigningKey, error) {
+	kid, err := generateKid(privateKey.Public())
 
-	if !keysDirExists(WELL_KWOWN) {
-		if err := os.MkdirAll(WELL_KWOWN, 0755); err != nil {
-			return nil, err
-		}
+	if err != nil {
+		return nil, err
 	}
 
-	jwks, err := loadJWKS(jwksPath)
+	return &SigningKey{
+		Kid:         kid,
+		Alg:         alg,
+		CreatedAt:   time.Now().UTC(),
+		ActivatesAt: activatesAt.UTC(),
+		privateKey:  privateKey,
+	}, nil
+}
 
-	if err == nil {
-		return jwks, nil
+func storeSigningKey(key *SigningKey) error {
+	privateKeyPEM, err := encodePrivateKey(key.privateKey)
+
+	if err != nil {
+		return err
 	}
 
-	jwks, err = CreateJWKS()
+	return saveKey(privateKeyPEM, keyFilePath(key.Kid))
+}
+
+func generateSigningKey(alg string, activatesAt time.Time) (*SigningKey, error) {
+	privateKey, err := generatePrivateKey(alg)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if err := storeJwks(jwks, jwksPath); err != nil {
+	key, err := newSigningKey(privateKey, alg, activatesAt)
+
+	if err != nil {
 		return nil, err
 	}
 
-	return jwks, nil
+	if err := storeSigningKey(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
 }
 
-func GenerateServerPKI() error {
-	if !keysDirExists(PKI_PATH) {
-		if err := os.MkdirAll(PKI_PATH, 0755); err != nil {
-			return err
-		}
+// LoadKeys replaces the key ring with a single, already active key pair.
+func LoadKeys(publicKey, privateKey []byte) error {
+	loadPrivKey, err := parsePrivateKey(privateKey)
+
+	if err != nil {
+		return err
 	}
 
-	keys, err := CreatePKI()
+	loadPubKey, err := parsePublicKey(publicKey)
 
 	if err != nil {
 		return err
 	}
 
-	if err := saveKey(keys.PrivateKey, private_key_path); err != nil {
+	if pubKey, ok := loadPubKey.(interface{ Equal(crypto.PublicKey) bool }); !ok || !pubKey.Equal(loadPrivKey.Public()) {
+		return errors.New("public key does not match private key")
+	}
+
+	alg, err := algorithmFor(loadPrivKey)
+
+	if err != nil {
 		return err
 	}
 
-	if err := saveKey(keys.PublicKey, public_key_path); err != nil {
+	key, err := newSigningKey(loadPrivKey, alg, time.Now())
+
+	if err != nil {
 		return err
 	}
 
+	keyRing.set([]*SigningKey{key})
 	return nil
 }
 
-func keysDirExists(path string) bool {
-	info, err := os.Stat(path)
+func readManifest() (*keyManifest, error) {
+	rawManifest, err := os.ReadFile(manifest_path)
 
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	return info.IsDir()
+	var manifest keyManifest
+
+	if err := json.Unmarshal(rawManifest, &manifest); err != nil {
+		return nil, err
+	}
+
+	for _, key := range manifest.Keys {
+		privateKey, err := readPrivateKey(keyFilePath(key.Kid))
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key %s: %w", key.Kid, err)
+		}
+
+		key.privateKey = privateKey
+	}
+
+	return &manifest, nil
 }
 
-func saveKey(key *pem.Block, filePath string) error {
-	keyFile, err := os.Create(filePath)
+// persistKeys stores the manifest, refreshes the published JWKS and swaps
+// the in memory ring.
+func persistKeys(keys []*SigningKey) error {
+	rawManifest, err := json.MarshalIndent(keyManifest{Keys: keys}, "", "  ")
 
 	if err != nil {
-		return ErrFailedToOpenKey
+		return err
 	}
 
-	defer keyFile.Close()
+	if err := writeFileAtomic(manifest_path, rawManifest, 0600); err != nil {
+		return err
+	}
 
-	return pem.Encode(keyFile, key)
+	keyRing.set(keys)
+
+	jwks, err := CreateJWKS()
+
+	if err != nil {
+		return err
+	}
+
+	return storeJwks(jwks, jwks_path)
 }
 
-func CreatePKI() (*KeyPair, error) {
-	privateKey, err := rsa.GenerateKey(rand.Reader, KeyBits)
+// initialKeys imports the single key pair used before rotation existed, so
+// tokens already out there keep verifying, or creates a fresh active key.
+func initialKeys(alg string) ([]*SigningKey, error) {
+	privateKey, err := readPrivateKey(legacy_private_key_path)
+
+	if err != nil {
+		key, err := generateSigningKey(alg, time.Now())
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []*SigningKey{key}, nil
+	}
+
+	// the legacy key was always used with RS256
+	key, err := newSigningKey(privateKey, RS256, time.Now())
 
 	if err != nil {
 		return nil, err
 	}
 
-	privateKeyPEM := &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	if err := storeSigningKey(key); err != nil {
+		return nil, err
 	}
 
-	publicKeyPEM := &pem.Block{
-		Type:  "RSA PUBLIC KEY",
-		Bytes: x509.MarshalPKCS1PublicKey(&privateKey.PublicKey),
+	return []*SigningKey{key}, nil
+}
+
+// LoadKeyRing reads the key ring from disk, creating it on first start, and
+// publishes the JWKS. Calling it again picks up keys rotated by another
+// process.
+func LoadKeyRing() error {
+	alg, err := SigningAlgorithm()
+
+	if err != nil {
+		return err
 	}
 
-	return &KeyPair{
-		PrivateKey: privateKeyPEM,
-		PublicKey:  publicKeyPEM,
-	}, nil
+	if !keysDirExists(PKI_PATH) {
+		if err := os.MkdirAll(PKI_PATH, 0700); err != nil {
+			return err
+		}
+	}
+
+	manifest, err := readManifest()
+
+	if err == nil {
+		keyRing.set(manifest.Keys)
+
+		jwks, err := CreateJWKS()
+
+		if err != nil {
+			return err
+		}
+
+		return storeJwks(jwks, jwks_path)
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	keys, err := initialKeys(alg)
+
+	if err != nil {
+		return err
+	}
+
+	return persistKeys(keys)
+}
+
+// RotateKeys adds a new key that is published right away and starts signing
+// once publishLead has passed.
+func RotateKeys(publishLead time.Duration) (*SigningKey, error) {
+	alg, err := SigningAlgorithm()
+
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := generateSigningKey(alg, time.Now().Add(publishLead))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := persistKeys(append(keyRing.snapshot(), key)); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// PruneKeys removes the keys that can no longer have valid tokens.
+func PruneKeys() error {
+	kept, dropped := expired(keyRing.snapshot(), time.Now())
+
+	if len(dropped) == 0 {
+		return nil
+	}
+
+	if err := persistKeys(kept); err != nil {
+		return err
+	}
+
+	for _, key := range dropped {
+		if err := os.Remove(keyFilePath(key.Kid)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RotationDue tells whether the newest key, active or pending, is older than
+// the rotation period or was made for another algorithm than the configured
+// one.
+func RotationDue(period time.Duration) bool {
+	keys := keyRing.snapshot()
+
+	if len(keys) == 0 {
+		return false
+	}
+
+	newest := keys[len(keys)-1]
+
+	if alg, err := SigningAlgorithm(); err == nil && newest.Alg != alg {
+		return true
+	}
+
+	return period > 0 && time.Since(newest.CreatedAt) >= period
 }
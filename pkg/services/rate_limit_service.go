@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+)
+
+type (
+	// RateLimit allows Requests per Window, zero requests disables it.
+	RateLimit struct {
+		Requests int64
+		Window   time.Duration
+	}
+
+	IRateLimiter interface {
+		Allow(key interfaces.RedisKey, limit RateLimit) (time.Duration, error)
+	}
+
+	RateLimiter struct {
+		redis interfaces.Redis
+	}
+)
+
+const rateLimitKey = "rate_limit"
+
+var (
+	ErrInvalidRateLimit = errors.New("rate limit must look like <requests>/<window>, e.g. 10/1m")
+)
+
+func NewRateLimiter(redis interfaces.Redis) *RateLimiter {
+	return &RateLimiter{
+		redis: redis,
+	}
+}
+
+func NewRateLimitKey(values ...string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(append([]string{rateLimitKey}, values...)...)
+}
+
+// ParseRateLimit reads a "<requests>/<window>" pair like "10/1m", a plain
+// "0" disables the limit.
+func ParseRateLimit(raw string) (RateLimit, error) {
+	if strings.TrimSpace(raw) == "0" {
+		return RateLimit{}, nil
+	}
+
+	rawRequests, rawWindow, ok := strings.Cut(raw, "/")
+
+	if !ok {
+		return RateLimit{}, ErrInvalidRateLimit
+	}
+
+	requests, err := strconv.ParseInt(strings.TrimSpace(rawRequests), 10, 64)
+
+	if err != nil || requests < 0 {
+		return RateLimit{}, ErrInvalidRateLimit
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(rawWindow))
+
+	if err != nil || window < time.Second {
+		return RateLimit{}, ErrInvalidRateLimit
+	}
+
+	return RateLimit{Requests: requests, Window: window}, nil
+}
+
+func (rl RateLimit) Enabled() bool {
+	return rl.Requests > 0 && rl.Window > 0
+}
+
+// Allow counts a request against key with a sliding window: the count of the
+// current fixed window plus the count of the previous one, weighted by how
+// much of it still overlaps the sliding window. A zero duration means the
+// request is allowed, otherwise it is how long to wait before retrying.
+func (rlr *RateLimiter) Allow(key interfaces.RedisKey, limit RateLimit) (time.Duration, error) {
+	if !limit.Enabled() {
+		return 0, nil
+	}
+
+	now := time.Now()
+	window := now.UnixNano() / int64(limit.Window)
+	elapsed := time.Duration(now.UnixNano() % int64(limit.Window))
+
+	current, err := rlr.redis.Increment(windowKey(key, window), 2*limit.Window)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var previous int64
+
+	if raw, err := rlr.redis.GetValue(windowKey(key, window-1)); err == nil {
+		previous, _ = strconv.ParseInt(raw, 10, 64)
+	}
+
+	overlap := 1 - float64(elapsed)/float64(limit.Window)
+
+	if float64(previous)*overlap+float64(current) <= float64(limit.Requests) {
+		return 0, nil
+	}
+
+	return retryAfter(previous, current, limit, elapsed), nil
+}
+
+func windowKey(key interfaces.RedisKey, window int64) interfaces.RedisKey {
+	return interfaces.NewRedisKey(key.Key, strconv.FormatInt(window, 10))
+}
+
+// retryAfter is how long until the sliding count makes room for one more
+// request, either once the previous window weighs little enough or, when the
+// current window is already full, part way into the next one. Rejected
+// requests are counted too, a client that doesn't wait only waits longer.
+func retryAfter(previous, current int64, limit RateLimit, elapsed time.Duration) time.Duration {
+	var wait time.Duration
+
+	if room := limit.Requests - current - 1; room >= 0 && previous > 0 {
+		shift := 1 - float64(room)/float64(previous)
+		wait = time.Duration(shift*float64(limit.Window)) - elapsed
+	} else {
+		shift := math.Max(0, 1-float64(limit.Requests-1)/float64(current))
+		wait = limit.Window - elapsed + time.Duration(shift*float64(limit.Window))
+	}
+
+	// Retry-After is in whole seconds, round up so retrying on time works
+	return max((wait + time.Second - 1).Truncate(time.Second), time.Second)
+}
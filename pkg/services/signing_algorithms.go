@@ -0,0 +1,280 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/BeatEcoprove/identityService/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	RS256 = "RS256"
+	PS256 = "PS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+
+	DefaultSigningAlg = RS256
+)
+
+var (
+	SupportedSigningAlgs = []string{RS256, PS256, ES256, EdDSA}
+
+	ErrUnsupportedSigningAlg = errors.New("unsupported signing algorithm")
+	ErrUnsupportedKey        = errors.New("unsupported key type")
+)
+
+// SigningAlgorithm is the algorithm new keys are generated for, existing keys
+// keep the one they were created with.
+func SigningAlgorithm() (string, error) {
+	alg := config.GetConfig().JWT_SIGNING_ALG
+
+	if alg == "" {
+		return DefaultSigningAlg, nil
+	}
+
+	if !slices.Contains(SupportedSigningAlgs, alg) {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedSigningAlg, alg)
+	}
+
+	return alg, nil
+}
+
+func generatePrivateKey(alg string) (crypto.Signer, error) {
+	switch alg {
+	case RS256, PS256:
+		return rsa.GenerateKey(rand.Reader, KeyBits)
+	case ES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case EdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedSigningAlg, alg)
+}
+
+// algorithmFor picks the algorithm of an imported key, RSA keys follow the
+// configured algorithm when it is an RSA one.
+func algorithmFor(privateKey crypto.Signer) (string, error) {
+	switch privateKey.(type) {
+	case *rsa.PrivateKey:
+		if alg, err := SigningAlgorithm(); err == nil && alg == PS256 {
+			return PS256, nil
+		}
+
+		return RS256, nil
+	case *ecdsa.PrivateKey:
+		return ES256, nil
+	case ed25519.PrivateKey:
+		return EdDSA, nil
+	}
+
+	return "", ErrUnsupportedKey
+}
+
+func encodePrivateKey(privateKey crypto.Signer) (*pem.Block, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
+}
+
+func encodePublicKey(publicKey crypto.PublicKey) (*pem.Block, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &pem.Block{Type: "PUBLIC KEY", Bytes: der}, nil
+}
+
+// parsePrivateKey accepts PKCS8 as well as the PKCS1 and SEC1 blocks written
+// by older versions of the service.
+func parsePrivateKey(rawKey []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(rawKey)
+
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+
+	return signer, nil
+}
+
+func parsePublicKey(rawKey []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(rawKey)
+
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// generateKid hashes RSA keys in PKCS1 form, as the service always did, so
+// the kid of keys imported from before rotation doesn't change.
+func generateKid(publicKey crypto.PublicKey) (string, error) {
+	var (
+		pubKeyBytes []byte
+		err         error
+	)
+
+	if rsaKey, ok := publicKey.(*rsa.PublicKey); ok {
+		pubKeyBytes = x509.MarshalPKCS1PublicKey(rsaKey)
+	} else {
+		pubKeyBytes, err = x509.MarshalPKIXPublicKey(publicKey)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(pubKeyBytes)), nil
+}
+
+func base64URLUInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func newJWK(key *SigningKey) (JWK, error) {
+	jwk := JWK{
+		Kid: key.Kid,
+		Use: "sig",
+		Alg: key.Alg,
+	}
+
+	switch publicKey := key.PublicKey().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64URLUInt(publicKey.N)
+		jwk.E = base64URLUInt(big.NewInt(int64(publicKey.E)))
+	case *ecdsa.PublicKey:
+		ecdhKey, err := publicKey.ECDH()
+
+		if err != nil {
+			return JWK{}, err
+		}
+
+		// uncompressed point 0x04 || x || y, both padded to the curve size
+		// as RFC 7518 section 6.2.1.2 requires
+		point := ecdhKey.Bytes()[1:]
+		size := len(point) / 2
+
+		jwk.Kty = "EC"
+		jwk.Crv = publicKey.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[:size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[size:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	default:
+		return JWK{}, ErrUnsupportedKey
+	}
+
+	return jwk, nil
+}
+
+// parseJWK is the reverse of newJWK, it reads the keys published by other
+// issuers.
+func parseJWK(jwk JWK) (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decode(jwk.N)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decode(jwk.E)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if jwk.Crv != elliptic.P256().Params().Name {
+			return nil, ErrUnsupportedKey
+		}
+
+		x, err := decode(jwk.X)
+
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decode(jwk.Y)
+
+		if err != nil {
+			return nil, err
+		}
+
+		publicKey := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+
+		// ECDH refuses points that are not on the curve
+		if _, err := publicKey.ECDH(); err != nil {
+			return nil, ErrUnsupportedKey
+		}
+
+		return publicKey, nil
+	case "OKP":
+		x, err := decode(jwk.X)
+
+		if err != nil || jwk.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, ErrUnsupportedKey
+}
@@ -1,19 +1,57 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
 	"log"
+	"slices"
+	"sort"
 	"time"
 
 	"github.com/BeatEcoprove/identityService/config"
 	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+	"github.com/google/uuid"
 )
 
 type (
 	TokenKey string
 
+	DeviceInfo struct {
+		Name      string
+		IP        string
+		UserAgent string
+	}
+
+	Session struct {
+		ID         string    `json:"id"`
+		Device     string    `json:"device"`
+		IP         string    `json:"ip"`
+		UserAgent  string    `json:"user_agent"`
+		CreatedAt  time.Time `json:"created_at"`
+		LastUsedAt time.Time `json:"last_used_at"`
+
+		// the oauth client the session was authorized for, kept for the
+		// id_tokens of later refreshes
+		ClientID string `json:"client_id,omitempty"`
+	}
+
+	// AuthenticationTokens is everything issued for a session on login or
+	// refresh.
+	AuthenticationTokens struct {
+		SessionID string
+		Access    *JwtToken
+		Refresh   *JwtToken
+		ID        *JwtToken
+	}
+
 	ITokenService interface {
-		CreateAuthenticationTokens(payload TokenPayload) (*JwtToken, *JwtToken, error)
-		ValidateToken(authID, token string, key TokenKey) error
+		CreateAuthenticationTokens(payload TokenPayload) (*AuthenticationTokens, error)
+		CreateMachineToken(clientID string, scope []string) (*JwtToken, error)
+		ValidateToken(authID, sessionID, token string, key TokenKey) error
+		ConsumeRefreshToken(authID, sessionID, token string) error
+		GetSessions(authID string) ([]Session, error)
+		RevokeSession(authID, sessionID string) error
+		RevokeSessions(authID string, except ...string) error
 	}
 
 	TokenService struct {
@@ -24,6 +62,14 @@ type (
 const (
 	AccessTokenKey  TokenKey = "access"
 	RefreshTokenKey TokenKey = "refresh"
+
+	sessionKey     = "session"
+	sessionsSetKey = "sessions"
+)
+
+var (
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrRefreshTokenReused = errors.New("refresh token was already used")
 )
 
 func NewTokenService(redis interfaces.Redis) *TokenService {
@@ -32,12 +78,30 @@ func NewTokenService(redis interfaces.Redis) *TokenService {
 	}
 }
 
-func NewAccessTokenKey(userId string) interfaces.RedisKey {
-	return interfaces.NewRedisKey(userId, string(AccessTokenKey))
+// AccessTokenLifetime is configured in minutes.
+func AccessTokenLifetime() time.Duration {
+	return time.Duration(config.GetConfig().JWT_ACCESS_EXPIRED) * time.Minute
+}
+
+// RefreshTokenLifetime is configured in months.
+func RefreshTokenLifetime() time.Duration {
+	return time.Duration(config.GetConfig().JWT_REFRESH_EXPIRED) * time.Hour * 24 * 30
 }
 
-func NewRefreshTokenKey(userId string) interfaces.RedisKey {
-	return interfaces.NewRedisKey(userId, string(RefreshTokenKey))
+func NewAccessTokenKey(userId, sessionId string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(userId, sessionId, string(AccessTokenKey))
+}
+
+func NewRefreshTokenKey(userId, sessionId string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(userId, sessionId, string(RefreshTokenKey))
+}
+
+func NewSessionKey(userId, sessionId string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(userId, sessionId, sessionKey)
+}
+
+func NewSessionsKey(userId string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(userId, sessionsSetKey)
 }
 
 func generateAuthenticationTokens(payload TokenPayload, accessTokenExp, refreshTokenExp time.Duration) (*JwtToken, *JwtToken, error) {
@@ -62,14 +126,18 @@ func generateAuthenticationTokens(payload TokenPayload, accessTokenExp, refreshT
 	return accessToken, refreshToken, nil
 }
 
-func (ts *TokenService) ValidateToken(authId, token string, key TokenKey) error {
+func (ts *TokenService) ValidateToken(authId, sessionId, token string, key TokenKey) error {
 	var tokenKey interfaces.RedisKey
 
+	if sessionId == "" {
+		return ErrInvalidToken
+	}
+
 	switch key {
 	case AccessTokenKey:
-		tokenKey = NewAccessTokenKey(authId)
+		tokenKey = NewAccessTokenKey(authId, sessionId)
 	case RefreshTokenKey:
-		tokenKey = NewRefreshTokenKey(authId)
+		tokenKey = NewRefreshTokenKey(authId, sessionId)
 	default:
 		return ErrInvalidToken
 	}
@@ -87,30 +155,226 @@ func (ts *TokenService) ValidateToken(authId, token string, key TokenKey) error
 	return nil
 }
 
-func (ts *TokenService) CreateAuthenticationTokens(payload TokenPayload) (*JwtToken, *JwtToken, error) {
-	env := config.GetConfig()
+// ConsumeRefreshToken makes refresh tokens single-use. The stored token of the
+// session is taken out of redis, so a new pair must be issued right after.
+// Presenting a token of the session that was already rotated means it leaked,
+// so the whole session is revoked and ErrRefreshTokenReused is returned.
+func (ts *TokenService) ConsumeRefreshToken(authId, sessionId, token string) error {
+	if sessionId == "" {
+		return ErrInvalidToken
+	}
+
+	storedToken, err := ts.redis.GetAndDelValue(NewRefreshTokenKey(authId, sessionId))
+
+	if err != nil || storedToken == "" {
+		return ErrInvalidToken
+	}
+
+	if storedToken != token {
+		if err := ts.deleteSession(authId, sessionId); err != nil {
+			log.Printf("failed to revoke session %s: %s", sessionId, err.Error())
+		}
+
+		return ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+// CreateAuthenticationTokens issues a token pair bound to payload.SessionID,
+// starting a new session when none is given. Only the tokens of that session
+// are replaced, other sessions of the same user are left untouched. The
+// id_token is issued alongside, its auth_time is the creation of the session.
+func (ts *TokenService) CreateAuthenticationTokens(payload TokenPayload) (*AuthenticationTokens, error) {
+	accessTokenExp := AccessTokenLifetime()
+	refreshTokenExp := RefreshTokenLifetime()
 
-	accessTokenExp := time.Duration(env.JWT_ACCESS_EXPIRED) * time.Minute           // per minute
-	refreshTokenExp := time.Duration(env.JWT_REFRESH_EXPIRED) * time.Hour * 24 * 30 // per month
+	if payload.SessionID == "" {
+		payload.SessionID = uuid.NewString()
+	}
 
 	accessToken, refreshToken, err := generateAuthenticationTokens(payload, accessTokenExp, refreshTokenExp)
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	ts.redis.GetAndDelValue(NewAccessTokenKey(payload.UserID))
-	ts.redis.GetAndDelValue(NewRefreshTokenKey(payload.UserID))
+	if err := ts.redis.SetValue(NewAccessTokenKey(payload.UserID, payload.SessionID), accessToken.Token, accessTokenExp); err != nil {
+		log.Printf("%s", err.Error())
+		return nil, ErrCreatingToken
+	}
 
-	if err := ts.redis.SetValue(NewAccessTokenKey(payload.UserID), accessToken.Token, accessTokenExp); err != nil {
+	if err := ts.redis.SetValue(NewRefreshTokenKey(payload.UserID, payload.SessionID), refreshToken.Token, refreshTokenExp); err != nil {
 		log.Printf("%s", err.Error())
-		return nil, nil, ErrCreatingToken
+		return nil, ErrCreatingToken
 	}
 
-	if err := ts.redis.SetValue(NewRefreshTokenKey(payload.UserID), refreshToken.Token, refreshTokenExp); err != nil {
+	session, err := ts.storeSession(payload, refreshTokenExp)
+
+	if err != nil {
 		log.Printf("%s", err.Error())
-		return nil, nil, ErrCreatingToken
+		return nil, ErrCreatingToken
 	}
 
-	return accessToken, refreshToken, nil
+	payload.Duration = accessTokenExp
+	payload.ClientID = session.ClientID
+	idToken, err := CreateIDToken(payload, session.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthenticationTokens{
+		SessionID: payload.SessionID,
+		Access:    accessToken,
+		Refresh:   refreshToken,
+		ID:        idToken,
+	}, nil
+}
+
+// CreateMachineToken issues the access token of a registered client. It isn't
+// stored, the token is short lived and only checked for its signature, type
+// and scope.
+func (ts *TokenService) CreateMachineToken(clientID string, scope []string) (*JwtToken, error) {
+	return CreateJwtToken(TokenPayload{
+		UserID:   clientID,
+		Scope:    scope,
+		Duration: AccessTokenLifetime(),
+		Type:     Machine,
+	})
+}
+
+func (ts *TokenService) storeSession(payload TokenPayload, expiration time.Duration) (*Session, error) {
+	now := time.Now()
+
+	session, err := ts.getSession(payload.UserID, payload.SessionID)
+
+	if err != nil {
+		session = &Session{
+			ID:        payload.SessionID,
+			CreatedAt: now,
+		}
+	}
+
+	if payload.Device.Name != "" {
+		session.Device = payload.Device.Name
+	}
+
+	if payload.Device.IP != "" {
+		session.IP = payload.Device.IP
+	}
+
+	if payload.Device.UserAgent != "" {
+		session.UserAgent = payload.Device.UserAgent
+	}
+
+	if payload.ClientID != "" {
+		session.ClientID = payload.ClientID
+	}
+
+	session.LastUsedAt = now
+
+	rawSession, err := json.Marshal(session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ts.redis.SetValue(NewSessionKey(payload.UserID, payload.SessionID), string(rawSession), expiration); err != nil {
+		return nil, err
+	}
+
+	// the set lives as long as its newest session, sessions that expired on
+	// their own are dropped from it when it is read
+	if err := ts.redis.AddToSet(NewSessionsKey(payload.UserID), expiration, payload.SessionID); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (ts *TokenService) getSession(authId, sessionId string) (*Session, error) {
+	rawSession, err := ts.redis.GetValue(NewSessionKey(authId, sessionId))
+
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	var session Session
+
+	if err := json.Unmarshal([]byte(rawSession), &session); err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	return &session, nil
+}
+
+// GetSessions returns the active sessions of a user, most recently used
+// first. Sessions whose refresh token already expired are pruned from the
+// index on the way.
+func (ts *TokenService) GetSessions(authId string) ([]Session, error) {
+	sessionIds, err := ts.redis.GetSetMembers(NewSessionsKey(authId))
+
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(sessionIds))
+
+	for _, sessionId := range sessionIds {
+		session, err := ts.getSession(authId, sessionId)
+
+		if err != nil {
+			ts.redis.RemoveFromSet(NewSessionsKey(authId), sessionId)
+			continue
+		}
+
+		sessions = append(sessions, *session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+func (ts *TokenService) RevokeSession(authId, sessionId string) error {
+	if _, err := ts.getSession(authId, sessionId); err != nil {
+		return err
+	}
+
+	return ts.deleteSession(authId, sessionId)
+}
+
+func (ts *TokenService) RevokeSessions(authId string, except ...string) error {
+	sessionIds, err := ts.redis.GetSetMembers(NewSessionsKey(authId))
+
+	if err != nil {
+		return err
+	}
+
+	for _, sessionId := range sessionIds {
+		if slices.Contains(except, sessionId) {
+			continue
+		}
+
+		if err := ts.deleteSession(authId, sessionId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ts *TokenService) deleteSession(authId, sessionId string) error {
+	if err := ts.redis.DelValue(
+		NewAccessTokenKey(authId, sessionId),
+		NewRefreshTokenKey(authId, sessionId),
+		NewSessionKey(authId, sessionId),
+	); err != nil {
+		return err
+	}
+
+	return ts.redis.RemoveFromSet(NewSessionsKey(authId), sessionId)
 }
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/config"
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+)
+
+type (
+	ITOTPService interface {
+		Verify(authID, secret, code string) (bool, error)
+		AttemptsExceeded(authID string) bool
+		RegisterFailure(authID string) error
+		ResetAttempts(authID string) error
+	}
+
+	TOTPService struct {
+		redis interfaces.Redis
+	}
+)
+
+const (
+	// RFC 6238 defaults, the only parameters every authenticator app supports
+	TOTPPeriod = 30 * time.Second
+	TOTPDigits = 6
+
+	// codes of the previous and next period are accepted for clock drift
+	totpSkew        = 1
+	totpSecretBytes = 20
+	totpCounterKey  = "totp_counter"
+
+	// a signed in user sending codes to change the second factor is refused
+	// after this many wrong ones within TOTPAttemptsWindow, mfa challenges
+	// count theirs per token instead
+	MaxTOTPAttempts    = 5
+	TOTPAttemptsWindow = 15 * time.Minute
+	totpAttemptsKey    = "totp_attempts"
+)
+
+var (
+	ErrMissingMFAKey = errors.New("MFA_ENCRYPTION_KEY is not configured")
+
+	totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+func NewTOTPService(redis interfaces.Redis) *TOTPService {
+	return &TOTPService{
+		redis: redis,
+	}
+}
+
+// NewTOTPCounterKey keeps the last period a user signed in with, a code
+// can't be replayed within its window.
+func NewTOTPCounterKey(authID string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(authID, totpCounterKey)
+}
+
+func NewTOTPAttemptsKey(authID string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(authID, totpAttemptsKey)
+}
+
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretBytes)
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPProvisioningURI is the otpauth uri authenticator apps read from a QR
+// code.
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(TOTPDigits))
+	query.Set("period", strconv.Itoa(int(TOTPPeriod.Seconds())))
+
+	label := url.PathEscape(fmt.Sprintf("%s:%s", issuer, account))
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// GenerateTOTPCode is the code of the period at t (RFC 6238 section 4).
+func GenerateTOTPCode(secret string, t time.Time) (string, error) {
+	return totpCode(secret, totpCounter(t))
+}
+
+func totpCounter(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// totpCode is the HOTP value of a counter (RFC 4226 section 5.3).
+func totpCode(secret string, counter int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+
+	if err != nil {
+		return "", err
+	}
+
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for range TOTPDigits {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", TOTPDigits, value%modulo), nil
+}
+
+// ValidateTOTP returns the counter of the period the code belongs to, codes
+// one period off are accepted for clock drift.
+func ValidateTOTP(secret, code string, t time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	current := totpCounter(t)
+
+	for counter := current - totpSkew; counter <= current+totpSkew; counter++ {
+		expected, err := totpCode(secret, counter)
+
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+
+	return 0, false
+}
+
+// Verify checks a code of a user and refuses codes of a period the user
+// already signed in with.
+func (ts *TOTPService) Verify(authID, secret, code string) (bool, error) {
+	counter, ok := ValidateTOTP(secret, code, time.Now())
+
+	if !ok {
+		return false, nil
+	}
+
+	key := NewTOTPCounterKey(authID)
+
+	if raw, err := ts.redis.GetValue(key); err == nil && raw != "" {
+		if last, err := strconv.ParseInt(raw, 10, 64); err == nil && counter <= last {
+			return false, nil
+		}
+	}
+
+	if err := ts.redis.SetValue(key, counter, (2*totpSkew+1)*TOTPPeriod); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// AttemptsExceeded fails open, an unreachable redis doesn't lock users out
+// of their settings.
+func (ts *TOTPService) AttemptsExceeded(authID string) bool {
+	raw, err := ts.redis.GetValue(NewTOTPAttemptsKey(authID))
+
+	if err != nil || raw == "" {
+		return false
+	}
+
+	attempts, err := strconv.ParseInt(raw, 10, 64)
+	return err == nil && attempts >= MaxTOTPAttempts
+}
+
+// RegisterFailure counts a wrong code, every one of them restarts the
+// window.
+func (ts *TOTPService) RegisterFailure(authID string) error {
+	_, err := ts.redis.Increment(NewTOTPAttemptsKey(authID), TOTPAttemptsWindow)
+	return err
+}
+
+func (ts *TOTPService) ResetAttempts(authID string) error {
+	return ts.redis.DelValue(NewTOTPAttemptsKey(authID))
+}
+
+// mfaKey derives the AES key of the stored secrets from MFA_ENCRYPTION_KEY,
+// so it can be any passphrase.
+func mfaKey() ([]byte, error) {
+	key := config.GetConfig().MFA_ENCRYPTION_KEY
+
+	if key == "" {
+		return nil, ErrMissingMFAKey
+	}
+
+	hash := sha256.Sum256([]byte(key))
+	return hash[:], nil
+}
+
+func EncryptTOTPSecret(secret string) (string, error) {
+	key, err := mfaKey()
+
+	if err != nil {
+		return "", err
+	}
+
+	return AesEncrypt([]byte(secret), key)
+}
+
+// HashRecoveryCode is a HMAC-SHA256 keyed from MFA_ENCRYPTION_KEY. Recovery
+// codes are random, unlike passwords they don't need a slow hash, and the
+// key keeps a leaked table from being brute forced offline.
+func HashRecoveryCode(code string) (string, error) {
+	key, err := mfaKey()
+
+	if err != nil {
+		return "", err
+	}
+
+	// a key of its own, the aes key of the secrets isn't reused
+	derive := hmac.New(sha256.New, key)
+	derive.Write([]byte("recovery-code"))
+
+	mac := hmac.New(sha256.New, derive.Sum(nil))
+	mac.Write([]byte(code))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func DecryptTOTPSecret(encryptedSecret string) (string, error) {
+	key, err := mfaKey()
+
+	if err != nil {
+		return "", err
+	}
+
+	return AesDecrypt(encryptedSecret, key)
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/BeatEcoprove/identityService/config"
+	interfaces "github.com/BeatEcoprove/identityService/pkg/adapters"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+type (
+	IWebAuthnService interface {
+		BeginRegistration(user webauthn.User) (*protocol.CredentialCreation, error)
+		FinishRegistration(user webauthn.User, response []byte) (*webauthn.Credential, error)
+		BeginLogin() (*protocol.CredentialAssertion, error)
+		FinishLogin(handler webauthn.DiscoverableUserHandler, response []byte) (*webauthn.Credential, error)
+	}
+
+	WebAuthnService struct {
+		redis interfaces.Redis
+	}
+)
+
+const (
+	// how long the browser has to answer a registration or login ceremony
+	WebAuthnCeremonyLifetime = 5 * time.Minute
+
+	webAuthnRegistrationKey = "webauthn_registration"
+	webAuthnLoginKey        = "webauthn_login"
+)
+
+var (
+	ErrMissingWebAuthnConfig   = errors.New("WEBAUTHN_RP_ID and WEBAUTHN_RP_ORIGINS are not configured")
+	ErrInvalidWebAuthnCeremony = errors.New("invalid webauthn ceremony")
+)
+
+func NewWebAuthnService(redis interfaces.Redis) *WebAuthnService {
+	return &WebAuthnService{
+		redis: redis,
+	}
+}
+
+// NewWebAuthnRegistrationKey holds the pending registration of a user, a
+// new one replaces it.
+func NewWebAuthnRegistrationKey(authID string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(authID, webAuthnRegistrationKey)
+}
+
+// NewWebAuthnLoginKey holds a pending login by its challenge, the browser
+// signs it back inside the client data.
+func NewWebAuthnLoginKey(challenge string) interfaces.RedisKey {
+	return interfaces.NewRedisKey(webAuthnLoginKey, challenge)
+}
+
+// relyingParty builds the ceremonies from WEBAUTHN_RP_ID and
+// WEBAUTHN_RP_ORIGINS, passkeys only work on the configured domain.
+func relyingParty() (*webauthn.WebAuthn, error) {
+	cfg := config.GetConfig()
+
+	var origins []string
+
+	for _, origin := range strings.Split(cfg.WEBAUTHN_RP_ORIGINS, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	if cfg.WEBAUTHN_RP_ID == "" || len(origins) == 0 {
+		return nil, ErrMissingWebAuthnConfig
+	}
+
+	name := cfg.WEBAUTHN_RP_NAME
+
+	if name == "" {
+		name = cfg.WEBAUTHN_RP_ID
+	}
+
+	timeout := webauthn.TimeoutConfig{
+		Enforce:    true,
+		Timeout:    WebAuthnCeremonyLifetime,
+		TimeoutUVD: WebAuthnCeremonyLifetime,
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          cfg.WEBAUTHN_RP_ID,
+		RPDisplayName: name,
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			UserVerification:   protocol.VerificationRequired,
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+}
+
+// BeginRegistration asks for a discoverable credential with user
+// verification, the passkey alone is enough to sign in.
+func (ws *WebAuthnService) BeginRegistration(user webauthn.User) (*protocol.CredentialCreation, error) {
+	relyingParty, err := relyingParty()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var exclusions []protocol.CredentialDescriptor
+
+	for _, credential := range user.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, session, err := relyingParty.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ws.storeSession(NewWebAuthnRegistrationKey(string(user.WebAuthnID())), session); err != nil {
+		return nil, err
+	}
+
+	return creation, nil
+}
+
+func (ws *WebAuthnService) FinishRegistration(user webauthn.User, response []byte) (*webauthn.Credential, error) {
+	relyingParty, err := relyingParty()
+
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(response))
+
+	if err != nil {
+		return nil, ErrInvalidWebAuthnCeremony
+	}
+
+	session, err := ws.takeSession(NewWebAuthnRegistrationKey(string(user.WebAuthnID())))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return relyingParty.CreateCredential(user, *session, parsed)
+}
+
+// BeginLogin starts a login without a username, the authenticator offers
+// the passkeys it holds for the domain.
+func (ws *WebAuthnService) BeginLogin() (*protocol.CredentialAssertion, error) {
+	relyingParty, err := relyingParty()
+
+	if err != nil {
+		return nil, err
+	}
+
+	assertion, session, err := relyingParty.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ws.storeSession(NewWebAuthnLoginKey(session.Challenge), session); err != nil {
+		return nil, err
+	}
+
+	return assertion, nil
+}
+
+// FinishLogin finds the pending login by the challenge the authenticator
+// signed, each challenge is good for a single assertion.
+func (ws *WebAuthnService) FinishLogin(handler webauthn.DiscoverableUserHandler, response []byte) (*webauthn.Credential, error) {
+	relyingParty, err := relyingParty()
+
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+
+	if err != nil {
+		return nil, ErrInvalidWebAuthnCeremony
+	}
+
+	session, err := ws.takeSession(NewWebAuthnLoginKey(parsed.Response.CollectedClientData.Challenge))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return relyingParty.ValidateDiscoverableLogin(handler, *session, parsed)
+}
+
+func (ws *WebAuthnService) storeSession(key interfaces.RedisKey, session *webauthn.SessionData) error {
+	rawSession, err := json.Marshal(session)
+
+	if err != nil {
+		return err
+	}
+
+	if err := ws.redis.SetValue(key, string(rawSession), WebAuthnCeremonyLifetime); err != nil {
+		return ErrCreatingToken
+	}
+
+	return nil
+}
+
+func (ws *WebAuthnService) takeSession(key interfaces.RedisKey) (*webauthn.SessionData, error) {
+	rawSession, err := ws.redis.GetAndDelValue(key)
+
+	if err != nil || rawSession == "" {
+		return nil, ErrInvalidWebAuthnCeremony
+	}
+
+	var session webauthn.SessionData
+
+	if err := json.Unmarshal([]byte(rawSession), &session); err != nil {
+		return nil, ErrInvalidWebAuthnCeremony
+	}
+
+	return &session, nil
+}
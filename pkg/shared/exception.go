@@ -27,6 +27,15 @@ func NewBadRequestError(id, title string, details map[string]string) *Validation
 	}
 }
 
+func NewBadRequest(id, title, detail string) *Error {
+	return &Error{
+		Id:     id,
+		Status: fiber.StatusBadRequest,
+		Title:  title,
+		Detail: detail,
+	}
+}
+
 func NewUnauthorizedError(id, title, detail string) *Error {
 	return &Error{
 		Id:     id,
@@ -63,6 +72,24 @@ func NewConflitError(id, title, detail string) *Error {
 	}
 }
 
+func NewLockedError(id, title, detail string) *Error {
+	return &Error{
+		Id:     id,
+		Status: fiber.StatusLocked,
+		Title:  title,
+		Detail: detail,
+	}
+}
+
+func NewTooManyRequestsError(id, title, detail string) *Error {
+	return &Error{
+		Id:     id,
+		Status: fiber.StatusTooManyRequests,
+		Title:  title,
+		Detail: detail,
+	}
+}
+
 func NewUnsupportedMediaError(id, title, detail string) *Error {
 	return &Error{
 		Id:     id,
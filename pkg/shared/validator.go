@@ -12,9 +12,26 @@ var validate = validator.New()
 
 var (
 	messages = map[string]string{
-		"email":    "Email is required and must be valid.",
-		"password": "Password is required and must be at least 8 characters long.",
-		"role":     "Role is required and must be a positive number.",
+		"email":           "Email is required and must be valid.",
+		"password":        "Password is required.",
+		"role":            "Role is required and must be a positive number.",
+		"token":           "Token is required.",
+		"tokentypehint":   "Token type hint must be either access_token or refresh_token.",
+		"code":            "Code is required.",
+		"codeverifier":    "Code verifier is required and must be between 43 and 128 characters long.",
+		"codechallenge":   "Code challenge is required.",
+		"clientid":        "Client id is required.",
+		"redirecturi":     "Redirect uri is required and must be a valid url.",
+		"responsetype":    "Response type is required.",
+		"mfatoken":        "Mfa token is required.",
+		"otp":             "Otp is required and must be a 6 digit code.",
+		"recoverycode":    "Recovery code is required when no otp is sent.",
+		"credential":      "Credential is required and must be the public key credential of the browser.",
+		"name":            "Name must be at most 64 characters long.",
+		"provider":        "Provider is required.",
+		"idtoken":         "Id token is required.",
+		"currentpassword": "Current password is required.",
+		"newpassword":     "New password is required.",
 	}
 )
 